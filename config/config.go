@@ -2,6 +2,7 @@ package config
 
 import (
 	"log"
+	"strings"
 
 	"github.com/spf13/viper"
 )
@@ -12,6 +13,11 @@ func InitConfig() {
 	viper.AddConfigPath("./config")
 	viper.AddConfigPath(".")
 
+	// Secrets are kept out of the file, any key can be set from the environment instead, with
+	// dots as underscores: ADMIN_TOKENS for admin.tokens
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.AutomaticEnv()
+
 	if err := viper.ReadInConfig(); err != nil {
 		log.Panicf("Error reading config file: %v", err)
 	}
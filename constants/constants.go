@@ -3,16 +3,119 @@ package constants
 import "time"
 
 const (
-	RequestID                = "request_id"
-	Env                      = "env"
-	Consistency              = "consistency"
-	EventualConsistency      = "eventual"
-	StrongConsistency        = "strong"
-	UserID                   = "user_id"
-	OneMinute                = time.Minute
-	OneHour                  = OneMinute * 60
-	OneDay                   = OneHour * 24
-	TopLeaderboardLimit      = 10
-	LeaderboardTopKeyFormat  = "leaderboard:top:%d"
-	LeaderboardUserKeyFormat = "leaderboard:user:%s"
+	RequestID                   = "request_id"
+	Env                         = "env"
+	Consistency                 = "consistency"
+	EventualConsistency         = "eventual"
+	StrongConsistency           = "strong"
+	UserID                      = "user_id"
+	OneMinute                   = time.Minute
+	OneHour                     = OneMinute * 60
+	OneDay                      = OneHour * 24
+	GameMode                    = "game_mode"
+	GameModeSolo                = "solo"
+	GameModeTeam                = "team"
+	GameModeOverall             = "overall"
+	Board                       = "board"
+	BoardID                     = "board_id"
+	AggregationSum              = "sum"
+	AggregationBest             = "best"
+	AggregationLatest           = "latest"
+	AggregationAverage          = "average"
+	AggregationBestN            = "best_n"
+	RankCompetition             = "competition"
+	RankDense                   = "dense"
+	RankOrdinal                 = "ordinal"
+	TieBreakReachedFirst        = "reached_first"
+	TieBreakUserID              = "user_id"
+	SplitSum                    = "sum"
+	SplitAverage                = "average"
+	SplitTop                    = "top"
+	DefinitionKindBoard         = "board"
+	DefinitionKindTeam          = "team"
+	TierBasisPercentile         = "percentile"
+	TierBasisScore              = "score"
+	SortDescending              = "desc"
+	SortAscending               = "asc"
+	Window                      = "window"
+	TimeWindow                  = "time_window"
+	RecordedOn                  = "recorded_on"
+	PeriodStart                 = "period_start"
+	WindowDaily                 = "daily"
+	WindowWeekly                = "weekly"
+	WindowMonthly               = "monthly"
+	WindowAllTime               = "all_time"
+	SeasonID                    = "season_id"
+	Status                      = "status"
+	SeasonStatusActive          = "active"
+	SeasonStatusClosed          = "closed"
+	LeaderboardPendingKey       = "leaderboard:pending"
+	LeaderboardWorkerLockKey    = "leaderboard:worker:lock"
+	LeaderboardWorkerFencingKey = "leaderboard:worker:fencing"
+	PendingFirstField           = "first"
+	PendingLastField            = "last"
+	PendingFullField            = "full"
+	SeasonBoundaryKey           = "season:boundary"
+	AdminTokenHeader            = "X-Admin-Token"
+	AdminActor                  = "admin_actor"
+	LegacyAdminName             = "admin"
+	IdempotencyKeyHeader        = "Idempotency-Key"
+	IdempotentReplayedHeader    = "Idempotent-Replayed"
+	SubmissionCreated           = "created"
+	SubmissionReplayed          = "replayed"
+	SubmissionRejected          = "rejected"
+	SubmissionQuarantined       = "quarantined"
+	SessionStatusAccepted       = "accepted"
+	SessionStatusQuarantined    = "quarantined"
+	SessionStatusRejected       = "rejected"
+	ReviewApprove               = "approve"
+	ReviewReject                = "reject"
+	SessionID                   = "session_id"
+	TeamID                      = "team_id"
+	FriendID                    = "friend_id"
+	FriendshipMutual            = "mutual"
+	FriendshipFollow            = "follow"
+	Region                      = "region"
+	GameID                      = "game_id"
+	APIKeyHeader                = "X-API-Key"
+	PublicSchema                = "public"
+	GameSchemaFormat            = "game_%s"
+	GameKeyPrefixFormat         = "game:%s:"
+	BanKindBan                  = "ban"
+	BanKindShadow               = "shadow"
+	ModerationVoidSession       = "void_session"
+	ModerationRestoreSession    = "restore_session"
+	ModerationBanUser           = "ban_user"
+	ModerationShadowBanUser     = "shadow_ban_user"
+	ModerationUnbanUser         = "unban_user"
+	ModerationApproveSession    = "approve_session"
+	ModerationRejectSession     = "reject_session"
+	ModerationTargetSession     = "session"
+	ModerationTargetUser        = "user"
+	PlaceholderSecret           = "change-me"
+	DefaultPageLimit            = 50
+	TopLeaderboardLimit         = 10
+	MaxBoardSizeCap             = 100
+	BoardsReloadInterval        = 15 * time.Second
+	RejectedAPIKeyTTL           = 10 * time.Second
+	MaxRejectedAPIKeys          = 10000
+	DefaultHistoryDays          = 30
+	MaxHistoryDays              = 366
+	DefaultAroundRadius         = 5
+	LeaderboardTopKeyFormat     = "leaderboard:top:%s:%d"
+	LeaderboardUserKeyFormat    = "leaderboard:user:%s:%s"
+	LeaderboardAroundKeyFormat  = "leaderboard:around:%s:%s:%d"
+	RegionTopKeyFormat          = "leaderboard:top:%s:region:%s:%d"
+	TeamTopKeyFormat            = "leaderboard:teams:top:%s:%d"
+	UserProfileKeyFormat        = "user:profile:%d"
+	SubmissionRateKeyFormat     = "submissions:rate:%d:%d"
+	RankingKeyFormat            = "leaderboard:ranking:%s"
+	RankingScoresKeyFormat      = "leaderboard:ranking:%s:scores"
+	RankingReachedKeyFormat     = "leaderboard:ranking:%s:reached"
+	RankingRebuildKeyFormat     = "%s:rebuild:%d"
+	RankingJournalKeyFormat     = "%s:journal"
+	RankingRebuildBatchSize     = 5000
+	RankingJournalTTL           = 15 * time.Minute
+	RankingDriftSampleSize      = 100
+	RankingTieGroupLimit        = 1000
 )
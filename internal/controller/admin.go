@@ -0,0 +1,237 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/controller/request"
+	gameSessionsSvc "gaming-leaderboard/internal/game_sessions/service"
+	leaderboardSvc "gaming-leaderboard/internal/leaderboard/service"
+	"gaming-leaderboard/internal/models"
+	"gaming-leaderboard/pkg/apperror"
+	"gaming-leaderboard/pkg/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminController struct {
+	gameSessionsService *gameSessionsSvc.GameSessionsService
+	leaderboardWorker   *leaderboardSvc.LeaderboardWorker
+}
+
+func NewAdminController(
+	gameSessionsService *gameSessionsSvc.GameSessionsService,
+	leaderboardWorker *leaderboardSvc.LeaderboardWorker,
+) *AdminController {
+	return &AdminController{
+		gameSessionsService: gameSessionsService,
+		leaderboardWorker:   leaderboardWorker,
+	}
+}
+
+func (c *AdminController) RecalculateLeaderboard(ctx *gin.Context) {
+	// The body is optional, an empty one runs whichever recalculation is next due
+	var req request.ForceRecalculationRequest
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+			apperror.New(fmt.Errorf("invalid request body: %w", err), 400).AbortWithError(ctx)
+			return
+		}
+	}
+
+	summary, cusErr := c.leaderboardWorker.RecalculateNow(ctx, req.Full)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.OK(ctx, summary)
+	return
+}
+
+func (c *AdminController) GetWorkerLeadership(ctx *gin.Context) {
+	status, cusErr := c.leaderboardWorker.Leadership(ctx)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.OK(ctx, status)
+	return
+}
+
+func (c *AdminController) GetQuarantinedSessions(ctx *gin.Context) {
+	var query request.QuarantineQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		apperror.New(fmt.Errorf("invalid query params: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.Limit == 0 {
+		query.Limit = constants.DefaultPageLimit
+	}
+
+	sessions, total, cusErr := c.gameSessionsService.ListQuarantinedSessions(ctx, query.Page, query.Limit)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.OKWithMeta(ctx, sessions, response.NewPaginationMeta(query.Page, query.Limit, total))
+	return
+}
+
+func (c *AdminController) ReviewSession(ctx *gin.Context) {
+	sessionID, err := strconv.Atoi(ctx.Param(constants.SessionID))
+	if err != nil {
+		apperror.New(fmt.Errorf("invalid session id: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	var req request.ReviewSessionRequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+		apperror.New(fmt.Errorf("invalid request body: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	session, cusErr := c.gameSessionsService.ReviewSession(ctx, sessionID, ctx.GetString(constants.AdminActor), req)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.OK(ctx, session)
+	return
+}
+
+func (c *AdminController) VoidSession(ctx *gin.Context) {
+	c.moderateSession(ctx, c.gameSessionsService.VoidSession)
+}
+
+func (c *AdminController) RestoreSession(ctx *gin.Context) {
+	c.moderateSession(ctx, c.gameSessionsService.RestoreSession)
+}
+
+func (c *AdminController) moderateSession(
+	ctx *gin.Context,
+	moderate func(context.Context, int, string, request.ModerationRequest) (models.GameSession, apperror.Error),
+) {
+	sessionID, err := strconv.Atoi(ctx.Param(constants.SessionID))
+	if err != nil {
+		apperror.New(fmt.Errorf("invalid session id: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	var req request.ModerationRequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+		apperror.New(fmt.Errorf("invalid request body: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	session, cusErr := moderate(ctx, sessionID, ctx.GetString(constants.AdminActor), req)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.OK(ctx, session)
+	return
+}
+
+func (c *AdminController) BanUser(ctx *gin.Context) {
+	userID, err := strconv.Atoi(ctx.Param(constants.UserID))
+	if err != nil {
+		apperror.New(fmt.Errorf("invalid user id: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	var req request.BanUserRequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+		apperror.New(fmt.Errorf("invalid request body: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	ban, cusErr := c.gameSessionsService.BanUser(ctx, userID, ctx.GetString(constants.AdminActor), req)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.OK(ctx, ban)
+	return
+}
+
+func (c *AdminController) UnbanUser(ctx *gin.Context) {
+	userID, err := strconv.Atoi(ctx.Param(constants.UserID))
+	if err != nil {
+		apperror.New(fmt.Errorf("invalid user id: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	var req request.ModerationRequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+		apperror.New(fmt.Errorf("invalid request body: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	ban, cusErr := c.gameSessionsService.UnbanUser(ctx, userID, ctx.GetString(constants.AdminActor), req)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.OK(ctx, ban)
+	return
+}
+
+func (c *AdminController) GetBans(ctx *gin.Context) {
+	var query request.BansQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		apperror.New(fmt.Errorf("invalid query params: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.Limit == 0 {
+		query.Limit = constants.DefaultPageLimit
+	}
+
+	bans, total, cusErr := c.gameSessionsService.ListBans(ctx, query.Page, query.Limit)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.OKWithMeta(ctx, bans, response.NewPaginationMeta(query.Page, query.Limit, total))
+	return
+}
+
+func (c *AdminController) GetModerationLog(ctx *gin.Context) {
+	var query request.ModerationLogQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		apperror.New(fmt.Errorf("invalid query params: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.Limit == 0 {
+		query.Limit = constants.DefaultPageLimit
+	}
+
+	actions, total, cusErr := c.gameSessionsService.ListModerationActions(ctx, query)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.OKWithMeta(ctx, actions, response.NewPaginationMeta(query.Page, query.Limit, total))
+	return
+}
@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"fmt"
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/controller/request"
+	leaderboardSvc "gaming-leaderboard/internal/leaderboard/service"
+	"gaming-leaderboard/pkg/apperror"
+	"gaming-leaderboard/pkg/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BoardsController struct {
+	definitionsService *leaderboardSvc.DefinitionsService
+}
+
+func NewBoardsController(
+	definitionsService *leaderboardSvc.DefinitionsService,
+) *BoardsController {
+	return &BoardsController{
+		definitionsService: definitionsService,
+	}
+}
+
+func (c *BoardsController) ListBoards(ctx *gin.Context) {
+	definitions, cusErr := c.definitionsService.ListDefinitions(ctx)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.OK(ctx, definitions)
+	return
+}
+
+func (c *BoardsController) GetBoard(ctx *gin.Context) {
+	boardID, err := strconv.Atoi(ctx.Param(constants.BoardID))
+	if err != nil {
+		apperror.New(fmt.Errorf("invalid board id: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	definition, cusErr := c.definitionsService.GetDefinition(ctx, boardID)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.OK(ctx, definition)
+	return
+}
+
+func (c *BoardsController) CreateBoard(ctx *gin.Context) {
+	var req request.CreateLeaderboardDefinitionRequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+		apperror.New(fmt.Errorf("invalid request body: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	definition, cusErr := c.definitionsService.CreateDefinition(ctx, req)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.Created(ctx, definition)
+	return
+}
+
+func (c *BoardsController) UpdateBoard(ctx *gin.Context) {
+	boardID, err := strconv.Atoi(ctx.Param(constants.BoardID))
+	if err != nil {
+		apperror.New(fmt.Errorf("invalid board id: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	var req request.LeaderboardDefinitionRequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+		apperror.New(fmt.Errorf("invalid request body: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	definition, cusErr := c.definitionsService.UpdateDefinition(ctx, boardID, req)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.OK(ctx, definition)
+	return
+}
+
+func (c *BoardsController) DeleteBoard(ctx *gin.Context) {
+	boardID, err := strconv.Atoi(ctx.Param(constants.BoardID))
+	if err != nil {
+		apperror.New(fmt.Errorf("invalid board id: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	definition, cusErr := c.definitionsService.DeleteDefinition(ctx, boardID)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.OK(ctx, definition)
+	return
+}
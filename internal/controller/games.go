@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"fmt"
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/controller/request"
+	gamesSvc "gaming-leaderboard/internal/games/service"
+	"gaming-leaderboard/internal/models"
+	"gaming-leaderboard/pkg/apperror"
+	"gaming-leaderboard/pkg/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GameHost serves the games' APIs, it is told about every game created or changed here so
+// the change takes effect without waiting for its cached API keys to be checked again
+type GameHost interface {
+	Reload(game models.Game)
+}
+
+type GamesController struct {
+	gamesService *gamesSvc.GamesService
+	host         GameHost
+}
+
+func NewGamesController(
+	gamesService *gamesSvc.GamesService,
+	host GameHost,
+) *GamesController {
+	return &GamesController{
+		gamesService: gamesService,
+		host:         host,
+	}
+}
+
+func (c *GamesController) CreateGame(ctx *gin.Context) {
+	var req request.CreateGameRequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+		apperror.New(fmt.Errorf("invalid request body: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	credentials, cusErr := c.gamesService.CreateGame(ctx, req)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	c.host.Reload(credentials.Game)
+
+	response.Created(ctx, credentials)
+	return
+}
+
+func (c *GamesController) ListGames(ctx *gin.Context) {
+	var query request.GamesQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		apperror.New(fmt.Errorf("invalid query params: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	games, total, cusErr := c.gamesService.ListGames(ctx, query.Page, query.Limit)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.OKWithMeta(ctx, games, response.NewPaginationMeta(query.Page, query.Limit, total))
+	return
+}
+
+func (c *GamesController) GetGame(ctx *gin.Context) {
+	gameID, err := strconv.Atoi(ctx.Param(constants.GameID))
+	if err != nil {
+		apperror.New(fmt.Errorf("invalid game id: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	game, cusErr := c.gamesService.GetGame(ctx, gameID)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.OK(ctx, game)
+	return
+}
+
+func (c *GamesController) UpdateGame(ctx *gin.Context) {
+	gameID, err := strconv.Atoi(ctx.Param(constants.GameID))
+	if err != nil {
+		apperror.New(fmt.Errorf("invalid game id: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	var req request.UpdateGameRequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+		apperror.New(fmt.Errorf("invalid request body: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	game, cusErr := c.gamesService.UpdateGame(ctx, gameID, req)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	c.host.Reload(game)
+
+	response.OK(ctx, game)
+	return
+}
+
+func (c *GamesController) RotateAPIKey(ctx *gin.Context) {
+	gameID, err := strconv.Atoi(ctx.Param(constants.GameID))
+	if err != nil {
+		apperror.New(fmt.Errorf("invalid game id: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	credentials, cusErr := c.gamesService.RotateAPIKey(ctx, gameID)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	c.host.Reload(credentials.Game)
+
+	response.OK(ctx, credentials)
+	return
+}
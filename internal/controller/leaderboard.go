@@ -8,6 +8,7 @@ import (
 	leaderboardSvc "gaming-leaderboard/internal/leaderboard/service"
 	"gaming-leaderboard/pkg/apperror"
 	"gaming-leaderboard/pkg/response"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -34,18 +35,82 @@ func (c *LeaderboardController) CreateScore(ctx *gin.Context) {
 		return
 	}
 
-	cusErr := c.gameSessionsService.CreateGameSession(ctx, req)
+	if key := ctx.GetHeader(constants.IdempotencyKeyHeader); key != "" {
+		if len(key) > 255 || (req.ClientSessionID != "" && req.ClientSessionID != key) {
+			apperror.New(
+				fmt.Errorf("invalid %s header: must match client_session_id and be at most 255 characters", constants.IdempotencyKeyHeader),
+				400,
+			).AbortWithError(ctx)
+			return
+		}
+		req.ClientSessionID = key
+	}
+
+	session, replayed, cusErr := c.gameSessionsService.CreateGameSession(ctx, req)
+	if replayed {
+		ctx.Header(constants.IdempotentReplayedHeader, "true")
+	}
+
 	if cusErr.Exists() {
 		cusErr.AbortWithError(ctx)
 		return
 	}
 
-	response.OK(ctx, nil)
+	response.OK(ctx, session)
 	return
 }
 
+func (c *LeaderboardController) CreateScores(ctx *gin.Context) {
+	var req request.SubmitScoreBatchRequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+		apperror.New(fmt.Errorf("invalid request body: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	results, cusErr := c.gameSessionsService.CreateGameSessions(ctx, req.Entries)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.OKWithMeta(ctx, results, response.NewBatchMeta(batchStatuses(results)))
+	return
+}
+
+func batchStatuses(results []gameSessionsSvc.SubmissionResult) []string {
+	statuses := make([]string, 0, len(results))
+	for _, result := range results {
+		statuses = append(statuses, result.Status)
+	}
+
+	return statuses
+}
+
 func (c *LeaderboardController) GetTopLeaderboard(ctx *gin.Context) {
-	leaders, cusErr := c.leaderboardService.GetTopLeaderboards(ctx)
+	var query request.TopQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		apperror.New(fmt.Errorf("invalid query params: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	scope, cusErr := c.leaderboardService.ResolveScope(ctx, query.LeaderboardQuery)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	if query.Region != "" {
+		leaders, cusErr := c.leaderboardService.GetRegionalTopLeaderboards(ctx, scope, query.Region)
+		if cusErr.Exists() {
+			cusErr.AbortWithError(ctx)
+			return
+		}
+
+		response.OK(ctx, leaders)
+		return
+	}
+
+	leaders, cusErr := c.leaderboardService.GetTopLeaderboards(ctx, scope)
 	if cusErr.Exists() {
 		cusErr.AbortWithError(ctx)
 		return
@@ -55,8 +120,47 @@ func (c *LeaderboardController) GetTopLeaderboard(ctx *gin.Context) {
 	return
 }
 
+func (c *LeaderboardController) GetLeaderboard(ctx *gin.Context) {
+	var query request.BrowseQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		apperror.New(fmt.Errorf("invalid query params: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	if query.Limit == 0 {
+		query.Limit = constants.DefaultPageLimit
+	}
+
+	scope, cusErr := c.leaderboardService.ResolveScope(ctx, query.LeaderboardQuery)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	leaders, next, cusErr := c.leaderboardService.GetLeaderboardPage(ctx, scope, query.Limit, query.Cursor)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.OKWithMeta(ctx, leaders, response.NewCursorMeta(query.Limit, next))
+	return
+}
+
 func (c *LeaderboardController) GetUserRankByUserID(ctx *gin.Context) {
-	rank, cusErr := c.leaderboardService.GetUserRankByUserID(ctx, ctx.Param(constants.UserID))
+	var query request.LeaderboardQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		apperror.New(fmt.Errorf("invalid query params: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	scope, cusErr := c.leaderboardService.ResolveScope(ctx, query)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	rank, cusErr := c.leaderboardService.GetUserStanding(ctx, ctx.Param(constants.UserID), scope)
 	if cusErr.Exists() {
 		cusErr.AbortWithError(ctx)
 		return
@@ -65,3 +169,99 @@ func (c *LeaderboardController) GetUserRankByUserID(ctx *gin.Context) {
 	response.OK(ctx, rank)
 	return
 }
+
+func (c *LeaderboardController) GetBoardTiers(ctx *gin.Context) {
+	var query request.LeaderboardQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		apperror.New(fmt.Errorf("invalid query params: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	scope, cusErr := c.leaderboardService.ResolveScope(ctx, query)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	tiers, cusErr := c.leaderboardService.GetBoardTiers(ctx, scope)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.OK(ctx, tiers)
+	return
+}
+
+func (c *LeaderboardController) GetUserNeighbours(ctx *gin.Context) {
+	var query request.AroundQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		apperror.New(fmt.Errorf("invalid query params: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	if query.Radius == 0 {
+		query.Radius = constants.DefaultAroundRadius
+	}
+
+	scope, cusErr := c.leaderboardService.ResolveScope(ctx, query.LeaderboardQuery)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	neighbours, cusErr := c.leaderboardService.GetUserNeighbours(ctx, ctx.Param(constants.UserID), scope, query.Radius)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.OK(ctx, neighbours)
+	return
+}
+
+func (c *LeaderboardController) GetRankHistory(ctx *gin.Context) {
+	var query request.RankHistoryQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		apperror.New(fmt.Errorf("invalid query params: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	history, cusErr := c.leaderboardService.GetRankHistory(ctx, ctx.Param(constants.UserID), query)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.OK(ctx, history)
+	return
+}
+
+func (c *LeaderboardController) GetFriendsLeaderboard(ctx *gin.Context) {
+	userID, err := strconv.Atoi(ctx.Param(constants.UserID))
+	if err != nil {
+		apperror.New(fmt.Errorf("invalid user id: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	var query request.LeaderboardQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		apperror.New(fmt.Errorf("invalid query params: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	scope, cusErr := c.leaderboardService.ResolveScope(ctx, query)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	friends, cusErr := c.leaderboardService.GetFriendsLeaderboard(ctx, userID, scope)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.OK(ctx, friends)
+	return
+}
@@ -1,7 +1,199 @@
 package request
 
+import (
+	"time"
+
+	"gaming-leaderboard/internal/models"
+)
+
 type SubmitScoreRequest struct {
 	UserID   int    `json:"user_id" binding:"required,gt=0"`
 	Score    int    `json:"score" binding:"required,gt=0"`
 	GameMode string `json:"game_mode" binding:"required,oneof=solo team"`
+	// ClientSessionID makes retries safe, the Idempotency-Key header sets it too
+	ClientSessionID string `json:"client_session_id" binding:"omitempty,max=255"`
+	// SessionToken is the token POST /sessions/start issued for the play session
+	SessionToken string `json:"session_token" binding:"required,max=1024"`
+}
+
+type StartSessionRequest struct {
+	UserID   int    `json:"user_id" binding:"required,gt=0"`
+	GameMode string `json:"game_mode" binding:"required,oneof=solo team"`
+}
+
+// SubmitScoreBatchRequest entries are validated one by one, an invalid entry only rejects itself
+type SubmitScoreBatchRequest struct {
+	Entries []SubmitScoreRequest `json:"entries" binding:"required,min=1"`
+}
+
+type LeaderboardQuery struct {
+	Board  string `form:"board" binding:"omitempty,max=64"`
+	Mode   string `form:"mode" binding:"omitempty,oneof=solo team overall"`
+	Window string `form:"window" binding:"omitempty,oneof=daily weekly monthly all_time"`
+	Period string `form:"period" binding:"omitempty,datetime=2006-01-02"`
+}
+
+type TopQuery struct {
+	LeaderboardQuery
+	// Region lists the board among the sessions submitted from one country
+	Region string `form:"region" binding:"omitempty,iso3166_1_alpha2"`
+}
+
+type AroundQuery struct {
+	LeaderboardQuery
+	Radius int `form:"radius" binding:"omitempty,gte=1,lte=50"`
+}
+
+type BrowseQuery struct {
+	LeaderboardQuery
+	Limit  int    `form:"limit" binding:"omitempty,gte=1,lte=100"`
+	Cursor string `form:"cursor" binding:"omitempty,max=512"`
+}
+
+type RankHistoryQuery struct {
+	Board  string `form:"board" binding:"omitempty,max=64"`
+	Mode   string `form:"mode" binding:"omitempty,oneof=solo team overall"`
+	Window string `form:"window" binding:"omitempty,oneof=daily weekly monthly all_time"`
+	From   string `form:"from" binding:"omitempty,datetime=2006-01-02"`
+	To     string `form:"to" binding:"omitempty,datetime=2006-01-02"`
+}
+
+type OpenSeasonRequest struct {
+	Name  string     `json:"name" binding:"required,max=255"`
+	EndAt *time.Time `json:"end_at"`
+}
+
+type SeasonLeaderboardQuery struct {
+	Board string `form:"board" binding:"omitempty,max=64"`
+	Mode  string `form:"mode" binding:"omitempty,oneof=solo team overall"`
+	Page  int    `form:"page" binding:"omitempty,gte=1"`
+	Limit int    `form:"limit" binding:"omitempty,gte=1,lte=100"`
+}
+
+type QuarantineQuery struct {
+	Page  int `form:"page" binding:"omitempty,gte=1"`
+	Limit int `form:"limit" binding:"omitempty,gte=1,lte=100"`
+}
+
+type ReviewSessionRequest struct {
+	Decision string `json:"decision" binding:"required,oneof=approve reject"`
+	Reason   string `json:"reason" binding:"omitempty,max=1000"`
+}
+
+type ForceRecalculationRequest struct {
+	Full bool `json:"full"`
+}
+
+// ModerationRequest gives why a moderation action was taken, it is kept in the audit log along
+// with the admin who took it
+type ModerationRequest struct {
+	Reason string `json:"reason" binding:"required,max=1000"`
+}
+
+type BanUserRequest struct {
+	ModerationRequest
+	Kind string `json:"kind" binding:"required,oneof=ban shadow"`
+}
+
+type BansQuery struct {
+	Page  int `form:"page" binding:"omitempty,gte=1"`
+	Limit int `form:"limit" binding:"omitempty,gte=1,lte=100"`
+}
+
+type ModerationLogQuery struct {
+	TargetType string `form:"target_type" binding:"omitempty,oneof=session user"`
+	TargetID   int    `form:"target_id" binding:"omitempty,gt=0"`
+	Page       int    `form:"page" binding:"omitempty,gte=1"`
+	Limit      int    `form:"limit" binding:"omitempty,gte=1,lte=100"`
+}
+
+type CreateUserRequest struct {
+	Username    string `json:"username" binding:"required,min=3,max=32,printascii"`
+	DisplayName string `json:"display_name" binding:"omitempty,max=64"`
+	AvatarURL   string `json:"avatar_url" binding:"omitempty,url,max=512"`
+	Country     string `json:"country" binding:"omitempty,iso3166_1_alpha2"`
+}
+
+// UpdateUserRequest changes only the fields present, an empty string clears a profile field
+type UpdateUserRequest struct {
+	Username    *string `json:"username" binding:"omitempty,min=3,max=32,printascii"`
+	DisplayName *string `json:"display_name" binding:"omitempty,max=64"`
+	AvatarURL   *string `json:"avatar_url" binding:"omitempty,url,max=512"`
+	Country     *string `json:"country" binding:"omitempty,iso3166_1_alpha2"`
+}
+
+type UserSearchQuery struct {
+	Username string `form:"username" binding:"required,max=32"`
+	Page     int    `form:"page" binding:"omitempty,gte=1"`
+	Limit    int    `form:"limit" binding:"omitempty,gte=1,lte=100"`
+}
+
+type TeamLeaderboardQuery struct {
+	Board  string `form:"board" binding:"omitempty,max=64"`
+	Window string `form:"window" binding:"omitempty,oneof=daily weekly monthly all_time"`
+	Period string `form:"period" binding:"omitempty,datetime=2006-01-02"`
+}
+
+type CreateTeamRequest struct {
+	Name string `json:"name" binding:"required,min=3,max=64,printascii"`
+}
+
+type AddTeamMemberRequest struct {
+	UserID int `json:"user_id" binding:"required,gt=0"`
+}
+
+type AddFriendRequest struct {
+	FriendID int    `json:"friend_id" binding:"required,gt=0"`
+	Kind     string `json:"kind" binding:"omitempty,oneof=mutual follow"`
+}
+
+type FriendsQuery struct {
+	Page  int `form:"page" binding:"omitempty,gte=1"`
+	Limit int `form:"limit" binding:"omitempty,gte=1,lte=100"`
+}
+
+// CreateGameRequest registers a game, whose leaderboard definitions are seeded from Boards, or
+// from the configured boards when none are given
+type CreateGameRequest struct {
+	Slug   string         `json:"slug" binding:"required,min=3,max=32"`
+	Name   string         `json:"name" binding:"required,max=255"`
+	Boards []models.Board `json:"boards" binding:"omitempty,max=32"`
+}
+
+// UpdateGameRequest changes only the fields present, boards are managed by the game's own admin API
+type UpdateGameRequest struct {
+	Name *string `json:"name" binding:"omitempty,min=1,max=255"`
+}
+
+type GamesQuery struct {
+	Page  int `form:"page" binding:"omitempty,gte=1"`
+	Limit int `form:"limit" binding:"omitempty,gte=1,lte=100"`
+}
+
+// LeaderboardDefinitionRequest declares how a board or team board ranks, an update replaces
+// every field with the ones given. Fields left empty take their defaults. Team boards count
+// team sessions, and only take the aggregation, sessions, rank type and size cap along with
+// their split and members.
+type LeaderboardDefinitionRequest struct {
+	GameMode       string        `json:"game_mode" binding:"required,oneof=solo team overall"`
+	Windows        []string      `json:"windows" binding:"omitempty,max=4,dive,oneof=daily weekly monthly all_time"`
+	Aggregation    string        `json:"aggregation" binding:"required,oneof=sum best latest average best_n"`
+	Sessions       int           `json:"sessions" binding:"omitempty,gte=1,lte=1000"`
+	RankType       string        `json:"rank_type" binding:"omitempty,oneof=competition dense ordinal"`
+	TieBreaker     string        `json:"tie_breaker" binding:"omitempty,oneof=reached_first user_id"`
+	SortDirection  string        `json:"sort_direction" binding:"omitempty,oneof=desc asc"`
+	SizeCap        int           `json:"size_cap" binding:"omitempty,gte=1,lte=100"`
+	RefreshSeconds int           `json:"refresh_seconds" binding:"omitempty,gte=0"`
+	CacheSeconds   int           `json:"cache_seconds" binding:"omitempty,gte=0"`
+	TierBasis      string        `json:"tier_basis" binding:"omitempty,oneof=percentile score"`
+	Tiers          []models.Tier `json:"tiers" binding:"omitempty,max=20"`
+	Split          string        `json:"split" binding:"omitempty,oneof=sum average top"`
+	Members        int           `json:"members" binding:"omitempty,gte=1,lte=1000"`
+}
+
+type CreateLeaderboardDefinitionRequest struct {
+	Name string `json:"name" binding:"required,max=64"`
+	// Kind is board unless given, a definition keeps its kind for good
+	Kind string `json:"kind" binding:"omitempty,oneof=board team"`
+	LeaderboardDefinitionRequest
 }
@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"fmt"
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/controller/request"
+	leaderboardSvc "gaming-leaderboard/internal/leaderboard/service"
+	"gaming-leaderboard/pkg/apperror"
+	"gaming-leaderboard/pkg/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SeasonsController struct {
+	leaderboardService *leaderboardSvc.LeaderboardService
+	leaderboardWorker  *leaderboardSvc.LeaderboardWorker
+}
+
+func NewSeasonsController(
+	leaderboardService *leaderboardSvc.LeaderboardService,
+	leaderboardWorker *leaderboardSvc.LeaderboardWorker,
+) *SeasonsController {
+	return &SeasonsController{
+		leaderboardService: leaderboardService,
+		leaderboardWorker:  leaderboardWorker,
+	}
+}
+
+func (c *SeasonsController) OpenSeason(ctx *gin.Context) {
+	var req request.OpenSeasonRequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+		apperror.New(fmt.Errorf("invalid request body: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	season, cusErr := c.leaderboardService.OpenSeason(ctx, req)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.Created(ctx, season)
+	return
+}
+
+func (c *SeasonsController) CloseSeason(ctx *gin.Context) {
+	seasonID, err := strconv.Atoi(ctx.Param(constants.SeasonID))
+	if err != nil {
+		apperror.New(fmt.Errorf("invalid season id: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	season, cusErr := c.leaderboardWorker.CloseSeason(ctx, seasonID)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.OK(ctx, season)
+	return
+}
+
+func (c *SeasonsController) GetSeasonLeaderboard(ctx *gin.Context) {
+	seasonID, err := strconv.Atoi(ctx.Param(constants.SeasonID))
+	if err != nil {
+		apperror.New(fmt.Errorf("invalid season id: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	var query request.SeasonLeaderboardQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		apperror.New(fmt.Errorf("invalid query params: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.Limit == 0 {
+		query.Limit = constants.DefaultPageLimit
+	}
+
+	standings, total, cusErr := c.leaderboardService.GetSeasonStandings(ctx, seasonID, query)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.OKWithMeta(ctx, standings, response.NewPaginationMeta(query.Page, query.Limit, total))
+	return
+}
@@ -0,0 +1,40 @@
+package controller
+
+import (
+	"fmt"
+	"gaming-leaderboard/internal/controller/request"
+	gameSessionsSvc "gaming-leaderboard/internal/game_sessions/service"
+	"gaming-leaderboard/pkg/apperror"
+	"gaming-leaderboard/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SessionsController struct {
+	gameSessionsService *gameSessionsSvc.GameSessionsService
+}
+
+func NewSessionsController(
+	gameSessionsService *gameSessionsSvc.GameSessionsService,
+) *SessionsController {
+	return &SessionsController{
+		gameSessionsService: gameSessionsService,
+	}
+}
+
+func (c *SessionsController) StartSession(ctx *gin.Context) {
+	var req request.StartSessionRequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+		apperror.New(fmt.Errorf("invalid request body: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	session, cusErr := c.gameSessionsService.StartSession(ctx, req)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.Created(ctx, session)
+	return
+}
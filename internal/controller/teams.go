@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"fmt"
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/controller/request"
+	leaderboardSvc "gaming-leaderboard/internal/leaderboard/service"
+	teamsSvc "gaming-leaderboard/internal/teams/service"
+	"gaming-leaderboard/pkg/apperror"
+	"gaming-leaderboard/pkg/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TeamsController struct {
+	teamsService       *teamsSvc.TeamsService
+	leaderboardService *leaderboardSvc.LeaderboardService
+}
+
+func NewTeamsController(
+	teamsService *teamsSvc.TeamsService,
+	leaderboardService *leaderboardSvc.LeaderboardService,
+) *TeamsController {
+	return &TeamsController{
+		teamsService:       teamsService,
+		leaderboardService: leaderboardService,
+	}
+}
+
+func (c *TeamsController) CreateTeam(ctx *gin.Context) {
+	var req request.CreateTeamRequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+		apperror.New(fmt.Errorf("invalid request body: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	team, cusErr := c.teamsService.CreateTeam(ctx, req)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.Created(ctx, team)
+	return
+}
+
+func (c *TeamsController) GetTeam(ctx *gin.Context) {
+	teamID, err := strconv.Atoi(ctx.Param(constants.TeamID))
+	if err != nil {
+		apperror.New(fmt.Errorf("invalid team id: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	team, cusErr := c.teamsService.GetTeam(ctx, teamID)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.OK(ctx, team)
+	return
+}
+
+func (c *TeamsController) AddMember(ctx *gin.Context) {
+	teamID, err := strconv.Atoi(ctx.Param(constants.TeamID))
+	if err != nil {
+		apperror.New(fmt.Errorf("invalid team id: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	var req request.AddTeamMemberRequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+		apperror.New(fmt.Errorf("invalid request body: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	member, cusErr := c.teamsService.AddMember(ctx, teamID, req)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.Created(ctx, member)
+	return
+}
+
+func (c *TeamsController) RemoveMember(ctx *gin.Context) {
+	teamID, err := strconv.Atoi(ctx.Param(constants.TeamID))
+	if err != nil {
+		apperror.New(fmt.Errorf("invalid team id: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	userID, err := strconv.Atoi(ctx.Param(constants.UserID))
+	if err != nil {
+		apperror.New(fmt.Errorf("invalid user id: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	if cusErr := c.teamsService.RemoveMember(ctx, teamID, userID); cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.OK(ctx, gin.H{"team_id": teamID, "user_id": userID})
+	return
+}
+
+func (c *TeamsController) GetTopTeams(ctx *gin.Context) {
+	var query request.TeamLeaderboardQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		apperror.New(fmt.Errorf("invalid query params: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	scope, cusErr := c.leaderboardService.ResolveTeamScope(ctx, query)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	teams, cusErr := c.leaderboardService.GetTopTeams(ctx, scope)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.OK(ctx, teams)
+	return
+}
+
+func (c *TeamsController) GetTeamRank(ctx *gin.Context) {
+	teamID, err := strconv.Atoi(ctx.Param(constants.TeamID))
+	if err != nil {
+		apperror.New(fmt.Errorf("invalid team id: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	var query request.TeamLeaderboardQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		apperror.New(fmt.Errorf("invalid query params: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	scope, cusErr := c.leaderboardService.ResolveTeamScope(ctx, query)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	rank, cusErr := c.leaderboardService.GetTeamRank(ctx, teamID, scope)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.OK(ctx, rank)
+	return
+}
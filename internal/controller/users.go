@@ -0,0 +1,181 @@
+package controller
+
+import (
+	"fmt"
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/controller/request"
+	usersSvc "gaming-leaderboard/internal/users/service"
+	"gaming-leaderboard/pkg/apperror"
+	"gaming-leaderboard/pkg/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UsersController struct {
+	usersService *usersSvc.UsersService
+}
+
+func NewUsersController(
+	usersService *usersSvc.UsersService,
+) *UsersController {
+	return &UsersController{
+		usersService: usersService,
+	}
+}
+
+func (c *UsersController) CreateUser(ctx *gin.Context) {
+	var req request.CreateUserRequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+		apperror.New(fmt.Errorf("invalid request body: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	user, cusErr := c.usersService.CreateUser(ctx, req)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.Created(ctx, user)
+	return
+}
+
+func (c *UsersController) GetUser(ctx *gin.Context) {
+	userID, err := strconv.Atoi(ctx.Param(constants.UserID))
+	if err != nil {
+		apperror.New(fmt.Errorf("invalid user id: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	user, cusErr := c.usersService.GetUser(ctx, userID)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.OK(ctx, user)
+	return
+}
+
+func (c *UsersController) UpdateUser(ctx *gin.Context) {
+	userID, err := strconv.Atoi(ctx.Param(constants.UserID))
+	if err != nil {
+		apperror.New(fmt.Errorf("invalid user id: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	var req request.UpdateUserRequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+		apperror.New(fmt.Errorf("invalid request body: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	user, cusErr := c.usersService.UpdateUser(ctx, userID, req)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.OK(ctx, user)
+	return
+}
+
+func (c *UsersController) SearchUsers(ctx *gin.Context) {
+	var query request.UserSearchQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		apperror.New(fmt.Errorf("invalid query params: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.Limit == 0 {
+		query.Limit = constants.DefaultPageLimit
+	}
+
+	users, total, cusErr := c.usersService.SearchUsers(ctx, query.Username, query.Page, query.Limit)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.OKWithMeta(ctx, users, response.NewPaginationMeta(query.Page, query.Limit, total))
+	return
+}
+
+func (c *UsersController) AddFriend(ctx *gin.Context) {
+	userID, err := strconv.Atoi(ctx.Param(constants.UserID))
+	if err != nil {
+		apperror.New(fmt.Errorf("invalid user id: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	var req request.AddFriendRequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+		apperror.New(fmt.Errorf("invalid request body: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	friendship, cusErr := c.usersService.AddFriend(ctx, userID, req)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.Created(ctx, friendship)
+	return
+}
+
+func (c *UsersController) RemoveFriend(ctx *gin.Context) {
+	userID, err := strconv.Atoi(ctx.Param(constants.UserID))
+	if err != nil {
+		apperror.New(fmt.Errorf("invalid user id: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	friendID, err := strconv.Atoi(ctx.Param(constants.FriendID))
+	if err != nil {
+		apperror.New(fmt.Errorf("invalid friend id: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	if cusErr := c.usersService.RemoveFriend(ctx, userID, friendID); cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.OK(ctx, gin.H{"user_id": userID, "friend_id": friendID})
+	return
+}
+
+func (c *UsersController) ListFriends(ctx *gin.Context) {
+	userID, err := strconv.Atoi(ctx.Param(constants.UserID))
+	if err != nil {
+		apperror.New(fmt.Errorf("invalid user id: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	var query request.FriendsQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		apperror.New(fmt.Errorf("invalid query params: %w", err), 400).AbortWithError(ctx)
+		return
+	}
+
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.Limit == 0 {
+		query.Limit = constants.DefaultPageLimit
+	}
+
+	friends, total, cusErr := c.usersService.ListFriends(ctx, userID, query.Page, query.Limit)
+	if cusErr.Exists() {
+		cusErr.AbortWithError(ctx)
+		return
+	}
+
+	response.OKWithMeta(ctx, friends, response.NewPaginationMeta(query.Page, query.Limit, total))
+	return
+}
@@ -1,9 +1,17 @@
 package repository
 
 import (
+	"context"
+	"log"
+	"time"
+
+	"gaming-leaderboard/constants"
 	"gaming-leaderboard/internal/models"
 	"gaming-leaderboard/internal/repository"
 	"gaming-leaderboard/pkg/db/postgres"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type GameSessionsRepository struct {
@@ -17,3 +25,346 @@ func NewGameSessionsRepository(db *postgres.DbCluster) *GameSessionsRepository {
 		db:        db,
 	}
 }
+
+// CreateOnce inserts session unless the user already recorded one under the same client
+// session id, or a session was already recorded for the same play session. The original session
+// is returned instead with created false, the one sharing the client session id if both exist.
+func (r *GameSessionsRepository) CreateOnce(
+	ctx context.Context,
+	session *models.GameSession,
+) (stored models.GameSession, created bool, err error) {
+	db := r.db.GetMasterDB(ctx)
+
+	tx := db.Clauses(clause.OnConflict{DoNothing: true}).Create(session)
+	if tx.Error != nil {
+		log.Printf("[ERROR] CreateOnce: insert failed | user_id=%d | err=%v", session.UserID, tx.Error)
+		return models.GameSession{}, false, tx.Error
+	}
+
+	if tx.RowsAffected == 1 || !session.Keyed() {
+		return *session, true, nil
+	}
+
+	// Read from the master, a retry can arrive before the original reaches the replicas
+	originals, err := findOriginals(db, []*models.GameSession{session})
+	if err != nil {
+		log.Printf("[ERROR] CreateOnce: original session lookup failed | user_id=%d | err=%v", session.UserID, err)
+		return models.GameSession{}, false, err
+	}
+
+	stored, found := originals.of(session)
+	if !found {
+		log.Printf("[ERROR] CreateOnce: original session missing | user_id=%d", session.UserID)
+		return models.GameSession{}, false, gorm.ErrRecordNotFound
+	}
+
+	return stored, false, nil
+}
+
+// ClientSession is the key a client retries a submission under
+type ClientSession struct {
+	UserID          int
+	ClientSessionID string
+}
+
+// FindByClientSessions returns the sessions already recorded under any of keys. It reads from
+// the master, a retry can arrive before the original reaches the replicas.
+func (r *GameSessionsRepository) FindByClientSessions(
+	ctx context.Context,
+	keys []ClientSession,
+) (map[ClientSession]models.GameSession, error) {
+	sessions := make([]*models.GameSession, 0, len(keys))
+	for _, key := range keys {
+		clientSessionID := key.ClientSessionID
+		sessions = append(sessions, &models.GameSession{UserID: key.UserID, ClientSessionID: &clientSessionID})
+	}
+
+	originals, err := findOriginals(r.db.GetMasterDB(ctx), sessions)
+	if err != nil {
+		log.Printf("[ERROR] FindByClientSessions: lookup failed | keys=%d | err=%v", len(keys), err)
+		return nil, err
+	}
+
+	return originals.byClientSession, nil
+}
+
+// RecordRejections stores the outcome of submissions refused under a client session id. The
+// first outcome stored under a key is kept.
+func (r *GameSessionsRepository) RecordRejections(ctx context.Context, rejections []*models.RejectedSubmission) error {
+	if len(rejections) == 0 {
+		return nil
+	}
+
+	if err := r.db.GetMasterDB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&rejections).Error; err != nil {
+		log.Printf("[ERROR] RecordRejections: insert failed | rejections=%d | err=%v", len(rejections), err)
+		return err
+	}
+
+	return nil
+}
+
+// FindRejections returns the outcomes of submissions refused under any of keys. It reads from
+// the master, a retry can arrive before the refusal reaches the replicas.
+func (r *GameSessionsRepository) FindRejections(
+	ctx context.Context,
+	keys []ClientSession,
+) (map[ClientSession]models.RejectedSubmission, error) {
+	rejections := make(map[ClientSession]models.RejectedSubmission)
+	if len(keys) == 0 {
+		return rejections, nil
+	}
+
+	pairs := make([][]interface{}, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, []interface{}{key.UserID, key.ClientSessionID})
+	}
+
+	var found []models.RejectedSubmission
+	if err := r.db.GetMasterDB(ctx).Where("(user_id, client_session_id) IN ?", pairs).Find(&found).Error; err != nil {
+		log.Printf("[ERROR] FindRejections: lookup failed | keys=%d | err=%v", len(keys), err)
+		return nil, err
+	}
+
+	for _, rejection := range found {
+		rejections[ClientSession{rejection.UserID, rejection.ClientSessionID}] = rejection
+	}
+
+	return rejections, nil
+}
+
+// originalSessions indexes recorded sessions by the keys that make a session unique
+type originalSessions struct {
+	byClientSession map[ClientSession]models.GameSession
+	byPlaySession   map[string]models.GameSession
+}
+
+// findOriginals loads the recorded sessions sharing a client session id or play session with
+// any of sessions
+func findOriginals(db *gorm.DB, sessions []*models.GameSession) (originalSessions, error) {
+	originals := originalSessions{
+		byClientSession: make(map[ClientSession]models.GameSession),
+		byPlaySession:   make(map[string]models.GameSession),
+	}
+	if len(sessions) == 0 {
+		return originals, nil
+	}
+
+	clientSessions := make([][]interface{}, 0)
+	playSessions := make([]string, 0)
+	for _, session := range sessions {
+		if session.ClientSessionID != nil {
+			clientSessions = append(clientSessions, []interface{}{session.UserID, *session.ClientSessionID})
+		}
+		if session.PlaySessionID != nil {
+			playSessions = append(playSessions, *session.PlaySessionID)
+		}
+	}
+
+	query := db.Where("FALSE")
+	if len(clientSessions) > 0 {
+		query = query.Or("(user_id, client_session_id) IN ?", clientSessions)
+	}
+	if len(playSessions) > 0 {
+		query = query.Or("play_session_id IN ?", playSessions)
+	}
+
+	var found []models.GameSession
+	if err := query.Find(&found).Error; err != nil {
+		return originals, err
+	}
+
+	for _, original := range found {
+		if original.ClientSessionID != nil {
+			originals.byClientSession[ClientSession{original.UserID, *original.ClientSessionID}] = original
+		}
+		if original.PlaySessionID != nil {
+			originals.byPlaySession[*original.PlaySessionID] = original
+		}
+	}
+
+	return originals, nil
+}
+
+// of returns the original of a session that was not inserted, preferring the one sharing its
+// client session id so client retries resolve to what they retried
+func (o originalSessions) of(session *models.GameSession) (models.GameSession, bool) {
+	if session.ClientSessionID != nil {
+		if original, ok := o.byClientSession[ClientSession{session.UserID, *session.ClientSessionID}]; ok {
+			return original, true
+		}
+	}
+
+	if session.PlaySessionID != nil {
+		if original, ok := o.byPlaySession[*session.PlaySessionID]; ok {
+			return original, true
+		}
+	}
+
+	return models.GameSession{}, false
+}
+
+// CreateManyOnce inserts sessions in a single statement, skipping those CreateOnce would. It
+// returns, index for index, the stored session or its original and whether this call created it.
+// A session recorded under the same key in the meantime fails the whole statement, leaving the
+// caller to insert the sessions one at a time.
+func (r *GameSessionsRepository) CreateManyOnce(
+	ctx context.Context,
+	sessions []*models.GameSession,
+) (stored []models.GameSession, created []bool, err error) {
+	if len(sessions) == 0 {
+		return nil, nil, nil
+	}
+
+	db := r.db.GetMasterDB(ctx)
+
+	keyed := make([]*models.GameSession, 0)
+	for _, session := range sessions {
+		if session.Keyed() {
+			keyed = append(keyed, session)
+		}
+	}
+
+	originals, err := findOriginals(db, keyed)
+	if err != nil {
+		log.Printf("[ERROR] CreateManyOnce: original sessions lookup failed | sessions=%d | err=%v", len(keyed), err)
+		return nil, nil, err
+	}
+
+	stored = make([]models.GameSession, len(sessions))
+	created = make([]bool, len(sessions))
+	fresh := make([]*models.GameSession, 0, len(sessions))
+	for i, session := range sessions {
+		if original, found := originals.of(session); found {
+			stored[i] = original
+			continue
+		}
+		fresh = append(fresh, session)
+	}
+
+	if len(fresh) > 0 {
+		// Every row is inserted, so the ids RETURNING hands back line up with the sessions
+		if err := db.Create(&fresh).Error; err != nil {
+			log.Printf("[ERROR] CreateManyOnce: insert failed | sessions=%d | err=%v", len(fresh), err)
+			return nil, nil, err
+		}
+	}
+
+	for i, session := range sessions {
+		if stored[i].ID == 0 {
+			stored[i], created[i] = *session, true
+		}
+	}
+
+	return stored, created, nil
+}
+
+// ScoreStats summarises the user's last samples accepted scores in gameMode
+func (r *GameSessionsRepository) ScoreStats(
+	ctx context.Context,
+	userID int,
+	gameMode string,
+	samples int,
+) (stats models.ScoreStats, err error) {
+	err = r.db.GetSlaveDB(ctx).Raw(`
+		SELECT
+			COUNT(*) as count,
+			COALESCE(AVG(score), 0) as mean,
+			COALESCE(STDDEV_SAMP(score), 0) as std_dev
+		FROM (
+			SELECT score
+			FROM game_sessions
+			WHERE user_id = ? AND game_mode = ? AND status = ? AND voided_at IS NULL
+			ORDER BY id DESC
+			LIMIT ?
+		) recent
+	`, userID, gameMode, constants.SessionStatusAccepted, samples).Scan(&stats).Error
+	if err != nil {
+		log.Printf("[ERROR] ScoreStats: query failed | user_id=%d | game_mode=%s | err=%v", userID, gameMode, err)
+	}
+
+	return stats, err
+}
+
+// Review settles a quarantined session as status and audits it as action in the same
+// transaction. found is false when there is no such session, and reviewed false when it was
+// not quarantined, in which case it is left as it is.
+func (r *GameSessionsRepository) Review(
+	ctx context.Context,
+	sessionID int,
+	status string,
+	action *models.ModerationAction,
+) (session models.GameSession, found bool, reviewed bool, err error) {
+	return r.moderate(ctx, sessionID, map[string]interface{}{
+		constants.Status: status,
+		"reviewed_at":    time.Now().UTC(),
+	}, action, "status = ?", constants.SessionStatusQuarantined)
+}
+
+// Void soft-deletes a session on behalf of a moderator and audits it as action in the same
+// transaction. found is false when there is no such session, and voided false when it was
+// already voided, in which case it is left as it is.
+func (r *GameSessionsRepository) Void(
+	ctx context.Context,
+	sessionID int,
+	action *models.ModerationAction,
+) (session models.GameSession, found bool, voided bool, err error) {
+	return r.moderate(ctx, sessionID, map[string]interface{}{
+		"voided_at":   time.Now().UTC(),
+		"void_reason": action.Reason,
+		"voided_by":   action.Actor,
+	}, action, "voided_at IS NULL")
+}
+
+// Restore undoes the void of a session and audits it as action in the same transaction.
+// found is false when there is no such session, and restored false when it was not voided.
+func (r *GameSessionsRepository) Restore(
+	ctx context.Context,
+	sessionID int,
+	action *models.ModerationAction,
+) (session models.GameSession, found bool, restored bool, err error) {
+	return r.moderate(ctx, sessionID, map[string]interface{}{
+		"voided_at":   nil,
+		"void_reason": nil,
+		"voided_by":   nil,
+	}, action, "voided_at IS NOT NULL")
+}
+
+// moderate applies updates to a session in the state condition (bound to args) describes and
+// records action
+func (r *GameSessionsRepository) moderate(
+	ctx context.Context,
+	sessionID int,
+	updates map[string]interface{},
+	action *models.ModerationAction,
+	condition string,
+	args ...interface{},
+) (session models.GameSession, found bool, changed bool, err error) {
+	err = r.db.GetMasterDB(ctx).Transaction(func(tx *gorm.DB) error {
+		updated := tx.Model(&session).
+			Clauses(clause.Returning{}).
+			Where("id = ?", sessionID).
+			Where(condition, args...).
+			Updates(updates)
+		if updated.Error != nil {
+			return updated.Error
+		}
+
+		if updated.RowsAffected == 1 {
+			found, changed = true, true
+			return tx.Create(action).Error
+		}
+
+		if err := tx.Where("id = ?", sessionID).Limit(1).Find(&session).Error; err != nil {
+			return err
+		}
+
+		found = session.ID != 0
+		return nil
+	})
+	if err != nil {
+		log.Printf("[ERROR] moderate: action=%s | session_id=%d | err=%v", action.Action, sessionID, err)
+		return models.GameSession{}, false, false, err
+	}
+
+	return session, found, changed, nil
+}
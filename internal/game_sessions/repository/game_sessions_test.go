@@ -0,0 +1,297 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/models"
+)
+
+// submitted is a session of userID scoring score, keyed by the client and play session ids
+// that are not empty
+func submitted(userID int, score int, clientSessionID string, playSessionID string) *models.GameSession {
+	session := &models.GameSession{UserID: userID, Score: score, GameMode: constants.GameModeSolo}
+	if clientSessionID != "" {
+		session.ClientSessionID = &clientSessionID
+	}
+	if playSessionID != "" {
+		session.PlaySessionID = &playSessionID
+	}
+
+	return session
+}
+
+func TestCreateOnce(t *testing.T) {
+	tests := []struct {
+		name string
+		// recorded are stored before session is submitted
+		recorded []*models.GameSession
+		session  *models.GameSession
+		// original is the index in recorded of the session returned instead, -1 when session
+		// is created
+		original int
+	}{
+		{
+			name:     "first submission is created",
+			session:  submitted(1, 100, "client-1", "play-1"),
+			original: -1,
+		},
+		{
+			name:     "retry under the same client session id returns the original",
+			recorded: []*models.GameSession{submitted(1, 100, "client-1", "play-1")},
+			session:  submitted(1, 100, "client-1", "play-1"),
+			original: 0,
+		},
+		{
+			name:     "reuse of a play session under another client session id returns the original",
+			recorded: []*models.GameSession{submitted(1, 100, "client-1", "play-1")},
+			session:  submitted(1, 200, "client-2", "play-1"),
+			original: 0,
+		},
+		{
+			name: "the original sharing the client session id is preferred over the play session's",
+			recorded: []*models.GameSession{
+				submitted(1, 100, "client-1", "play-1"),
+				submitted(1, 200, "client-2", "play-2"),
+			},
+			session:  submitted(1, 100, "client-1", "play-2"),
+			original: 0,
+		},
+		{
+			name:     "client session ids are the user's own",
+			recorded: []*models.GameSession{submitted(1, 100, "client-1", "play-1")},
+			session:  submitted(2, 100, "client-1", "play-2"),
+			original: -1,
+		},
+		{
+			name:     "sessions without keys are always created",
+			recorded: []*models.GameSession{submitted(1, 100, "", "")},
+			session:  submitted(1, 100, "", ""),
+			original: -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testDB(t)
+			ctx := context.Background()
+			repo := NewGameSessionsRepository(db)
+			createUsers(t, db.GetMasterDB(ctx), 1, 2)
+
+			for _, session := range tt.recorded {
+				if _, created, err := repo.CreateOnce(ctx, session); err != nil || !created {
+					t.Fatalf("record = %v, %v, want created", created, err)
+				}
+			}
+
+			stored, created, err := repo.CreateOnce(ctx, tt.session)
+			if err != nil {
+				t.Fatalf("create: %v", err)
+			}
+
+			want := int64(len(tt.recorded))
+			if tt.original < 0 {
+				want++
+				if !created || stored.ID == 0 {
+					t.Errorf("create = %+v, %v, want a new session", stored, created)
+				}
+			} else {
+				original := tt.recorded[tt.original]
+				if created || stored.ID != original.ID || stored.Score != original.Score {
+					t.Errorf("create = %+v, %v, want the original %+v", stored, created, original)
+				}
+			}
+
+			if count := countSessions(t, db.GetMasterDB(ctx)); count != want {
+				t.Errorf("recorded %d sessions, want %d", count, want)
+			}
+		})
+	}
+}
+
+func TestCreateOnceRace(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	repo := NewGameSessionsRepository(db)
+	createUsers(t, db.GetMasterDB(ctx), 1)
+
+	// Retries racing the original all insert, the losers hit the conflict and read the winner
+	const retries = 6
+	ids := make([]int, retries)
+	created := make([]bool, retries)
+	errs := make([]error, retries)
+
+	var wg sync.WaitGroup
+	for i := 0; i < retries; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var stored models.GameSession
+			stored, created[i], errs[i] = repo.CreateOnce(ctx, submitted(1, 100, "client-1", "play-1"))
+			ids[i] = stored.ID
+		}(i)
+	}
+	wg.Wait()
+
+	creates := 0
+	for i := 0; i < retries; i++ {
+		if errs[i] != nil {
+			t.Fatalf("retry %d: %v", i, errs[i])
+		}
+		if created[i] {
+			creates++
+		}
+		if ids[i] == 0 || ids[i] != ids[0] {
+			t.Errorf("retry %d returned session %d, want %d", i, ids[i], ids[0])
+		}
+	}
+
+	if creates != 1 {
+		t.Errorf("%d retries created the session, want 1", creates)
+	}
+	if count := countSessions(t, db.GetMasterDB(ctx)); count != 1 {
+		t.Errorf("recorded %d sessions, want 1", count)
+	}
+}
+
+func TestFindByClientSessions(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	repo := NewGameSessionsRepository(db)
+	createUsers(t, db.GetMasterDB(ctx), 1, 2)
+
+	original := submitted(1, 100, "client-1", "play-1")
+	for _, session := range []*models.GameSession{original, submitted(2, 200, "", "play-2")} {
+		if _, _, err := repo.CreateOnce(ctx, session); err != nil {
+			t.Fatalf("record: %v", err)
+		}
+	}
+
+	found, err := repo.FindByClientSessions(ctx, []ClientSession{
+		{UserID: 1, ClientSessionID: "client-1"},
+		{UserID: 2, ClientSessionID: "client-1"},
+		{UserID: 1, ClientSessionID: "client-2"},
+	})
+	if err != nil {
+		t.Fatalf("find: %v", err)
+	}
+
+	if len(found) != 1 {
+		t.Errorf("found %d sessions, want only the original: %+v", len(found), found)
+	}
+	if session := found[ClientSession{UserID: 1, ClientSessionID: "client-1"}]; session.ID != original.ID {
+		t.Errorf("found session %d, want the original %d", session.ID, original.ID)
+	}
+}
+
+func TestRejections(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	repo := NewGameSessionsRepository(db)
+	key := ClientSession{UserID: 1, ClientSessionID: "client-1"}
+
+	refused := &models.RejectedSubmission{
+		UserID:          key.UserID,
+		ClientSessionID: key.ClientSessionID,
+		Score:           100,
+		GameMode:        constants.GameModeSolo,
+		StatusCode:      http.StatusForbidden,
+		Error:           "user 1 is banned from leaderboards",
+	}
+	if err := repo.RecordRejections(ctx, []*models.RejectedSubmission{refused}); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+
+	// A second refusal under the same key, such as a racing retry's, leaves the first in place
+	again := *refused
+	again.StatusCode = http.StatusUnauthorized
+	again.Error = "invalid session token"
+	if err := repo.RecordRejections(ctx, []*models.RejectedSubmission{&again}); err != nil {
+		t.Fatalf("record again: %v", err)
+	}
+
+	if err := repo.RecordRejections(ctx, nil); err != nil {
+		t.Errorf("record nothing: %v", err)
+	}
+
+	found, err := repo.FindRejections(ctx, []ClientSession{key, {UserID: 2, ClientSessionID: "client-1"}})
+	if err != nil {
+		t.Fatalf("find: %v", err)
+	}
+
+	if len(found) != 1 {
+		t.Errorf("found %d refusals, want 1: %+v", len(found), found)
+	}
+	if rejection := found[key]; rejection.StatusCode != refused.StatusCode || rejection.Error != refused.Error {
+		t.Errorf("found %+v, want the first refusal %+v", rejection, refused)
+	}
+
+	none, err := repo.FindRejections(ctx, nil)
+	if err != nil || len(none) != 0 {
+		t.Errorf("find nothing = %+v, %v, want none", none, err)
+	}
+}
+
+func TestCreateManyOnce(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	repo := NewGameSessionsRepository(db)
+	createUsers(t, db.GetMasterDB(ctx), 1, 2)
+
+	original := submitted(1, 100, "client-1", "play-1")
+	if _, _, err := repo.CreateOnce(ctx, original); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+
+	batch := []*models.GameSession{
+		submitted(2, 200, "client-2", "play-2"),
+		submitted(1, 100, "client-1", "play-1"),
+		submitted(2, 300, "", ""),
+		submitted(1, 400, "client-3", "play-1"),
+	}
+	stored, created, err := repo.CreateManyOnce(ctx, batch)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	// Results line up with the batch, originals in place of the sessions already recorded
+	wantCreated := []bool{true, false, true, false}
+	for i := range batch {
+		if created[i] != wantCreated[i] {
+			t.Errorf("session %d created = %v, want %v", i, created[i], wantCreated[i])
+		}
+		if !created[i] && stored[i].ID != original.ID {
+			t.Errorf("session %d = %d, want the original %d", i, stored[i].ID, original.ID)
+		}
+		if created[i] && (stored[i].ID == 0 || stored[i].Score != batch[i].Score) {
+			t.Errorf("session %d = %+v, want the new %+v", i, stored[i], batch[i])
+		}
+	}
+
+	if count := countSessions(t, db.GetMasterDB(ctx)); count != 3 {
+		t.Errorf("recorded %d sessions, want 3", count)
+	}
+}
+
+func TestCreateManyOnceDuplicatesInBatch(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	repo := NewGameSessionsRepository(db)
+	createUsers(t, db.GetMasterDB(ctx), 1)
+
+	// Neither is recorded yet, so both are inserted and the second collides with the first
+	batch := []*models.GameSession{
+		submitted(1, 100, "client-1", "play-1"),
+		submitted(1, 100, "client-2", "play-1"),
+	}
+	if _, _, err := repo.CreateManyOnce(ctx, batch); err == nil {
+		t.Fatalf("create succeeded, want the statement to fail for the caller to insert one by one")
+	}
+
+	if count := countSessions(t, db.GetMasterDB(ctx)); count != 0 {
+		t.Errorf("recorded %d sessions, want none", count)
+	}
+}
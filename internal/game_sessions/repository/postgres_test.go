@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"gaming-leaderboard/internal/models"
+	"gaming-leaderboard/pkg/db/postgres"
+
+	"gorm.io/gorm"
+)
+
+// testDB opens the database the TEST_POSTGRES_* variables name, the docker-compose one by
+// default, in a schema of its own that is dropped once the test ends. The test is skipped when
+// TEST_POSTGRES_HOST is not set.
+func testDB(t *testing.T) *postgres.DbCluster {
+	t.Helper()
+
+	host := os.Getenv("TEST_POSTGRES_HOST")
+	if host == "" {
+		t.Skip("TEST_POSTGRES_HOST is not set, skipping the Postgres test")
+	}
+
+	ctx := context.Background()
+	cluster := postgres.InitializeDBInstance(ctx, postgres.DBConfig{
+		Host:               host,
+		Port:               envOr("TEST_POSTGRES_PORT", "5433"),
+		Username:           envOr("TEST_POSTGRES_USER", "admin"),
+		Password:           envOr("TEST_POSTGRES_PASSWORD", "admin"),
+		Dbname:             envOr("TEST_POSTGRES_DB", "crud"),
+		MaxOpenConnections: 8,
+		MaxIdleConnections: 1,
+	}, &[]postgres.DBConfig{}, nil)
+
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	db, err := cluster.ForSchema(ctx, schema, 8, 1, nil)
+	if err != nil {
+		t.Fatalf("open schema %s: %v", schema, err)
+	}
+
+	t.Cleanup(func() {
+		closeDB(t, db.GetMasterDB(ctx))
+		if err := cluster.GetMasterDB(ctx).Exec(fmt.Sprintf(`DROP SCHEMA "%s" CASCADE`, schema)).Error; err != nil {
+			t.Errorf("drop schema %s: %v", schema, err)
+		}
+		closeDB(t, cluster.GetMasterDB(ctx))
+	})
+
+	return db
+}
+
+func envOr(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+
+	return fallback
+}
+
+func closeDB(t *testing.T, db *gorm.DB) {
+	sqlDB, err := db.DB()
+	if err == nil {
+		err = sqlDB.Close()
+	}
+	if err != nil {
+		t.Errorf("close connections: %v", err)
+	}
+}
+
+// createUsers records the users sessions are submitted for
+func createUsers(t *testing.T, db *gorm.DB, userIDs ...int) {
+	t.Helper()
+
+	for _, userID := range userIDs {
+		if err := db.Create(&models.User{ID: userID, Username: fmt.Sprintf("user-%d", userID)}).Error; err != nil {
+			t.Fatalf("create user %d: %v", userID, err)
+		}
+	}
+}
+
+// countSessions is how many sessions are recorded
+func countSessions(t *testing.T, db *gorm.DB) int64 {
+	t.Helper()
+
+	var count int64
+	if err := db.Model(&models.GameSession{}).Count(&count).Error; err != nil {
+		t.Fatalf("count sessions: %v", err)
+	}
+
+	return count
+}
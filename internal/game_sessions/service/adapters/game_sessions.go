@@ -6,9 +6,16 @@ import (
 )
 
 func ConvertToGameSessionModel(req request.SubmitScoreRequest) *models.GameSession {
-	return &models.GameSession{
+	session := &models.GameSession{
 		UserID:   req.UserID,
 		Score:    req.Score,
 		GameMode: req.GameMode,
 	}
+
+	if req.ClientSessionID != "" {
+		clientSessionID := req.ClientSessionID
+		session.ClientSessionID = &clientSessionID
+	}
+
+	return session
 }
@@ -2,56 +2,690 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"net/http"
 	"strconv"
+	"time"
 
+	"gaming-leaderboard/constants"
 	"gaming-leaderboard/internal/controller/request"
 	"gaming-leaderboard/internal/game_sessions/repository"
 	"gaming-leaderboard/internal/game_sessions/service/adapters"
+	"gaming-leaderboard/internal/game_sessions/service/validators"
+	leaderboardRepo "gaming-leaderboard/internal/leaderboard/repository"
 	leaderboardSvc "gaming-leaderboard/internal/leaderboard/service"
+	"gaming-leaderboard/internal/models"
+	baseRepository "gaming-leaderboard/internal/repository"
+	teamsRepo "gaming-leaderboard/internal/teams/repository"
+	usersRepo "gaming-leaderboard/internal/users/repository"
 	"gaming-leaderboard/pkg/apperror"
 
+	"github.com/gin-gonic/gin/binding"
 	"github.com/newrelic/go-agent/v3/newrelic"
+	"gorm.io/gorm"
 )
 
+// Config holds the score submission settings
+type Config struct {
+	// MaxBatchSize caps the entries of a single batch submission
+	MaxBatchSize int
+	// TokenSecret signs the session tokens a score must be submitted with
+	TokenSecret []byte
+	// TokenTTL is how long a play session may last before its score is refused
+	TokenTTL time.Duration
+}
+
 type GameSessionsService struct {
-	repository         *repository.GameSessionsRepository
-	leaderboardService *leaderboardSvc.LeaderboardService
-	leaderboardWorker  *leaderboardSvc.LeaderboardWorker
+	repository                  *repository.GameSessionsRepository
+	usersRepository             *usersRepo.UsersRepository
+	teamsRepository             *teamsRepo.TeamsRepository
+	bansRepository              *leaderboardRepo.BansRepository
+	moderationActionsRepository *leaderboardRepo.ModerationActionsRepository
+	leaderboardService          *leaderboardSvc.LeaderboardService
+	leaderboardWorker           *leaderboardSvc.LeaderboardWorker
+	validators                  validators.Chain
+	config                      Config
 }
 
 func NewGameSessionsService(
 	repo *repository.GameSessionsRepository,
+	usersRepository *usersRepo.UsersRepository,
+	teamsRepository *teamsRepo.TeamsRepository,
+	bansRepository *leaderboardRepo.BansRepository,
+	moderationActionsRepository *leaderboardRepo.ModerationActionsRepository,
 	leaderboardService *leaderboardSvc.LeaderboardService,
 	leaderboardWorker *leaderboardSvc.LeaderboardWorker,
+	validatorChain validators.Chain,
+	config Config,
 ) *GameSessionsService {
 	return &GameSessionsService{
-		repository:         repo,
-		leaderboardService: leaderboardService,
-		leaderboardWorker:  leaderboardWorker,
+		repository:                  repo,
+		usersRepository:             usersRepository,
+		teamsRepository:             teamsRepository,
+		bansRepository:              bansRepository,
+		moderationActionsRepository: moderationActionsRepository,
+		leaderboardService:          leaderboardService,
+		leaderboardWorker:           leaderboardWorker,
+		validators:                  validatorChain,
+		config:                      config,
+	}
+}
+
+// StartSession opens a play session for a user and game mode, returning the signed token the
+// session's score must be submitted with. The token bounds when and for how long the session
+// was played, but req.UserID is taken on trust: whoever holds the game's API key can start
+// sessions for any user, so the player has to be authenticated before this is called.
+func (s *GameSessionsService) StartSession(
+	ctx context.Context,
+	req request.StartSessionRequest,
+) (SessionStart, apperror.Error) {
+	existing, err := s.usersRepository.Existing(ctx, []int{req.UserID})
+	if err != nil {
+		return SessionStart{}, apperror.New(fmt.Errorf("unable to start session"), http.StatusInternalServerError)
+	}
+
+	if !existing[req.UserID] {
+		return SessionStart{}, apperror.New(fmt.Errorf("user %d not found", req.UserID), http.StatusNotFound)
+	}
+
+	sessionID, err := newSessionID()
+	if err != nil {
+		return SessionStart{}, apperror.New(err, http.StatusInternalServerError)
+	}
+
+	now := time.Now().UTC()
+	claims := sessionClaims{
+		SessionID: sessionID,
+		UserID:    req.UserID,
+		GameMode:  req.GameMode,
+		IssuedAt:  now.UnixMilli(),
+		ExpiresAt: now.Add(s.config.TokenTTL).UnixMilli(),
+	}
+
+	token, err := signSession(s.config.TokenSecret, claims)
+	if err != nil {
+		return SessionStart{}, apperror.New(err, http.StatusInternalServerError)
+	}
+
+	return SessionStart{
+		SessionID:    sessionID,
+		SessionToken: token,
+		StartedAt:    time.UnixMilli(claims.IssuedAt).UTC(),
+		ExpiresAt:    time.UnixMilli(claims.ExpiresAt).UTC(),
+	}, apperror.Error{}
+}
+
+// newGameSession builds the session a submission records at now, after checking its session
+// token was issued for the same user and game mode and has not expired, that the user is among
+// the known ones and that bans does not ban them. Team-mode sessions are played for the team
+// the user is in at the time, if any, and every session records the user's country at the time
+// as its region. The anti-cheat validators then accept it, quarantine it or refuse it.
+func (s *GameSessionsService) newGameSession(
+	ctx context.Context,
+	sessionData request.SubmitScoreRequest,
+	known submitters,
+	now time.Time,
+) (*models.GameSession, apperror.Error) {
+	claims, cusErr := verifySession(s.config.TokenSecret, sessionData.SessionToken, now)
+	if cusErr.Exists() {
+		return nil, cusErr
+	}
+
+	if claims.UserID != sessionData.UserID || claims.GameMode != sessionData.GameMode {
+		return nil, apperror.New(
+			fmt.Errorf("session token was issued for another user or game mode"),
+			http.StatusForbidden,
+		)
+	}
+
+	country, exists := known.countries[sessionData.UserID]
+	if !exists {
+		return nil, apperror.New(fmt.Errorf("user %d not found", sessionData.UserID), http.StatusNotFound)
+	}
+
+	// Shadow-banned users are let through, their sessions are simply never ranked
+	if known.bans[sessionData.UserID].Kind == constants.BanKindBan {
+		return nil, apperror.New(
+			fmt.Errorf("user %d is banned from leaderboards", sessionData.UserID),
+			http.StatusForbidden,
+		)
+	}
+
+	startedAt := time.UnixMilli(claims.IssuedAt).UTC()
+	duration := now.Sub(startedAt).Milliseconds()
+
+	session := adapters.ConvertToGameSessionModel(sessionData)
+	session.Timestamp = now
+	session.PlaySessionID = &claims.SessionID
+	session.StartedAt = &startedAt
+	session.DurationMs = &duration
+	session.Status = constants.SessionStatusAccepted
+
+	if teamID, ok := known.teams[session.UserID]; ok && session.GameMode == constants.GameModeTeam {
+		session.TeamID = &teamID
+	}
+
+	if country != "" {
+		session.Region = &country
+	}
+
+	verdict := s.validators.Check(ctx, validators.Submission{
+		UserID:      session.UserID,
+		GameMode:    session.GameMode,
+		Score:       session.Score,
+		Duration:    now.Sub(startedAt),
+		SubmittedAt: now,
+	})
+
+	switch verdict.Action {
+	case validators.Reject:
+		log.Printf("[WARN] submission refused | user_id=%d | rule=%s | reason=%s", session.UserID, verdict.Rule, verdict.Reason)
+		return nil, apperror.New(fmt.Errorf("score refused: %s", verdict.Reason), verdict.Code)
+	case validators.Quarantine:
+		log.Printf("[WARN] submission quarantined | user_id=%d | rule=%s | reason=%s", session.UserID, verdict.Rule, verdict.Reason)
+		reason := verdict.Rule + ": " + verdict.Reason
+		session.Status = constants.SessionStatusQuarantined
+		session.FlagReason = &reason
+	}
+
+	return session, apperror.Error{}
+}
+
+// submitters is what a submission needs to know about the users submitting: which of them
+// exist and their countries, the bans against them and the teams they are in
+type submitters struct {
+	countries map[int]string
+	bans      map[int]models.LeaderboardBan
+	teams     map[int]int
+}
+
+func (s *GameSessionsService) submitters(ctx context.Context, userIDs []int) (submitters, error) {
+	countries, err := s.usersRepository.Countries(ctx, userIDs)
+	if err != nil {
+		return submitters{}, err
 	}
+
+	bans, err := s.bansRepository.Bans(ctx, userIDs)
+	if err != nil {
+		return submitters{}, err
+	}
+
+	teams, err := s.teamsRepository.MemberTeams(ctx, userIDs)
+	if err != nil {
+		return submitters{}, err
+	}
+
+	return submitters{countries: countries, bans: bans, teams: teams}, nil
+}
+
+// priorOutcomes are what earlier submissions under the client session ids being retried came
+// to: the sessions they recorded and the refusals
+type priorOutcomes struct {
+	sessions   map[repository.ClientSession]models.GameSession
+	rejections map[repository.ClientSession]models.RejectedSubmission
 }
 
+// replays returns the outcomes already recorded under the client session ids of entries. They
+// are looked up before anything is validated, so a retry gets its original back even once its
+// token has expired or the user has been banned or rate limited since, and a refused
+// submission stays refused.
+func (s *GameSessionsService) replays(
+	ctx context.Context,
+	entries []request.SubmitScoreRequest,
+) (priorOutcomes, error) {
+	keys := make([]repository.ClientSession, 0, len(entries))
+	for _, entry := range entries {
+		if entry.ClientSessionID != "" {
+			keys = append(keys, repository.ClientSession{UserID: entry.UserID, ClientSessionID: entry.ClientSessionID})
+		}
+	}
+
+	if len(keys) == 0 {
+		return priorOutcomes{}, nil
+	}
+
+	sessions, err := s.repository.FindByClientSessions(ctx, keys)
+	if err != nil {
+		return priorOutcomes{}, err
+	}
+
+	rejections, err := s.repository.FindRejections(ctx, keys)
+	if err != nil {
+		return priorOutcomes{}, err
+	}
+
+	return priorOutcomes{sessions: sessions, rejections: rejections}, nil
+}
+
+// sessionOf returns the recorded session entry retries, if any
+func (p priorOutcomes) sessionOf(entry request.SubmitScoreRequest) (models.GameSession, bool) {
+	if entry.ClientSessionID == "" {
+		return models.GameSession{}, false
+	}
+
+	original, ok := p.sessions[repository.ClientSession{UserID: entry.UserID, ClientSessionID: entry.ClientSessionID}]
+	return original, ok
+}
+
+// rejectionOf returns the refusal of the submission entry retries, if it was refused
+func (p priorOutcomes) rejectionOf(entry request.SubmitScoreRequest) (models.RejectedSubmission, bool) {
+	if entry.ClientSessionID == "" {
+		return models.RejectedSubmission{}, false
+	}
+
+	rejection, ok := p.rejections[repository.ClientSession{UserID: entry.UserID, ClientSessionID: entry.ClientSessionID}]
+	return rejection, ok
+}
+
+// rejectionFor is the refusal of entry with cusErr to keep for its retries. ok is false when
+// there is nothing to keep: the entry carries no client session id, or it was rate limited and
+// is meant to be retried.
+func rejectionFor(entry request.SubmitScoreRequest, cusErr apperror.Error) (rejection *models.RejectedSubmission, ok bool) {
+	if entry.ClientSessionID == "" || cusErr.StatusCode() == http.StatusTooManyRequests {
+		return nil, false
+	}
+
+	return &models.RejectedSubmission{
+		UserID:          entry.UserID,
+		ClientSessionID: entry.ClientSessionID,
+		Score:           entry.Score,
+		GameMode:        entry.GameMode,
+		StatusCode:      cusErr.StatusCode(),
+		Error:           cusErr.Error(),
+	}, true
+}
+
+// recordRejections keeps refusals for their retries. A refusal that is not kept only means a
+// retry is validated again, so failures are logged rather than returned.
+func (s *GameSessionsService) recordRejections(ctx context.Context, rejections []*models.RejectedSubmission) {
+	if err := s.repository.RecordRejections(ctx, rejections); err != nil {
+		log.Printf("[WARN] submission refusals not recorded | rejections=%d | err=%v", len(rejections), err)
+	}
+}
+
+// CreateGameSession records a submitted score. A retry carrying the client session id of an
+// earlier submission records nothing and returns that original session with replayed set,
+// without being validated again, and any other reuse of a session token is refused. A retry of
+// a refused submission is refused again with the original error, replayed set.
 func (s *GameSessionsService) CreateGameSession(
 	ctx context.Context,
 	sessionData request.SubmitScoreRequest,
-) apperror.Error {
+) (session models.GameSession, replayed bool, cusErr apperror.Error) {
 	txn := newrelic.FromContext(ctx)
 
-	if cusErr := s.repository.Create(
-		ctx,
-		adapters.ConvertToGameSessionModel(sessionData),
-	); cusErr.Exists() {
+	replays, err := s.replays(ctx, []request.SubmitScoreRequest{sessionData})
+	if err != nil {
 		if txn != nil {
-			txn.NoticeError(cusErr)
+			txn.NoticeError(err)
 		}
-		return apperror.New(
+		return models.GameSession{}, false, apperror.New(
 			fmt.Errorf("unable to create session, please try again later"),
 			400,
 		)
 	}
 
-	s.leaderboardService.InvalidateUserCache(ctx, strconv.Itoa(sessionData.UserID))
+	if original, ok := replays.sessionOf(sessionData); ok {
+		if cusErr := checkReplay(original, sessionData); cusErr.Exists() {
+			return models.GameSession{}, false, cusErr
+		}
+		return original, true, apperror.Error{}
+	}
+
+	if rejection, ok := replays.rejectionOf(sessionData); ok {
+		cusErr, replayed := replayRejection(rejection, sessionData)
+		return models.GameSession{}, replayed, cusErr
+	}
+
+	known, err := s.submitters(ctx, []int{sessionData.UserID})
+	if err != nil {
+		if txn != nil {
+			txn.NoticeError(err)
+		}
+		return models.GameSession{}, false, apperror.New(
+			fmt.Errorf("unable to create session, please try again later"),
+			400,
+		)
+	}
+
+	newSession, cusErr := s.newGameSession(ctx, sessionData, known, time.Now().UTC())
+	if cusErr.Exists() {
+		if rejection, ok := rejectionFor(sessionData, cusErr); ok {
+			s.recordRejections(ctx, []*models.RejectedSubmission{rejection})
+		}
+		return models.GameSession{}, false, cusErr
+	}
+
+	stored, created, err := s.repository.CreateOnce(ctx, newSession)
+	if err != nil {
+		if txn != nil {
+			txn.NoticeError(err)
+		}
+		return models.GameSession{}, false, apperror.New(
+			fmt.Errorf("unable to create session, please try again later"),
+			400,
+		)
+	}
+
+	if !created {
+		if cusErr := checkReplay(stored, sessionData); cusErr.Exists() {
+			return models.GameSession{}, false, cusErr
+		}
+		return stored, true, apperror.Error{}
+	}
+
+	s.applySessions(ctx, []models.GameSession{stored}, known.bans)
+
+	return stored, false, apperror.Error{}
+}
+
+// SubmissionResult is the outcome of one entry of a batch submission
+type SubmissionResult struct {
+	Index   int                 `json:"index"`
+	Status  string              `json:"status"`
+	Session *models.GameSession `json:"session,omitempty"`
+	Error   string              `json:"error,omitempty"`
+	// Replayed is set on a rejected entry refused as the earlier submission under its client
+	// session id was
+	Replayed bool `json:"replayed,omitempty"`
+}
+
+// CreateGameSessions records a batch of submitted scores with a single insert. Every entry is
+// validated and reported on its own, so invalid entries do not hold back the valid ones.
+func (s *GameSessionsService) CreateGameSessions(
+	ctx context.Context,
+	entries []request.SubmitScoreRequest,
+) ([]SubmissionResult, apperror.Error) {
+	txn := newrelic.FromContext(ctx)
+
+	if len(entries) > s.config.MaxBatchSize {
+		return nil, apperror.New(
+			fmt.Errorf("batch has %d entries, at most %d are allowed", len(entries), s.config.MaxBatchSize),
+			http.StatusBadRequest,
+		)
+	}
+
+	replays, err := s.replays(ctx, entries)
+	if err != nil {
+		if txn != nil {
+			txn.NoticeError(err)
+		}
+		return nil, apperror.New(
+			fmt.Errorf("unable to create sessions, please try again later"),
+			400,
+		)
+	}
+
+	userIDs := make([]int, 0, len(entries))
+	for _, entry := range entries {
+		userIDs = append(userIDs, entry.UserID)
+	}
+
+	known, err := s.submitters(ctx, userIDs)
+	if err != nil {
+		if txn != nil {
+			txn.NoticeError(err)
+		}
+		return nil, apperror.New(
+			fmt.Errorf("unable to create sessions, please try again later"),
+			400,
+		)
+	}
+
+	results := make([]SubmissionResult, len(entries))
+	sessions := make([]*models.GameSession, 0, len(entries))
+	accepted := make([]int, 0, len(entries))
+	rejections := make([]*models.RejectedSubmission, 0)
+
+	// Sessions submitted together are stamped with one time so they land in the same periods
+	now := time.Now().UTC()
+	for i, entry := range entries {
+		results[i] = SubmissionResult{Index: i}
+
+		if err := binding.Validator.ValidateStruct(&entry); err != nil {
+			results[i].Status = constants.SubmissionRejected
+			results[i].Error = err.Error()
+			continue
+		}
+
+		if original, ok := replays.sessionOf(entry); ok {
+			if cusErr := checkReplay(original, entry); cusErr.Exists() {
+				results[i].Status = constants.SubmissionRejected
+				results[i].Error = cusErr.Error()
+				continue
+			}
+			results[i].Status = constants.SubmissionReplayed
+			results[i].Session = &original
+			continue
+		}
+
+		if rejection, ok := replays.rejectionOf(entry); ok {
+			cusErr, replayed := replayRejection(rejection, entry)
+			results[i].Status = constants.SubmissionRejected
+			results[i].Error = cusErr.Error()
+			results[i].Replayed = replayed
+			continue
+		}
+
+		session, cusErr := s.newGameSession(ctx, entry, known, now)
+		if cusErr.Exists() {
+			results[i].Status = constants.SubmissionRejected
+			results[i].Error = cusErr.Error()
+			if rejection, ok := rejectionFor(entry, cusErr); ok {
+				rejections = append(rejections, rejection)
+			}
+			continue
+		}
+		sessions = append(sessions, session)
+		accepted = append(accepted, i)
+	}
+
+	s.recordRejections(ctx, rejections)
+
+	stored, created, err := s.repository.CreateManyOnce(ctx, sessions)
+	if err != nil {
+		if txn != nil {
+			txn.NoticeError(err)
+		}
+		// A single bad row, such as one of a user deleted since it was looked up, fails the whole
+		// statement, so the sessions are inserted one by one to reject only the entries at fault
+		log.Printf("[WARN] batch insert failed, inserting one by one | sessions=%d | err=%v", len(sessions), err)
+		stored, created = s.createEach(ctx, sessions)
+	}
+
+	createdSessions := make([]models.GameSession, 0, len(stored))
+	for j, i := range accepted {
+		session := stored[j]
+
+		switch {
+		case created[j]:
+			createdSessions = append(createdSessions, session)
+			results[i].Status = constants.SubmissionCreated
+			if session.Status == constants.SessionStatusQuarantined {
+				results[i].Status = constants.SubmissionQuarantined
+			}
+		case session.ID == 0:
+			results[i].Status = constants.SubmissionRejected
+			results[i].Error = "unable to create session, please try again later"
+			continue
+		default:
+			if cusErr := checkReplay(session, entries[i]); cusErr.Exists() {
+				results[i].Status = constants.SubmissionRejected
+				results[i].Error = cusErr.Error()
+				continue
+			}
+			results[i].Status = constants.SubmissionReplayed
+		}
+
+		results[i].Session = &session
+	}
+
+	s.applySessions(ctx, createdSessions, known.bans)
+
+	return results, apperror.Error{}
+}
+
+// createEach inserts sessions one at a time. A session that fails is left zero in stored, and
+// reported as rejected.
+func (s *GameSessionsService) createEach(
+	ctx context.Context,
+	sessions []*models.GameSession,
+) (stored []models.GameSession, created []bool) {
+	stored = make([]models.GameSession, len(sessions))
+	created = make([]bool, len(sessions))
+	for i, session := range sessions {
+		// CreateOnce logs the failure
+		stored[i], created[i], _ = s.repository.CreateOnce(ctx, session)
+	}
+
+	return stored, created
+}
+
+// checkReplay accepts a retry of the submission that recorded original, rejecting a different
+// submission that reuses its client session id or session token
+func checkReplay(original models.GameSession, retry request.SubmitScoreRequest) apperror.Error {
+	if retry.ClientSessionID == "" || original.ClientSessionID == nil || *original.ClientSessionID != retry.ClientSessionID {
+		return apperror.New(fmt.Errorf("session token has already been used"), http.StatusConflict)
+	}
+
+	if original.Score != retry.Score || original.GameMode != retry.GameMode {
+		return reusedClientSession(retry.ClientSessionID)
+	}
 
 	return apperror.Error{}
 }
+
+// replayRejection refuses a retry of a refused submission with the original error, replayed
+// set, and a different submission reusing its client session id as checkReplay does
+func replayRejection(rejection models.RejectedSubmission, retry request.SubmitScoreRequest) (cusErr apperror.Error, replayed bool) {
+	if rejection.Score != retry.Score || rejection.GameMode != retry.GameMode {
+		return reusedClientSession(retry.ClientSessionID), false
+	}
+
+	return apperror.New(errors.New(rejection.Error), rejection.StatusCode), true
+}
+
+// reusedClientSession refuses a submission under a client session id another one already used
+func reusedClientSession(clientSessionID string) apperror.Error {
+	return apperror.New(
+		fmt.Errorf("client session id %q was already used for a different submission", clientSessionID),
+		http.StatusUnprocessableEntity,
+	)
+}
+
+// applySessions reflects newly recorded sessions in the real-time rankings, signals the worker
+// and drops the cached standings of their users. Quarantined sessions are left out until
+// reviewed, and those of users under a ban in bans are never ranked.
+func (s *GameSessionsService) applySessions(
+	ctx context.Context,
+	sessions []models.GameSession,
+	bans map[int]models.LeaderboardBan,
+) {
+	records := make([]leaderboardSvc.ScoreRecord, 0, len(sessions))
+	userIDs := make(map[string][]string)
+	for _, session := range sessions {
+		if session.Status != constants.SessionStatusAccepted {
+			continue
+		}
+
+		if _, banned := bans[session.UserID]; banned {
+			continue
+		}
+
+		records = append(records, leaderboardSvc.ScoreRecord{
+			UserID:   session.UserID,
+			GameMode: session.GameMode,
+			Score:    session.Score,
+			PlayedAt: session.Timestamp,
+		})
+		userIDs[session.GameMode] = append(userIDs[session.GameMode], strconv.Itoa(session.UserID))
+	}
+
+	if len(records) == 0 {
+		return
+	}
+
+	// Ranking drift is repaired by the worker, so a failed increment must not fail the submission
+	if err := s.leaderboardService.RecordScores(ctx, records); err != nil {
+		log.Printf("[WARN] ranking update failed | sessions=%d | err=%v", len(records), err)
+	}
+
+	// A lost signal only delays ranking the sessions until the next submission
+	if err := s.leaderboardWorker.MarkPending(ctx); err != nil {
+		log.Printf("[WARN] pending leaderboard update not recorded | sessions=%d | err=%v", len(records), err)
+	}
+
+	if err := s.leaderboardService.InvalidateUsersCache(ctx, userIDs); err != nil {
+		log.Printf("[WARN] user cache invalidation failed | sessions=%d | err=%v", len(records), err)
+	}
+}
+
+// ListQuarantinedSessions pages through the sessions awaiting review, oldest first
+func (s *GameSessionsService) ListQuarantinedSessions(
+	ctx context.Context,
+	page int,
+	limit int,
+) ([]*models.GameSession, int64, apperror.Error) {
+	sessions, total, cusErr := s.repository.GetAllWithPagination(
+		ctx,
+		map[string]interface{}{constants.Status: constants.SessionStatusQuarantined},
+		baseRepository.Paginate(page, limit),
+		func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") },
+	)
+	if cusErr.Exists() {
+		return nil, 0, apperror.New(fmt.Errorf("unable to list quarantined sessions"), http.StatusInternalServerError)
+	}
+
+	return sessions, total, apperror.Error{}
+}
+
+// ReviewSession settles a quarantined session on behalf of actor, auditing the decision. An
+// approved session starts counting, which needs the boards recalculated in full since it
+// predates the sessions already ranked.
+func (s *GameSessionsService) ReviewSession(
+	ctx context.Context,
+	sessionID int,
+	actor string,
+	req request.ReviewSessionRequest,
+) (models.GameSession, apperror.Error) {
+	status, actionName := constants.SessionStatusRejected, constants.ModerationRejectSession
+	if req.Decision == constants.ReviewApprove {
+		status, actionName = constants.SessionStatusAccepted, constants.ModerationApproveSession
+	}
+
+	action := newModerationAction(
+		actionName,
+		constants.ModerationTargetSession,
+		sessionID,
+		actor,
+		request.ModerationRequest{Reason: req.Reason},
+	)
+
+	session, found, reviewed, err := s.repository.Review(ctx, sessionID, status, action)
+	if err != nil {
+		return models.GameSession{}, apperror.New(fmt.Errorf("unable to review session"), http.StatusInternalServerError)
+	}
+
+	if !found {
+		return models.GameSession{}, apperror.New(fmt.Errorf("session %d not found", sessionID), http.StatusNotFound)
+	}
+
+	if !reviewed {
+		return models.GameSession{}, apperror.New(
+			fmt.Errorf("session %d is %s, only quarantined sessions can be reviewed", sessionID, session.Status),
+			http.StatusConflict,
+		)
+	}
+
+	log.Printf("[INFO] session reviewed | session_id=%d | status=%s | actor=%s", sessionID, status, actor)
+
+	if status == constants.SessionStatusAccepted {
+		if err := s.leaderboardWorker.RequestFullRecalculation(ctx); err != nil {
+			log.Printf("[WARN] full recalculation not requested | session_id=%d | err=%v", sessionID, err)
+		}
+		s.leaderboardService.InvalidateUserCache(ctx, strconv.Itoa(session.UserID), session.GameMode)
+	}
+
+	return session, apperror.Error{}
+}
@@ -0,0 +1,376 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/controller/request"
+	"gaming-leaderboard/internal/game_sessions/service/validators"
+)
+
+func TestCreateGameSessionReplays(t *testing.T) {
+	s, db, cache := testService(t, []int{1, 2})
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	original := request.SubmitScoreRequest{
+		UserID:          1,
+		Score:           100,
+		GameMode:        constants.GameModeSolo,
+		ClientSessionID: "client-1",
+		SessionToken:    sessionToken(t, 1, constants.GameModeSolo, expiresAt),
+	}
+	created, replayed, cusErr := s.CreateGameSession(ctx, original)
+	if cusErr.Exists() || replayed || created.ID == 0 {
+		t.Fatalf("submit = %+v, %v, %v, want a new session", created, replayed, cusErr)
+	}
+
+	// A token signed for a session that already ended refuses any new submission
+	expired := original
+	expired.SessionToken = sessionToken(t, 1, constants.GameModeSolo, time.Now().Add(-time.Second))
+
+	retry := original
+	retry.Score = 150
+
+	reused := original
+	reused.ClientSessionID = ""
+
+	tests := []struct {
+		name         string
+		retry        request.SubmitScoreRequest
+		wantStatus   int
+		wantReplayed bool
+	}{
+		{
+			name:         "retry returns the original",
+			retry:        original,
+			wantReplayed: true,
+		},
+		{
+			name:         "retry is not validated again",
+			retry:        expired,
+			wantReplayed: true,
+		},
+		{
+			name:       "another score under the client session id is refused",
+			retry:      retry,
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "reuse of the token without the client session id is refused",
+			retry:      reused,
+			wantStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session, replayed, cusErr := s.CreateGameSession(ctx, tt.retry)
+			if cusErr.StatusCode() != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %v", cusErr.StatusCode(), tt.wantStatus, cusErr)
+			}
+			if replayed != tt.wantReplayed {
+				t.Errorf("replayed = %v, want %v", replayed, tt.wantReplayed)
+			}
+			if tt.wantReplayed && (session.ID != created.ID || session.Score != created.Score) {
+				t.Errorf("replayed %+v, want the original %+v", session, created)
+			}
+		})
+	}
+
+	if count := countSessions(t, db); count != 1 {
+		t.Errorf("recorded %d sessions, want 1", count)
+	}
+	if cache.marks != 1 {
+		t.Errorf("worker signalled %d times, want once for the original", cache.marks)
+	}
+}
+
+func TestCreateGameSessionReplaysRefusals(t *testing.T) {
+	s, db, _ := testService(t, []int{1, 2})
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	// The token was issued for another user
+	refused := request.SubmitScoreRequest{
+		UserID:          2,
+		Score:           100,
+		GameMode:        constants.GameModeSolo,
+		ClientSessionID: "client-1",
+		SessionToken:    sessionToken(t, 1, constants.GameModeSolo, expiresAt),
+	}
+	_, replayed, cusErr := s.CreateGameSession(ctx, refused)
+	if cusErr.StatusCode() != http.StatusForbidden || replayed {
+		t.Fatalf("submit = %v, %v, want refused", replayed, cusErr)
+	}
+
+	// A retry stays refused even once it would pass
+	fixed := refused
+	fixed.SessionToken = sessionToken(t, 2, constants.GameModeSolo, expiresAt)
+
+	changed := fixed
+	changed.Score = 150
+
+	tests := []struct {
+		name         string
+		retry        request.SubmitScoreRequest
+		wantStatus   int
+		wantError    string
+		wantReplayed bool
+	}{
+		{
+			name:         "retry is refused with the original error",
+			retry:        refused,
+			wantStatus:   http.StatusForbidden,
+			wantError:    cusErr.Error(),
+			wantReplayed: true,
+		},
+		{
+			name:         "retry with a valid token is refused with the original error",
+			retry:        fixed,
+			wantStatus:   http.StatusForbidden,
+			wantError:    cusErr.Error(),
+			wantReplayed: true,
+		},
+		{
+			name:       "another score under the client session id is refused",
+			retry:      changed,
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, replayed, cusErr := s.CreateGameSession(ctx, tt.retry)
+			if cusErr.StatusCode() != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %v", cusErr.StatusCode(), tt.wantStatus, cusErr)
+			}
+			if replayed != tt.wantReplayed {
+				t.Errorf("replayed = %v, want %v", replayed, tt.wantReplayed)
+			}
+			if tt.wantError != "" && cusErr.Error() != tt.wantError {
+				t.Errorf("error = %q, want %q", cusErr.Error(), tt.wantError)
+			}
+		})
+	}
+
+	if count := countSessions(t, db); count != 0 {
+		t.Errorf("recorded %d sessions, want none", count)
+	}
+}
+
+// quarantineAbove holds scores above limit for review
+type quarantineAbove struct {
+	limit int
+}
+
+func (r quarantineAbove) Name() string {
+	return "quarantine_above"
+}
+
+func (r quarantineAbove) Check(ctx context.Context, submission validators.Submission) (validators.Verdict, error) {
+	if submission.Score > r.limit {
+		return validators.Verdict{Action: validators.Quarantine, Reason: "score above the limit"}, nil
+	}
+
+	return validators.Verdict{Action: validators.Accept}, nil
+}
+
+// wantResult is what a batch entry is expected to come to. session is the index of the entry
+// whose session it returns, -1 for none.
+type wantResult struct {
+	status   string
+	session  int
+	replayed bool
+}
+
+// checkResults compares the results of a batch with want, entry for entry
+func checkResults(t *testing.T, results []SubmissionResult, want []wantResult) {
+	t.Helper()
+
+	if len(results) != len(want) {
+		t.Fatalf("%d results, want %d", len(results), len(want))
+	}
+
+	for i, result := range results {
+		if result.Index != i || result.Status != want[i].status || result.Replayed != want[i].replayed {
+			t.Errorf("entry %d = %+v, want %+v", i, result, want[i])
+			continue
+		}
+
+		if want[i].session < 0 {
+			if result.Session != nil || result.Error == "" {
+				t.Errorf("entry %d = %+v, want an error and no session", i, result)
+			}
+			continue
+		}
+
+		same := results[want[i].session].Session
+		if result.Session == nil || result.Session.ID == 0 || same == nil || result.Session.ID != same.ID {
+			t.Errorf("entry %d = %+v, want the session of entry %d", i, result, want[i].session)
+		}
+	}
+}
+
+func TestCreateGameSessions(t *testing.T) {
+	s, db, cache := testService(t, []int{1, 2, 3}, quarantineAbove{limit: 1000})
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	earlier := request.SubmitScoreRequest{
+		UserID:          1,
+		Score:           100,
+		GameMode:        constants.GameModeSolo,
+		ClientSessionID: "client-1",
+		SessionToken:    sessionToken(t, 1, constants.GameModeSolo, expiresAt),
+	}
+	recorded, _, cusErr := s.CreateGameSession(ctx, earlier)
+	if cusErr.Exists() {
+		t.Fatalf("submit: %v", cusErr)
+	}
+
+	batch := []request.SubmitScoreRequest{
+		{
+			UserID:          2,
+			Score:           200,
+			GameMode:        constants.GameModeSolo,
+			ClientSessionID: "client-2",
+			SessionToken:    sessionToken(t, 2, constants.GameModeSolo, expiresAt),
+		},
+		earlier,
+		{
+			UserID:          3,
+			GameMode:        constants.GameModeSolo,
+			ClientSessionID: "client-3",
+			SessionToken:    sessionToken(t, 3, constants.GameModeSolo, expiresAt),
+		},
+		{
+			UserID:          3,
+			Score:           300,
+			GameMode:        constants.GameModeSolo,
+			ClientSessionID: "client-4",
+			SessionToken:    sessionToken(t, 2, constants.GameModeSolo, expiresAt),
+		},
+		{
+			UserID:          3,
+			Score:           5000,
+			GameMode:        constants.GameModeSolo,
+			ClientSessionID: "client-5",
+			SessionToken:    sessionToken(t, 3, constants.GameModeSolo, expiresAt),
+		},
+		{
+			UserID:       4,
+			Score:        400,
+			GameMode:     constants.GameModeSolo,
+			SessionToken: sessionToken(t, 4, constants.GameModeSolo, expiresAt),
+		},
+	}
+
+	results, cusErr := s.CreateGameSessions(ctx, batch)
+	if cusErr.Exists() {
+		t.Fatalf("submit batch: %v", cusErr)
+	}
+
+	checkResults(t, results, []wantResult{
+		{status: constants.SubmissionCreated, session: 0},
+		{status: constants.SubmissionReplayed, session: 1},
+		// The score is missing
+		{status: constants.SubmissionRejected, session: -1},
+		// The token was issued for another user
+		{status: constants.SubmissionRejected, session: -1},
+		{status: constants.SubmissionQuarantined, session: 4},
+		// The user does not exist
+		{status: constants.SubmissionRejected, session: -1},
+	})
+	if results[1].Session != nil && results[1].Session.ID != recorded.ID {
+		t.Errorf("replayed session %d, want the earlier %d", results[1].Session.ID, recorded.ID)
+	}
+	if count := countSessions(t, db); count != 3 {
+		t.Errorf("recorded %d sessions, want 3", count)
+	}
+
+	// Retrying the batch records nothing, every entry comes to what it did the first time
+	retried, cusErr := s.CreateGameSessions(ctx, batch)
+	if cusErr.Exists() {
+		t.Fatalf("retry batch: %v", cusErr)
+	}
+
+	checkResults(t, retried, []wantResult{
+		{status: constants.SubmissionReplayed, session: 0},
+		{status: constants.SubmissionReplayed, session: 1},
+		{status: constants.SubmissionRejected, session: -1},
+		{status: constants.SubmissionRejected, session: -1, replayed: true},
+		{status: constants.SubmissionReplayed, session: 4},
+		{status: constants.SubmissionRejected, session: -1},
+	})
+	for _, i := range []int{0, 1, 4} {
+		if retried[i].Session != nil && results[i].Session != nil && retried[i].Session.ID != results[i].Session.ID {
+			t.Errorf("entry %d replayed session %d, want %d", i, retried[i].Session.ID, results[i].Session.ID)
+		}
+	}
+	if retried[3].Error != results[3].Error {
+		t.Errorf("refusal replayed as %q, want %q", retried[3].Error, results[3].Error)
+	}
+
+	if count := countSessions(t, db); count != 3 {
+		t.Errorf("recorded %d sessions after the retry, want 3", count)
+	}
+	// Once for the earlier submission and once for the batch, the retry created nothing
+	if cache.marks != 2 {
+		t.Errorf("worker signalled %d times, want 2", cache.marks)
+	}
+}
+
+func TestCreateGameSessionsDuplicatesInBatch(t *testing.T) {
+	s, db, _ := testService(t, []int{1, 2})
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	submission := request.SubmitScoreRequest{
+		UserID:          1,
+		Score:           100,
+		GameMode:        constants.GameModeSolo,
+		ClientSessionID: "client-1",
+		SessionToken:    sessionToken(t, 1, constants.GameModeSolo, expiresAt),
+	}
+
+	reused := submission
+	reused.ClientSessionID = "client-2"
+
+	other := request.SubmitScoreRequest{
+		UserID:       2,
+		Score:        200,
+		GameMode:     constants.GameModeSolo,
+		SessionToken: sessionToken(t, 2, constants.GameModeSolo, expiresAt),
+	}
+
+	// The duplicates fail the single insert, the sessions are then inserted one by one
+	results, cusErr := s.CreateGameSessions(ctx, []request.SubmitScoreRequest{submission, submission, reused, other})
+	if cusErr.Exists() {
+		t.Fatalf("submit batch: %v", cusErr)
+	}
+
+	checkResults(t, results, []wantResult{
+		{status: constants.SubmissionCreated, session: 0},
+		{status: constants.SubmissionReplayed, session: 0},
+		// The token was already used by the first entry
+		{status: constants.SubmissionRejected, session: -1},
+		{status: constants.SubmissionCreated, session: 3},
+	})
+
+	if count := countSessions(t, db); count != 2 {
+		t.Errorf("recorded %d sessions, want 2", count)
+	}
+}
+
+func TestCreateGameSessionsBatchSize(t *testing.T) {
+	s := NewGameSessionsService(nil, nil, nil, nil, nil, nil, nil, nil, Config{MaxBatchSize: 2})
+
+	entries := make([]request.SubmitScoreRequest, 3)
+	if _, cusErr := s.CreateGameSessions(context.Background(), entries); cusErr.StatusCode() != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", cusErr.StatusCode(), http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/controller/request"
+	"gaming-leaderboard/internal/models"
+	baseRepository "gaming-leaderboard/internal/repository"
+	"gaming-leaderboard/pkg/apperror"
+
+	"gorm.io/gorm"
+)
+
+// VoidSession soft-deletes a session so it stops counting on every board. It stays stored
+// with the reason and the moderator, and RestoreSession brings it back.
+func (s *GameSessionsService) VoidSession(
+	ctx context.Context,
+	sessionID int,
+	actor string,
+	req request.ModerationRequest,
+) (models.GameSession, apperror.Error) {
+	action := newModerationAction(constants.ModerationVoidSession, constants.ModerationTargetSession, sessionID, actor, req)
+
+	session, found, voided, err := s.repository.Void(ctx, sessionID, action)
+	if err != nil {
+		return models.GameSession{}, apperror.New(fmt.Errorf("unable to void session"), http.StatusInternalServerError)
+	}
+
+	if !found {
+		return models.GameSession{}, apperror.New(fmt.Errorf("session %d not found", sessionID), http.StatusNotFound)
+	}
+
+	if !voided {
+		return models.GameSession{}, apperror.New(fmt.Errorf("session %d is already voided", sessionID), http.StatusConflict)
+	}
+
+	log.Printf("[INFO] session voided | session_id=%d | actor=%s", sessionID, actor)
+	s.restand(ctx, session.UserID, session.Timestamp, session.GameMode)
+	s.rerank(ctx, session.UserID, session.GameMode)
+
+	return session, apperror.Error{}
+}
+
+// RestoreSession undoes the void of a session, which counts again on the real-time rankings
+// at once and on the stored standings from the next recalculation
+func (s *GameSessionsService) RestoreSession(
+	ctx context.Context,
+	sessionID int,
+	actor string,
+	req request.ModerationRequest,
+) (models.GameSession, apperror.Error) {
+	action := newModerationAction(constants.ModerationRestoreSession, constants.ModerationTargetSession, sessionID, actor, req)
+
+	session, found, restored, err := s.repository.Restore(ctx, sessionID, action)
+	if err != nil {
+		return models.GameSession{}, apperror.New(fmt.Errorf("unable to restore session"), http.StatusInternalServerError)
+	}
+
+	if !found {
+		return models.GameSession{}, apperror.New(fmt.Errorf("session %d not found", sessionID), http.StatusNotFound)
+	}
+
+	if !restored {
+		return models.GameSession{}, apperror.New(fmt.Errorf("session %d is not voided", sessionID), http.StatusConflict)
+	}
+
+	log.Printf("[INFO] session restored | session_id=%d | actor=%s", sessionID, actor)
+	s.restand(ctx, session.UserID, session.Timestamp, session.GameMode)
+	s.rerank(ctx, session.UserID, session.GameMode)
+
+	return session, apperror.Error{}
+}
+
+// BanUser takes a user off every board. A ban also refuses the user's submissions, a shadow
+// ban accepts them and keeps showing the user their own standing so they cannot tell. Banning
+// an already banned user with the other kind switches the kind.
+func (s *GameSessionsService) BanUser(
+	ctx context.Context,
+	userID int,
+	actor string,
+	req request.BanUserRequest,
+) (models.LeaderboardBan, apperror.Error) {
+	actionName := constants.ModerationBanUser
+	if req.Kind == constants.BanKindShadow {
+		actionName = constants.ModerationShadowBanUser
+	}
+	action := newModerationAction(actionName, constants.ModerationTargetUser, userID, actor, req.ModerationRequest)
+
+	ban := models.LeaderboardBan{
+		UserID:    userID,
+		Kind:      req.Kind,
+		Reason:    req.Reason,
+		Actor:     actor,
+		CreatedAt: action.CreatedAt,
+	}
+
+	userFound, changed, err := s.bansRepository.Ban(ctx, &ban, action)
+	if err != nil {
+		return models.LeaderboardBan{}, apperror.New(fmt.Errorf("unable to ban user"), http.StatusInternalServerError)
+	}
+
+	if !userFound {
+		return models.LeaderboardBan{}, apperror.New(fmt.Errorf("user %d not found", userID), http.StatusNotFound)
+	}
+
+	if !changed {
+		return models.LeaderboardBan{}, apperror.New(
+			fmt.Errorf("user %d is already under a %s ban", userID, req.Kind),
+			http.StatusConflict,
+		)
+	}
+
+	log.Printf("[INFO] user banned | user_id=%d | kind=%s | actor=%s", userID, req.Kind, actor)
+	s.restand(ctx, userID, time.Now(), constants.GameModeSolo, constants.GameModeTeam)
+	s.rerank(ctx, userID, constants.GameModeSolo, constants.GameModeTeam)
+
+	return ban, apperror.Error{}
+}
+
+// UnbanUser lifts a user's ban, returning it. The user's sessions count again on the current
+// real-time rankings at once and on the stored standings from the next recalculation.
+func (s *GameSessionsService) UnbanUser(
+	ctx context.Context,
+	userID int,
+	actor string,
+	req request.ModerationRequest,
+) (models.LeaderboardBan, apperror.Error) {
+	action := newModerationAction(constants.ModerationUnbanUser, constants.ModerationTargetUser, userID, actor, req)
+
+	ban, found, err := s.bansRepository.Unban(ctx, userID, action)
+	if err != nil {
+		return models.LeaderboardBan{}, apperror.New(fmt.Errorf("unable to unban user"), http.StatusInternalServerError)
+	}
+
+	if !found {
+		return models.LeaderboardBan{}, apperror.New(fmt.Errorf("user %d is not banned", userID), http.StatusNotFound)
+	}
+
+	log.Printf("[INFO] user unbanned | user_id=%d | kind=%s | actor=%s", userID, ban.Kind, actor)
+	s.restand(ctx, userID, time.Now(), constants.GameModeSolo, constants.GameModeTeam)
+	s.rerank(ctx, userID, constants.GameModeSolo, constants.GameModeTeam)
+
+	return ban, apperror.Error{}
+}
+
+// ListBans pages through the bans in force, most recent first
+func (s *GameSessionsService) ListBans(
+	ctx context.Context,
+	page int,
+	limit int,
+) ([]*models.LeaderboardBan, int64, apperror.Error) {
+	bans, total, cusErr := s.bansRepository.GetAllWithPagination(
+		ctx,
+		map[string]interface{}{},
+		baseRepository.Paginate(page, limit),
+		func(db *gorm.DB) *gorm.DB { return db.Order("created_at DESC, user_id ASC") },
+	)
+	if cusErr.Exists() {
+		return nil, 0, apperror.New(fmt.Errorf("unable to list bans"), http.StatusInternalServerError)
+	}
+
+	return bans, total, apperror.Error{}
+}
+
+// ListModerationActions pages through the moderation audit log, most recent first, optionally
+// narrowed to one kind of target or one target
+func (s *GameSessionsService) ListModerationActions(
+	ctx context.Context,
+	query request.ModerationLogQuery,
+) ([]*models.ModerationAction, int64, apperror.Error) {
+	filter := map[string]interface{}{}
+	if query.TargetType != "" {
+		filter["target_type"] = query.TargetType
+	}
+	if query.TargetID != 0 {
+		filter["target_id"] = query.TargetID
+	}
+
+	actions, total, cusErr := s.moderationActionsRepository.GetAllWithPagination(
+		ctx,
+		filter,
+		baseRepository.Paginate(query.Page, query.Limit),
+		func(db *gorm.DB) *gorm.DB { return db.Order("id DESC") },
+	)
+	if cusErr.Exists() {
+		return nil, 0, apperror.New(fmt.Errorf("unable to list moderation actions"), http.StatusInternalServerError)
+	}
+
+	return actions, total, apperror.Error{}
+}
+
+func newModerationAction(
+	action string,
+	targetType string,
+	targetID int,
+	actor string,
+	req request.ModerationRequest,
+) *models.ModerationAction {
+	return &models.ModerationAction{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Reason:     req.Reason,
+		Actor:      actor,
+		CreatedAt:  time.Now().UTC(),
+	}
+}
+
+// restand recomputes a user's score on the real-time rankings of the boards of gameModes
+// covering at from the sessions that still count, so a ban, void or restore shows on them
+// before the full recalculation rerank requests
+func (s *GameSessionsService) restand(ctx context.Context, userID int, at time.Time, gameModes ...string) {
+	for _, gameMode := range gameModes {
+		if err := s.leaderboardService.RestandInRankings(ctx, userID, gameMode, at); err != nil {
+			log.Printf("[WARN] user standing not recomputed in rankings | user_id=%d | game_mode=%s | err=%v", userID, gameMode, err)
+		}
+	}
+}
+
+// rerank has the boards recalculated in full after a moderation action changed which of a
+// user's sessions count, and drops the user's cached standings on the boards of gameModes.
+// Only a full recalculation removes the stored standings and rebuilds the real-time rankings.
+func (s *GameSessionsService) rerank(ctx context.Context, userID int, gameModes ...string) {
+	if err := s.leaderboardWorker.RequestFullRecalculation(ctx); err != nil {
+		log.Printf("[WARN] full recalculation not requested | user_id=%d | err=%v", userID, err)
+	}
+
+	userIDs := make(map[string][]string, len(gameModes))
+	for _, gameMode := range gameModes {
+		userIDs[gameMode] = []string{strconv.Itoa(userID)}
+	}
+
+	if err := s.leaderboardService.InvalidateUsersCache(ctx, userIDs); err != nil {
+		log.Printf("[WARN] user cache invalidation failed | user_id=%d | err=%v", userID, err)
+	}
+}
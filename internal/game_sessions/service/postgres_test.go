@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/game_sessions/repository"
+	"gaming-leaderboard/internal/game_sessions/service/validators"
+	leaderboardRepo "gaming-leaderboard/internal/leaderboard/repository"
+	leaderboardSvc "gaming-leaderboard/internal/leaderboard/service"
+	"gaming-leaderboard/internal/models"
+	teamsRepo "gaming-leaderboard/internal/teams/repository"
+	usersRepo "gaming-leaderboard/internal/users/repository"
+	"gaming-leaderboard/pkg/db/postgres"
+	oredis "gaming-leaderboard/pkg/redis"
+
+	"gorm.io/gorm"
+)
+
+// testSecret signs the session tokens of the test submissions
+var testSecret = []byte("test-session-secret")
+
+// testDB opens the database the TEST_POSTGRES_* variables name, the docker-compose one by
+// default, in a schema of its own that is dropped once the test ends. The test is skipped when
+// TEST_POSTGRES_HOST is not set.
+func testDB(t *testing.T) *postgres.DbCluster {
+	t.Helper()
+
+	host := os.Getenv("TEST_POSTGRES_HOST")
+	if host == "" {
+		t.Skip("TEST_POSTGRES_HOST is not set, skipping the Postgres test")
+	}
+
+	ctx := context.Background()
+	cluster := postgres.InitializeDBInstance(ctx, postgres.DBConfig{
+		Host:               host,
+		Port:               envOr("TEST_POSTGRES_PORT", "5433"),
+		Username:           envOr("TEST_POSTGRES_USER", "admin"),
+		Password:           envOr("TEST_POSTGRES_PASSWORD", "admin"),
+		Dbname:             envOr("TEST_POSTGRES_DB", "crud"),
+		MaxOpenConnections: 4,
+		MaxIdleConnections: 1,
+	}, &[]postgres.DBConfig{}, nil)
+
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	db, err := cluster.ForSchema(ctx, schema, 4, 1, nil)
+	if err != nil {
+		t.Fatalf("open schema %s: %v", schema, err)
+	}
+
+	t.Cleanup(func() {
+		closeDB(t, db.GetMasterDB(ctx))
+		if err := cluster.GetMasterDB(ctx).Exec(fmt.Sprintf(`DROP SCHEMA "%s" CASCADE`, schema)).Error; err != nil {
+			t.Errorf("drop schema %s: %v", schema, err)
+		}
+		closeDB(t, cluster.GetMasterDB(ctx))
+	})
+
+	return db
+}
+
+func envOr(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+
+	return fallback
+}
+
+func closeDB(t *testing.T, db *gorm.DB) {
+	sqlDB, err := db.DB()
+	if err == nil {
+		err = sqlDB.Close()
+	}
+	if err != nil {
+		t.Errorf("close connections: %v", err)
+	}
+}
+
+// sessionsCache stands in for Redis behind the leaderboard service. No boards are defined, so
+// recording a score touches no ranking; it only counts the times the worker is signalled.
+// The other Cache methods are not used and panic.
+type sessionsCache struct {
+	oredis.Cache
+	marks int
+}
+
+func (c *sessionsCache) Get(ctx context.Context, key string, out interface{}) (bool, error) {
+	return false, nil
+}
+
+func (c *sessionsCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	return true, nil
+}
+
+func (c *sessionsCache) PipedZUpdate(ctx context.Context, updates []oredis.ZUpdate) error {
+	return nil
+}
+
+func (c *sessionsCache) HSetNX(ctx context.Context, key string, field string, value interface{}) (bool, error) {
+	if key == constants.LeaderboardPendingKey && field == constants.PendingFirstField {
+		c.marks++
+	}
+
+	return true, nil
+}
+
+func (c *sessionsCache) HSet(ctx context.Context, key string, values map[string]interface{}) error {
+	return nil
+}
+
+// testService is a GameSessionsService recording sessions in a test schema, under rules. The
+// users are created with it.
+func testService(t *testing.T, userIDs []int, rules ...validators.Rule) (*GameSessionsService, *gorm.DB, *sessionsCache) {
+	t.Helper()
+
+	db := testDB(t)
+	ctx := context.Background()
+
+	for _, userID := range userIDs {
+		if err := db.GetMasterDB(ctx).Create(&models.User{ID: userID, Username: fmt.Sprintf("user-%d", userID)}).Error; err != nil {
+			t.Fatalf("create user %d: %v", userID, err)
+		}
+	}
+
+	cache := &sessionsCache{}
+	leaderboardRepository := leaderboardRepo.NewLeaderboardRepository(db)
+	usersRepository := usersRepo.NewUsersRepository(db)
+	bansRepository := leaderboardRepo.NewBansRepository(db)
+	leaderboardService := leaderboardSvc.NewLeaderboardService(
+		leaderboardRepository,
+		leaderboardRepo.NewSeasonsRepository(db),
+		leaderboardRepo.NewSeasonStandingsRepository(db),
+		leaderboardRepo.NewRankHistoryRepository(db),
+		bansRepository,
+		usersRepository,
+		usersRepo.NewFriendshipsRepository(db),
+		leaderboardRepo.NewTeamLeaderboardRepository(db),
+		leaderboardRepo.NewDefinitionsRepository(db),
+		cache,
+		leaderboardSvc.Config{Location: time.UTC},
+	)
+
+	s := NewGameSessionsService(
+		repository.NewGameSessionsRepository(db),
+		usersRepository,
+		teamsRepo.NewTeamsRepository(db),
+		bansRepository,
+		leaderboardRepo.NewModerationActionsRepository(db),
+		leaderboardService,
+		leaderboardSvc.NewLeaderboardWorker(leaderboardRepository, leaderboardService, leaderboardSvc.WorkerConfig{}),
+		validators.Chain(rules),
+		Config{MaxBatchSize: 10, TokenSecret: testSecret, TokenTTL: time.Hour},
+	)
+
+	return s, db.GetMasterDB(ctx), cache
+}
+
+// sessionToken signs a token for a play session of userID in gameMode that expires at expiresAt
+func sessionToken(t *testing.T, userID int, gameMode string, expiresAt time.Time) string {
+	t.Helper()
+
+	sessionID, err := newSessionID()
+	if err != nil {
+		t.Fatalf("session id: %v", err)
+	}
+
+	token, err := signSession(testSecret, sessionClaims{
+		SessionID: sessionID,
+		UserID:    userID,
+		GameMode:  gameMode,
+		IssuedAt:  time.Now().Add(-time.Minute).UnixMilli(),
+		ExpiresAt: expiresAt.UnixMilli(),
+	})
+	if err != nil {
+		t.Fatalf("sign session: %v", err)
+	}
+
+	return token
+}
+
+// countSessions is how many sessions are recorded
+func countSessions(t *testing.T, db *gorm.DB) int64 {
+	t.Helper()
+
+	var count int64
+	if err := db.Model(&models.GameSession{}).Count(&count).Error; err != nil {
+		t.Fatalf("count sessions: %v", err)
+	}
+
+	return count
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"gaming-leaderboard/pkg/apperror"
+)
+
+// sessionClaims are the fields signed into a session token. Times are unix milliseconds.
+type sessionClaims struct {
+	SessionID string `json:"sid"`
+	UserID    int    `json:"uid"`
+	GameMode  string `json:"mode"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// SessionStart is a play session opened with POST /sessions/start, its token must accompany
+// the score submitted at the end of the session
+type SessionStart struct {
+	SessionID    string    `json:"session_id"`
+	SessionToken string    `json:"session_token"`
+	StartedAt    time.Time `json:"started_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// signSession encodes claims as a token: the base64url JSON claims and their base64url
+// HMAC-SHA256, joined by a dot
+func signSession(secret []byte, claims sessionClaims) (string, error) {
+	raw, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(raw)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(sessionMAC(secret, payload)), nil
+}
+
+func sessionMAC(secret []byte, payload string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// verifySession returns the claims of a token signed with secret that has not expired at now
+func verifySession(secret []byte, token string, now time.Time) (sessionClaims, apperror.Error) {
+	invalid := apperror.New(fmt.Errorf("invalid session token"), http.StatusUnauthorized)
+
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return sessionClaims{}, invalid
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, sessionMAC(secret, payload)) {
+		return sessionClaims{}, invalid
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return sessionClaims{}, invalid
+	}
+
+	var claims sessionClaims
+	if err := json.Unmarshal(raw, &claims); err != nil || claims.SessionID == "" {
+		return sessionClaims{}, invalid
+	}
+
+	if now.UnixMilli() >= claims.ExpiresAt {
+		return sessionClaims{}, apperror.New(fmt.Errorf("session token expired"), http.StatusUnauthorized)
+	}
+
+	return claims, apperror.Error{}
+}
+
+// newSessionID returns a random play session id
+func newSessionID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(id), nil
+}
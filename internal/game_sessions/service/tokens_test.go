@@ -0,0 +1,83 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionToken(t *testing.T) {
+	secret := []byte("session-secret")
+	issuedAt := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	claims := sessionClaims{
+		SessionID: "0f1e2d3c4b5a69788796a5b4c3d2e1f0",
+		UserID:    42,
+		GameMode:  "solo",
+		IssuedAt:  issuedAt.UnixMilli(),
+		ExpiresAt: issuedAt.Add(time.Hour).UnixMilli(),
+	}
+
+	token, err := signSession(secret, claims)
+	if err != nil {
+		t.Fatalf("signSession: %v", err)
+	}
+	payload, signature, _ := strings.Cut(token, ".")
+
+	forged, err := signSession(secret, sessionClaims{
+		SessionID: claims.SessionID,
+		UserID:    7,
+		GameMode:  claims.GameMode,
+		IssuedAt:  claims.IssuedAt,
+		ExpiresAt: claims.ExpiresAt,
+	})
+	if err != nil {
+		t.Fatalf("signSession: %v", err)
+	}
+	forgedPayload, _, _ := strings.Cut(forged, ".")
+
+	unnamed, err := signSession(secret, sessionClaims{UserID: 42, ExpiresAt: claims.ExpiresAt})
+	if err != nil {
+		t.Fatalf("signSession: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		secret  []byte
+		token   string
+		now     time.Time
+		message string
+	}{
+		{name: "valid", secret: secret, token: token, now: issuedAt.Add(time.Minute)},
+		{name: "valid until the last millisecond", secret: secret, token: token, now: issuedAt.Add(time.Hour - time.Millisecond)},
+		{name: "expired", secret: secret, token: token, now: issuedAt.Add(time.Hour), message: "session token expired"},
+		{name: "other secret", secret: []byte("other-secret"), token: token, now: issuedAt, message: "invalid session token"},
+		{name: "payload swapped", secret: secret, token: forgedPayload + "." + signature, now: issuedAt, message: "invalid session token"},
+		{name: "signature dropped", secret: secret, token: payload, now: issuedAt, message: "invalid session token"},
+		{name: "signature not base64", secret: secret, token: payload + ".!!", now: issuedAt, message: "invalid session token"},
+		{name: "no session id", secret: secret, token: unnamed, now: issuedAt, message: "invalid session token"},
+		{name: "empty", secret: secret, token: "", now: issuedAt, message: "invalid session token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, cusErr := verifySession(tt.secret, tt.token, tt.now)
+
+			if tt.message != "" {
+				if !cusErr.Exists() {
+					t.Fatalf("verifySession = %+v, want %q", got, tt.message)
+				}
+				if cusErr.Error() != tt.message {
+					t.Errorf("error = %q, want %q", cusErr.Error(), tt.message)
+				}
+				return
+			}
+
+			if cusErr.Exists() {
+				t.Fatalf("verifySession: %v", cusErr)
+			}
+			if got != claims {
+				t.Errorf("claims = %+v, want %+v", got, claims)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package validators
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Action is what a rule asks for a submission
+type Action int
+
+const (
+	Accept     Action = iota // count the score right away
+	Quarantine               // record the score but hold it out of rankings until reviewed
+	Reject                   // refuse the submission
+)
+
+// Submission is a score as the validators see it
+type Submission struct {
+	UserID      int
+	GameMode    string
+	Score       int
+	Duration    time.Duration
+	SubmittedAt time.Time
+}
+
+// Verdict is a rule's decision on a submission. Code is the HTTP status of a rejection.
+type Verdict struct {
+	Action Action
+	Rule   string
+	Reason string
+	Code   int
+}
+
+// Rule is one anti-cheat check
+type Rule interface {
+	Name() string
+	Check(ctx context.Context, submission Submission) (Verdict, error)
+}
+
+// Chain runs rules in order and returns the first rejection, or else the first quarantine.
+// A rule that cannot be evaluated is skipped, so an outage of what it reads never blocks
+// submissions.
+type Chain []Rule
+
+func (c Chain) Check(ctx context.Context, submission Submission) Verdict {
+	verdict := Verdict{Action: Accept}
+
+	for _, rule := range c {
+		v, err := rule.Check(ctx, submission)
+		if err != nil {
+			log.Printf("[WARN] anti-cheat rule skipped | rule=%s | user_id=%d | err=%v", rule.Name(), submission.UserID, err)
+			continue
+		}
+
+		if v.Action <= verdict.Action {
+			continue
+		}
+
+		v.Rule = rule.Name()
+		verdict = v
+		if verdict.Action == Reject {
+			break
+		}
+	}
+
+	return verdict
+}
@@ -0,0 +1,90 @@
+package validators
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// verdictRule is a rule returning a fixed verdict, or failing with err
+type verdictRule struct {
+	name    string
+	verdict Verdict
+	err     error
+	checked *int
+}
+
+func (r verdictRule) Name() string {
+	return r.name
+}
+
+func (r verdictRule) Check(ctx context.Context, submission Submission) (Verdict, error) {
+	if r.checked != nil {
+		*r.checked++
+	}
+	return r.verdict, r.err
+}
+
+func TestChainCheck(t *testing.T) {
+	accept := Verdict{Action: Accept}
+	quarantine := Verdict{Action: Quarantine, Reason: "too fast"}
+	reject := Verdict{Action: Reject, Reason: "out of range", Code: http.StatusUnprocessableEntity}
+
+	tests := []struct {
+		name  string
+		rules []verdictRule
+		want  Verdict
+	}{
+		{
+			name: "no rules accept",
+			want: accept,
+		},
+		{
+			name:  "every rule accepts",
+			rules: []verdictRule{{name: "a", verdict: accept}, {name: "b", verdict: accept}},
+			want:  accept,
+		},
+		{
+			name:  "first quarantine wins over later ones",
+			rules: []verdictRule{{name: "a", verdict: accept}, {name: "b", verdict: quarantine}, {name: "c", verdict: Verdict{Action: Quarantine, Reason: "outlier"}}},
+			want:  Verdict{Action: Quarantine, Rule: "b", Reason: "too fast"},
+		},
+		{
+			name:  "rejection wins over an earlier quarantine",
+			rules: []verdictRule{{name: "a", verdict: quarantine}, {name: "b", verdict: reject}},
+			want:  Verdict{Action: Reject, Rule: "b", Reason: "out of range", Code: http.StatusUnprocessableEntity},
+		},
+		{
+			name:  "failing rule is skipped",
+			rules: []verdictRule{{name: "a", verdict: reject, err: errors.New("redis down")}, {name: "b", verdict: quarantine}},
+			want:  Verdict{Action: Quarantine, Rule: "b", Reason: "too fast"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := make(Chain, 0, len(tt.rules))
+			for _, rule := range tt.rules {
+				chain = append(chain, rule)
+			}
+
+			if got := chain.Check(context.Background(), Submission{UserID: 1}); got != tt.want {
+				t.Errorf("Check = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChainStopsAtRejection(t *testing.T) {
+	var checked int
+	chain := Chain{
+		verdictRule{name: "a", verdict: Verdict{Action: Reject}, checked: &checked},
+		verdictRule{name: "b", verdict: Verdict{Action: Accept}, checked: &checked},
+	}
+
+	chain.Check(context.Background(), Submission{UserID: 1})
+	if checked != 1 {
+		t.Errorf("checked %d rules, want 1", checked)
+	}
+}
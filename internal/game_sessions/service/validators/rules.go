@@ -0,0 +1,150 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/models"
+	oredis "gaming-leaderboard/pkg/redis"
+)
+
+// ScoreLimit bounds the scores of one game mode
+type ScoreLimit struct {
+	Min int
+	Max int
+}
+
+// ScoreRange rejects scores outside their game mode's limits, modes without limits are unbounded
+type ScoreRange struct {
+	Limits map[string]ScoreLimit
+}
+
+func (r ScoreRange) Name() string {
+	return "score_range"
+}
+
+func (r ScoreRange) Check(ctx context.Context, submission Submission) (Verdict, error) {
+	limit, ok := r.Limits[submission.GameMode]
+	if !ok {
+		return Verdict{Action: Accept}, nil
+	}
+
+	if submission.Score < limit.Min || (limit.Max > 0 && submission.Score > limit.Max) {
+		return Verdict{
+			Action: Reject,
+			Reason: fmt.Sprintf("score %d is outside the %s range %d-%d", submission.Score, submission.GameMode, limit.Min, limit.Max),
+			Code:   http.StatusUnprocessableEntity,
+		}, nil
+	}
+
+	return Verdict{Action: Accept}, nil
+}
+
+// ScoreRate quarantines scores earned faster than their game mode allows per second of play
+type ScoreRate struct {
+	MaxPerSecond map[string]float64
+}
+
+func (r ScoreRate) Name() string {
+	return "score_rate"
+}
+
+func (r ScoreRate) Check(ctx context.Context, submission Submission) (Verdict, error) {
+	maxPerSecond := r.MaxPerSecond[submission.GameMode]
+	if maxPerSecond <= 0 {
+		return Verdict{Action: Accept}, nil
+	}
+
+	// A session shorter than a second is judged as lasting one
+	seconds := submission.Duration.Seconds()
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	if rate := float64(submission.Score) / seconds; rate > maxPerSecond {
+		return Verdict{
+			Action: Quarantine,
+			Reason: fmt.Sprintf("scored %.1f per second, at most %.1f expected", rate, maxPerSecond),
+		}, nil
+	}
+
+	return Verdict{Action: Accept}, nil
+}
+
+// SubmissionRate rejects a user's submissions beyond Limit per Window
+type SubmissionRate struct {
+	Cache  oredis.Cache
+	Limit  int64
+	Window time.Duration
+}
+
+func (r SubmissionRate) Name() string {
+	return "submission_rate"
+}
+
+func (r SubmissionRate) Check(ctx context.Context, submission Submission) (Verdict, error) {
+	if r.Limit <= 0 || r.Window <= 0 {
+		return Verdict{Action: Accept}, nil
+	}
+
+	window := submission.SubmittedAt.UnixNano() / int64(r.Window)
+	count, err := r.Cache.IncrExpire(ctx, fmt.Sprintf(constants.SubmissionRateKeyFormat, submission.UserID, window), r.Window)
+	if err != nil {
+		return Verdict{}, err
+	}
+
+	if count > r.Limit {
+		return Verdict{
+			Action: Reject,
+			Reason: fmt.Sprintf("more than %d submissions in %v", r.Limit, r.Window),
+			Code:   http.StatusTooManyRequests,
+		}, nil
+	}
+
+	return Verdict{Action: Accept}, nil
+}
+
+// ScoreHistory reads the statistics of a user's recent accepted scores in a game mode
+type ScoreHistory interface {
+	ScoreStats(ctx context.Context, userID int, gameMode string, samples int) (models.ScoreStats, error)
+}
+
+// Outlier quarantines scores more than ZScore standard deviations above the user's last Samples
+// accepted scores, once at least MinSamples of them exist
+type Outlier struct {
+	History    ScoreHistory
+	ZScore     float64
+	MinSamples int
+	Samples    int
+}
+
+func (r Outlier) Name() string {
+	return "outlier"
+}
+
+func (r Outlier) Check(ctx context.Context, submission Submission) (Verdict, error) {
+	if r.ZScore <= 0 || r.Samples <= 0 {
+		return Verdict{Action: Accept}, nil
+	}
+
+	stats, err := r.History.ScoreStats(ctx, submission.UserID, submission.GameMode, r.Samples)
+	if err != nil {
+		return Verdict{}, err
+	}
+
+	if stats.Count < int64(r.MinSamples) || stats.StdDev == 0 {
+		return Verdict{Action: Accept}, nil
+	}
+
+	if z := (float64(submission.Score) - stats.Mean) / stats.StdDev; z > r.ZScore {
+		return Verdict{
+			Action: Quarantine,
+			Reason: fmt.Sprintf("score is %.1f standard deviations above the user's recent mean %.0f", z, stats.Mean),
+		}, nil
+	}
+
+	return Verdict{Action: Accept}, nil
+}
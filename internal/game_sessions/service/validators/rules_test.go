@@ -0,0 +1,120 @@
+package validators
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"gaming-leaderboard/internal/models"
+)
+
+// scoreHistory serves fixed score statistics
+type scoreHistory struct {
+	stats models.ScoreStats
+	err   error
+}
+
+func (h scoreHistory) ScoreStats(ctx context.Context, userID int, gameMode string, samples int) (models.ScoreStats, error) {
+	return h.stats, h.err
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name       string
+		rule       Rule
+		submission Submission
+		want       Action
+		code       int
+		failed     bool
+	}{
+		{
+			name:       "score inside the range is accepted",
+			rule:       ScoreRange{Limits: map[string]ScoreLimit{"solo": {Min: 0, Max: 1000}}},
+			submission: Submission{GameMode: "solo", Score: 1000},
+			want:       Accept,
+		},
+		{
+			name:       "score above the range is rejected",
+			rule:       ScoreRange{Limits: map[string]ScoreLimit{"solo": {Min: 0, Max: 1000}}},
+			submission: Submission{GameMode: "solo", Score: 1001},
+			want:       Reject,
+			code:       http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "score below the range is rejected",
+			rule:       ScoreRange{Limits: map[string]ScoreLimit{"solo": {Min: 0}}},
+			submission: Submission{GameMode: "solo", Score: -1},
+			want:       Reject,
+			code:       http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "mode without limits is unbounded",
+			rule:       ScoreRange{Limits: map[string]ScoreLimit{"solo": {Min: 0, Max: 1000}}},
+			submission: Submission{GameMode: "team", Score: 1 << 30},
+			want:       Accept,
+		},
+		{
+			name:       "score earned at the allowed rate is accepted",
+			rule:       ScoreRate{MaxPerSecond: map[string]float64{"solo": 10}},
+			submission: Submission{GameMode: "solo", Score: 600, Duration: time.Minute},
+			want:       Accept,
+		},
+		{
+			name:       "score earned too fast is quarantined",
+			rule:       ScoreRate{MaxPerSecond: map[string]float64{"solo": 10}},
+			submission: Submission{GameMode: "solo", Score: 601, Duration: time.Minute},
+			want:       Quarantine,
+		},
+		{
+			name:       "session shorter than a second counts as one",
+			rule:       ScoreRate{MaxPerSecond: map[string]float64{"solo": 10}},
+			submission: Submission{GameMode: "solo", Score: 10, Duration: time.Millisecond},
+			want:       Accept,
+		},
+		{
+			name:       "outlier score is quarantined",
+			rule:       Outlier{History: scoreHistory{stats: models.ScoreStats{Count: 20, Mean: 100, StdDev: 10}}, ZScore: 3, MinSamples: 10, Samples: 50},
+			submission: Submission{Score: 131},
+			want:       Quarantine,
+		},
+		{
+			name:       "score within the deviation is accepted",
+			rule:       Outlier{History: scoreHistory{stats: models.ScoreStats{Count: 20, Mean: 100, StdDev: 10}}, ZScore: 3, MinSamples: 10, Samples: 50},
+			submission: Submission{Score: 130},
+			want:       Accept,
+		},
+		{
+			name:       "outlier is accepted before enough samples exist",
+			rule:       Outlier{History: scoreHistory{stats: models.ScoreStats{Count: 9, Mean: 100, StdDev: 10}}, ZScore: 3, MinSamples: 10, Samples: 50},
+			submission: Submission{Score: 1000},
+			want:       Accept,
+		},
+		{
+			name:       "unreadable history fails the rule",
+			rule:       Outlier{History: scoreHistory{err: errors.New("replica down")}, ZScore: 3, MinSamples: 10, Samples: 50},
+			submission: Submission{Score: 1000},
+			failed:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict, err := tt.rule.Check(context.Background(), tt.submission)
+			if tt.failed {
+				if err == nil {
+					t.Fatalf("Check = %+v, want an error", verdict)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Check: %v", err)
+			}
+			if verdict.Action != tt.want || verdict.Code != tt.code {
+				t.Errorf("Check = %+v, want action %d code %d", verdict, tt.want, tt.code)
+			}
+		})
+	}
+}
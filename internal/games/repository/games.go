@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"log"
+
+	"gaming-leaderboard/internal/models"
+	"gaming-leaderboard/internal/repository"
+	"gaming-leaderboard/pkg/db/postgres"
+
+	"gorm.io/gorm/clause"
+)
+
+type GamesRepository struct {
+	repository.Interface[models.Game]
+	db *postgres.DbCluster
+}
+
+func NewGamesRepository(db *postgres.DbCluster) *GamesRepository {
+	return &GamesRepository{
+		Interface: &repository.Repository[models.Game]{Db: db},
+		db:        db,
+	}
+}
+
+// CreateUnique inserts game unless its slug, schema or API key is taken, in which case created
+// is false
+func (r *GamesRepository) CreateUnique(ctx context.Context, game *models.Game) (created bool, err error) {
+	tx := r.db.GetMasterDB(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(game)
+	if tx.Error != nil {
+		log.Printf("[ERROR] CreateUnique: game slug=%s | err=%v", game.Slug, tx.Error)
+		return false, tx.Error
+	}
+
+	return tx.RowsAffected == 1, nil
+}
+
+// GetByAPIKeyHash returns the game holding an API key, found is false when no game does. It
+// reads from the master so rotated keys stop working at once.
+func (r *GamesRepository) GetByAPIKeyHash(ctx context.Context, hash string) (game models.Game, found bool, err error) {
+	if err := r.db.GetMasterDB(ctx).
+		Where("api_key_hash = ?", hash).
+		Limit(1).
+		Find(&game).Error; err != nil {
+		log.Printf("[ERROR] GetByAPIKeyHash: err=%v", err)
+		return models.Game{}, false, err
+	}
+
+	return game, game.ID != 0, nil
+}
+
+// Update writes the named fields of game to the game with gameID and returns the game as
+// saved, found is false when there is no such game
+func (r *GamesRepository) Update(
+	ctx context.Context,
+	gameID int,
+	game models.Game,
+	fields []string,
+) (saved models.Game, found bool, err error) {
+	tx := r.db.GetMasterDB(ctx).
+		Model(&saved).
+		Clauses(clause.Returning{}).
+		Where("id = ?", gameID).
+		Select(append(fields, "updated_at")).
+		Updates(&game)
+	if tx.Error != nil {
+		log.Printf("[ERROR] Update: game_id=%d | err=%v", gameID, tx.Error)
+		return models.Game{}, false, tx.Error
+	}
+
+	return saved, tx.RowsAffected == 1, nil
+}
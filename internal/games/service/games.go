@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/controller/request"
+	"gaming-leaderboard/internal/games/repository"
+	leaderboardSvc "gaming-leaderboard/internal/leaderboard/service"
+	"gaming-leaderboard/internal/models"
+	baseRepository "gaming-leaderboard/internal/repository"
+	"gaming-leaderboard/pkg/apperror"
+
+	"gorm.io/gorm"
+)
+
+// slugPattern keeps slugs usable as Postgres schema names and Redis key segments as they are
+var slugPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{2,31}$`)
+
+type GamesService struct {
+	repository *repository.GamesRepository
+	// teamBoards are the configured team boards, which seed every game's team board definitions
+	teamBoards []models.TeamBoard
+}
+
+func NewGamesService(repo *repository.GamesRepository, teamBoards []models.TeamBoard) *GamesService {
+	return &GamesService{
+		repository: repo,
+		teamBoards: teamBoards,
+	}
+}
+
+// GameCredentials is a game along with its API key, which is shown only when it is issued
+type GameCredentials struct {
+	Game   models.Game `json:"game"`
+	APIKey string      `json:"api_key"`
+}
+
+// CreateGame registers a game under a slug no other game has, in a schema of its own, and
+// issues its API key
+func (s *GamesService) CreateGame(ctx context.Context, req request.CreateGameRequest) (GameCredentials, apperror.Error) {
+	if !slugPattern.MatchString(req.Slug) {
+		return GameCredentials{}, apperror.New(fmt.Errorf("slug must match %s", slugPattern), http.StatusBadRequest)
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return GameCredentials{}, apperror.New(fmt.Errorf("name must not be blank"), http.StatusBadRequest)
+	}
+
+	if len(req.Boards) > 0 {
+		if err := s.validateBoards(req.Boards); err != nil {
+			return GameCredentials{}, apperror.New(fmt.Errorf("invalid boards: %w", err), http.StatusBadRequest)
+		}
+	}
+
+	apiKey, err := newAPIKey()
+	if err != nil {
+		return GameCredentials{}, apperror.New(fmt.Errorf("unable to create game"), http.StatusInternalServerError)
+	}
+
+	game := models.Game{
+		Slug:       req.Slug,
+		Name:       name,
+		SchemaName: fmt.Sprintf(constants.GameSchemaFormat, req.Slug),
+		APIKeyHash: HashAPIKey(apiKey),
+		Boards:     req.Boards,
+	}
+
+	created, err := s.repository.CreateUnique(ctx, &game)
+	if err != nil {
+		return GameCredentials{}, apperror.New(fmt.Errorf("unable to create game"), http.StatusInternalServerError)
+	}
+
+	if !created {
+		return GameCredentials{}, apperror.New(fmt.Errorf("slug %q is taken", game.Slug), http.StatusConflict)
+	}
+
+	log.Printf("[INFO] game created | game_id=%d | slug=%s", game.ID, game.Slug)
+	return GameCredentials{Game: game, APIKey: apiKey}, apperror.Error{}
+}
+
+// EnsureDefaultGame registers the game served from the public schema, which holds the data
+// from before games existed, under apiKey. A default game already registered is left as it
+// is, so a key rotated since keeps working.
+func (s *GamesService) EnsureDefaultGame(ctx context.Context, slug, name, apiKey string) error {
+	if !slugPattern.MatchString(slug) {
+		return fmt.Errorf("slug must match %s", slugPattern)
+	}
+
+	if apiKey == "" {
+		return fmt.Errorf("api key must not be empty")
+	}
+
+	if apiKey == constants.PlaceholderSecret {
+		return fmt.Errorf("api key must not be the placeholder %q", constants.PlaceholderSecret)
+	}
+
+	game := models.Game{
+		Slug:       slug,
+		Name:       name,
+		SchemaName: constants.PublicSchema,
+		APIKeyHash: HashAPIKey(apiKey),
+	}
+
+	created, err := s.repository.CreateUnique(ctx, &game)
+	if err != nil {
+		return err
+	}
+
+	if created {
+		log.Printf("[INFO] default game created | game_id=%d | slug=%s", game.ID, game.Slug)
+	}
+
+	return nil
+}
+
+// GetGame returns one game
+func (s *GamesService) GetGame(ctx context.Context, gameID int) (models.Game, apperror.Error) {
+	games, cusErr := s.repository.GetAll(ctx, map[string]interface{}{"id": gameID})
+	if cusErr.Exists() {
+		return models.Game{}, apperror.New(fmt.Errorf("unable to get game"), http.StatusInternalServerError)
+	}
+
+	if len(games) == 0 {
+		return models.Game{}, apperror.New(fmt.Errorf("game %d not found", gameID), http.StatusNotFound)
+	}
+
+	return *games[0], apperror.Error{}
+}
+
+// ListGames pages through the games in the order they were created
+func (s *GamesService) ListGames(ctx context.Context, page int, limit int) ([]*models.Game, int64, apperror.Error) {
+	games, total, cusErr := s.repository.GetAllWithPagination(
+		ctx,
+		map[string]interface{}{},
+		baseRepository.Paginate(page, limit),
+		func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") },
+	)
+	if cusErr.Exists() {
+		return nil, 0, apperror.New(fmt.Errorf("unable to list games"), http.StatusInternalServerError)
+	}
+
+	return games, total, apperror.Error{}
+}
+
+// AllGames lists every game
+func (s *GamesService) AllGames(ctx context.Context) ([]*models.Game, apperror.Error) {
+	games, cusErr := s.repository.GetAll(ctx, map[string]interface{}{})
+	if cusErr.Exists() {
+		return nil, apperror.New(fmt.Errorf("unable to list games"), http.StatusInternalServerError)
+	}
+
+	return games, apperror.Error{}
+}
+
+// UpdateGame changes a game's name
+func (s *GamesService) UpdateGame(ctx context.Context, gameID int, req request.UpdateGameRequest) (models.Game, apperror.Error) {
+	var game models.Game
+	var fields []string
+	if req.Name != nil {
+		game.Name = strings.TrimSpace(*req.Name)
+		if game.Name == "" {
+			return models.Game{}, apperror.New(fmt.Errorf("name must not be blank"), http.StatusBadRequest)
+		}
+		fields = append(fields, "name")
+	}
+
+	if len(fields) == 0 {
+		return s.GetGame(ctx, gameID)
+	}
+
+	saved, found, err := s.repository.Update(ctx, gameID, game, fields)
+	if err != nil {
+		return models.Game{}, apperror.New(fmt.Errorf("unable to update game"), http.StatusInternalServerError)
+	}
+
+	if !found {
+		return models.Game{}, apperror.New(fmt.Errorf("game %d not found", gameID), http.StatusNotFound)
+	}
+
+	log.Printf("[INFO] game updated | game_id=%d", gameID)
+	return saved, apperror.Error{}
+}
+
+// RotateAPIKey issues a new API key for a game, the previous key stops working
+func (s *GamesService) RotateAPIKey(ctx context.Context, gameID int) (GameCredentials, apperror.Error) {
+	apiKey, err := newAPIKey()
+	if err != nil {
+		return GameCredentials{}, apperror.New(fmt.Errorf("unable to rotate api key"), http.StatusInternalServerError)
+	}
+
+	saved, found, err := s.repository.Update(ctx, gameID, models.Game{APIKeyHash: HashAPIKey(apiKey)}, []string{"api_key_hash"})
+	if err != nil {
+		return GameCredentials{}, apperror.New(fmt.Errorf("unable to rotate api key"), http.StatusInternalServerError)
+	}
+
+	if !found {
+		return GameCredentials{}, apperror.New(fmt.Errorf("game %d not found", gameID), http.StatusNotFound)
+	}
+
+	log.Printf("[INFO] game api key rotated | game_id=%d", gameID)
+	return GameCredentials{Game: saved, APIKey: apiKey}, apperror.Error{}
+}
+
+// Authenticate returns the game an API key belongs to
+func (s *GamesService) Authenticate(ctx context.Context, apiKey string) (models.Game, apperror.Error) {
+	if apiKey == "" {
+		return models.Game{}, apperror.New(fmt.Errorf("missing api key"), http.StatusUnauthorized)
+	}
+
+	game, found, err := s.repository.GetByAPIKeyHash(ctx, HashAPIKey(apiKey))
+	if err != nil {
+		return models.Game{}, apperror.New(fmt.Errorf("unable to check api key"), http.StatusInternalServerError)
+	}
+
+	if !found {
+		return models.Game{}, apperror.New(fmt.Errorf("invalid api key"), http.StatusUnauthorized)
+	}
+
+	return game, apperror.Error{}
+}
+
+// validateBoards checks the boards a game's definitions are seeded from as the configured ones
+// are checked, filling in their defaults, and that the configured team boards can be ranked
+// alongside them
+func (s *GamesService) validateBoards(boards []models.Board) error {
+	if err := leaderboardSvc.ValidateBoards(boards); err != nil {
+		return err
+	}
+
+	teamBoards := append([]models.TeamBoard(nil), s.teamBoards...)
+	return leaderboardSvc.ValidateTeamBoards(teamBoards, boards)
+}
+
+// HashAPIKey is how API keys are stored and looked up
+func HashAPIKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
+
+func newAPIKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		log.Printf("[ERROR] newAPIKey: err=%v", err)
+		return "", err
+	}
+
+	return hex.EncodeToString(key), nil
+}
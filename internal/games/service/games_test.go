@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/controller/request"
+	"gaming-leaderboard/internal/games/repository"
+	"gaming-leaderboard/internal/models"
+	"gaming-leaderboard/pkg/db/postgres"
+)
+
+func TestCreateGameRejectsInvalidSlugs(t *testing.T) {
+	tests := []struct {
+		name string
+		slug string
+	}{
+		{name: "upper case", slug: "Arcade"},
+		{name: "too short", slug: "ab"},
+		{name: "too long", slug: strings.Repeat("a", 33)},
+		{name: "leading digit", slug: "1arcade"},
+		{name: "hyphen", slug: "arc-ade"},
+		{name: "quote breaking out of the schema name", slug: `arcade"; DROP SCHEMA public CASCADE; --`},
+		{name: "empty", slug: ""},
+	}
+
+	// Without a repository any game that got past validation would panic
+	s := NewGamesService(nil, nil)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, cusErr := s.CreateGame(context.Background(), request.CreateGameRequest{Slug: tt.slug, Name: "Arcade"})
+			if cusErr.StatusCode() != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", cusErr.StatusCode(), http.StatusBadRequest)
+			}
+
+			if err := s.EnsureDefaultGame(context.Background(), tt.slug, "Arcade", "key"); err == nil {
+				t.Errorf("default game accepted slug %q", tt.slug)
+			}
+		})
+	}
+}
+
+func TestHashAPIKey(t *testing.T) {
+	hash := HashAPIKey("arcade-key")
+
+	if hash == "arcade-key" || strings.Contains(hash, "arcade") {
+		t.Errorf("hash %q reveals the key", hash)
+	}
+	if HashAPIKey("arcade-key") != hash {
+		t.Errorf("hashing the same key twice differs")
+	}
+	if HashAPIKey("arcade-kez") == hash {
+		t.Errorf("different keys hash the same")
+	}
+}
+
+func TestAuthenticateMissingKey(t *testing.T) {
+	s := NewGamesService(nil, nil)
+
+	if _, cusErr := s.Authenticate(context.Background(), ""); cusErr.StatusCode() != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", cusErr.StatusCode(), http.StatusUnauthorized)
+	}
+}
+
+// TestAuthenticate registers a game in the games table the TEST_POSTGRES_* variables name,
+// the docker-compose one by default, and removes it once the test ends. It is skipped when
+// TEST_POSTGRES_HOST is not set.
+func TestAuthenticate(t *testing.T) {
+	host := os.Getenv("TEST_POSTGRES_HOST")
+	if host == "" {
+		t.Skip("TEST_POSTGRES_HOST is not set, skipping the Postgres test")
+	}
+
+	ctx := context.Background()
+	db := postgres.InitializeDBInstance(ctx, postgres.DBConfig{
+		Host:               host,
+		Port:               envOr("TEST_POSTGRES_PORT", "5433"),
+		Username:           envOr("TEST_POSTGRES_USER", "admin"),
+		Password:           envOr("TEST_POSTGRES_PASSWORD", "admin"),
+		Dbname:             envOr("TEST_POSTGRES_DB", "crud"),
+		MaxOpenConnections: 2,
+		MaxIdleConnections: 1,
+	}, &[]postgres.DBConfig{}, nil)
+	t.Cleanup(func() {
+		if sqlDB, err := db.GetMasterDB(ctx).DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	s := NewGamesService(repository.NewGamesRepository(db), nil)
+	slug := fmt.Sprintf("test_%d", time.Now().UnixNano()%1_000_000_000)
+
+	credentials, cusErr := s.CreateGame(ctx, request.CreateGameRequest{Slug: slug, Name: "Test"})
+	if cusErr.Exists() {
+		t.Fatalf("create game: %v", cusErr)
+	}
+	t.Cleanup(func() {
+		if err := db.GetMasterDB(ctx).Delete(&models.Game{}, credentials.Game.ID).Error; err != nil {
+			t.Errorf("delete game %s: %v", slug, err)
+		}
+	})
+
+	if credentials.Game.SchemaName != fmt.Sprintf(constants.GameSchemaFormat, slug) {
+		t.Errorf("schema = %q, want the game's own", credentials.Game.SchemaName)
+	}
+	if credentials.Game.APIKeyHash != HashAPIKey(credentials.APIKey) {
+		t.Errorf("stored %q, want the hash of the issued key", credentials.Game.APIKeyHash)
+	}
+
+	game, cusErr := s.Authenticate(ctx, credentials.APIKey)
+	if cusErr.Exists() {
+		t.Fatalf("authenticate issued key: %v", cusErr)
+	}
+	if game.ID != credentials.Game.ID {
+		t.Errorf("authenticated game %d, want %d", game.ID, credentials.Game.ID)
+	}
+
+	if _, cusErr := s.Authenticate(ctx, credentials.APIKey+"0"); cusErr.StatusCode() != http.StatusUnauthorized {
+		t.Errorf("unknown key status = %d, want %d", cusErr.StatusCode(), http.StatusUnauthorized)
+	}
+
+	// A rotated key stops working at once
+	rotated, cusErr := s.RotateAPIKey(ctx, credentials.Game.ID)
+	if cusErr.Exists() {
+		t.Fatalf("rotate key: %v", cusErr)
+	}
+	if _, cusErr := s.Authenticate(ctx, credentials.APIKey); cusErr.StatusCode() != http.StatusUnauthorized {
+		t.Errorf("rotated out key status = %d, want %d", cusErr.StatusCode(), http.StatusUnauthorized)
+	}
+	if _, cusErr := s.Authenticate(ctx, rotated.APIKey); cusErr.Exists() {
+		t.Errorf("authenticate rotated key: %v", cusErr)
+	}
+}
+
+func envOr(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+
+	return fallback
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"log"
+
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/models"
+	"gaming-leaderboard/internal/repository"
+	"gaming-leaderboard/pkg/db/postgres"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// definitionFields are the columns an update replaces, a board keeps its name and kind for good
+// since its standings are stored under it
+var definitionFields = []string{
+	"game_mode",
+	"windows",
+	"aggregation",
+	"sessions",
+	"rank_type",
+	"tie_breaker",
+	"sort_direction",
+	"size_cap",
+	"refresh_seconds",
+	"cache_seconds",
+	"tier_basis",
+	"tiers",
+	"split",
+	"members",
+	"updated_at",
+}
+
+type DefinitionsRepository struct {
+	repository.Interface[models.LeaderboardDefinition]
+	db *postgres.DbCluster
+}
+
+func NewDefinitionsRepository(db *postgres.DbCluster) *DefinitionsRepository {
+	return &DefinitionsRepository{
+		Interface: &repository.Repository[models.LeaderboardDefinition]{Db: db},
+		db:        db,
+	}
+}
+
+// All returns every definition in the order they were created. It reads from the master so a
+// reload right after a change sees it.
+func (r *DefinitionsRepository) All(ctx context.Context) ([]models.LeaderboardDefinition, error) {
+	var definitions []models.LeaderboardDefinition
+	if err := r.db.GetMasterDB(ctx).
+		Order("id ASC").
+		Find(&definitions).Error; err != nil {
+		log.Printf("[ERROR] All: leaderboard definitions err=%v", err)
+		return nil, err
+	}
+
+	return definitions, nil
+}
+
+// SeededKinds returns the kinds of definition already seeded
+func (r *DefinitionsRepository) SeededKinds(ctx context.Context) (map[string]bool, error) {
+	var kinds []string
+	if err := r.db.GetMasterDB(ctx).
+		Table("leaderboard_definition_seeds").
+		Pluck("kind", &kinds).Error; err != nil {
+		log.Printf("[ERROR] SeededKinds: err=%v", err)
+		return nil, err
+	}
+
+	seeded := make(map[string]bool, len(kinds))
+	for _, kind := range kinds {
+		seeded[kind] = true
+	}
+
+	return seeded, nil
+}
+
+// Seed stores the definitions of one kind in order unless that kind was seeded before,
+// skipping any whose name is already defined
+func (r *DefinitionsRepository) Seed(ctx context.Context, kind string, definitions []models.LeaderboardDefinition) error {
+	err := r.db.GetMasterDB(ctx).Transaction(func(tx *gorm.DB) error {
+		marked := tx.Exec(
+			"INSERT INTO leaderboard_definition_seeds (kind) VALUES (?) ON CONFLICT (kind) DO NOTHING",
+			kind,
+		)
+		if marked.Error != nil || marked.RowsAffected == 0 || len(definitions) == 0 {
+			return marked.Error
+		}
+
+		for i := range definitions {
+			definitions[i].Kind = kind
+		}
+
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&definitions).Error
+	})
+	if err != nil {
+		log.Printf("[ERROR] Seed: kind=%s | definitions=%d | err=%v", kind, len(definitions), err)
+		return err
+	}
+
+	return nil
+}
+
+// CreateUnique inserts definition unless its name is taken, in which case created is false
+func (r *DefinitionsRepository) CreateUnique(ctx context.Context, definition *models.LeaderboardDefinition) (created bool, err error) {
+	tx := r.db.GetMasterDB(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(definition)
+	if tx.Error != nil {
+		log.Printf("[ERROR] CreateUnique: board=%s | err=%v", definition.Name, tx.Error)
+		return false, tx.Error
+	}
+
+	return tx.RowsAffected == 1, nil
+}
+
+// Update replaces every field of the definition with definitionID but its name and returns
+// the definition as saved, found is false when there is no such definition
+func (r *DefinitionsRepository) Update(
+	ctx context.Context,
+	definitionID int,
+	definition models.LeaderboardDefinition,
+) (saved models.LeaderboardDefinition, found bool, err error) {
+	tx := r.db.GetMasterDB(ctx).
+		Model(&saved).
+		Clauses(clause.Returning{}).
+		Where("id = ?", definitionID).
+		Select(definitionFields).
+		Updates(&definition)
+	if tx.Error != nil {
+		log.Printf("[ERROR] Update: definition_id=%d | err=%v", definitionID, tx.Error)
+		return models.LeaderboardDefinition{}, false, tx.Error
+	}
+
+	return saved, tx.RowsAffected == 1, nil
+}
+
+// Delete removes the definition with definitionID and returns it, found is false when there
+// is no such definition. The board's standings and watermarks, or the team board's standings,
+// go with it, so a board later created under the same name starts from nothing.
+func (r *DefinitionsRepository) Delete(
+	ctx context.Context,
+	definitionID int,
+) (deleted models.LeaderboardDefinition, found bool, err error) {
+	err = r.db.GetMasterDB(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.Returning{}).
+			Where("id = ?", definitionID).
+			Delete(&deleted)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		found = true
+
+		if err := tx.Exec("DELETE FROM leaderboard_watermarks WHERE "+constants.Board+" = ?", deleted.Name).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where(constants.Board+" = ?", deleted.Name).Delete(&models.TeamStanding{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Where(constants.Board+" = ?", deleted.Name).Delete(&models.Leaderboard{}).Error
+	})
+	if err != nil {
+		log.Printf("[ERROR] Delete: definition_id=%d | err=%v", definitionID, err)
+		return models.LeaderboardDefinition{}, false, err
+	}
+
+	return deleted, found, nil
+}
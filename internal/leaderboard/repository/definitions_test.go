@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/models"
+)
+
+func TestDefinitionUpdateBumpsVersion(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	repo := NewDefinitionsRepository(db)
+
+	definition := models.LeaderboardDefinition{
+		Kind: constants.DefinitionKindBoard,
+		Board: models.Board{
+			Name:        "global",
+			GameMode:    constants.GameModeSolo,
+			Aggregation: constants.AggregationSum,
+			RankType:    constants.RankCompetition,
+		},
+	}
+	created, err := repo.CreateUnique(ctx, &definition)
+	if err != nil || !created {
+		t.Fatalf("create = %v, %v, want created", created, err)
+	}
+
+	edited := definition
+	edited.Aggregation = constants.AggregationBest
+	saved, found, err := repo.Update(ctx, definition.ID, edited)
+	if err != nil || !found {
+		t.Fatalf("update = %v, %v, want found", found, err)
+	}
+
+	if saved.Aggregation != constants.AggregationBest {
+		t.Errorf("saved aggregation = %s, want %s", saved.Aggregation, constants.AggregationBest)
+	}
+	if saved.Version() <= definition.Version() {
+		t.Errorf("version after the edit = %d, want above %d", saved.Version(), definition.Version())
+	}
+
+	stored, err := repo.All(ctx)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(stored) != 1 || stored[0].Version() != saved.Version() {
+		t.Errorf("stored definitions = %+v, want the edit at version %d", stored, saved.Version())
+	}
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// leaderboardWorkerFence names the leaderboard worker's row in worker_fences
+const leaderboardWorkerFence = "leaderboard_worker"
+
+// ErrFenced is returned when a newer holder of the worker lock has already written, so the
+// caller is no longer the leader and its work was rolled back
+var ErrFenced = errors.New("leaderboard worker lock lost to a newer holder")
+
+// Fence identifies the worker lock term a write is made under
+type Fence struct {
+	Token  int64
+	Holder string
+}
+
+// checkFence records fence as the newest term to write inside tx, or fails with ErrFenced when
+// a newer one already has. The row stays locked until tx ends, so terms cannot interleave.
+func checkFence(tx *gorm.DB, fence Fence) error {
+	result := tx.Exec(`
+		INSERT INTO worker_fences (name, token, holder, updated_at)
+		VALUES (@name, @token, @holder, @updated_at)
+		ON CONFLICT (name)
+		DO UPDATE SET
+			token = EXCLUDED.token,
+			holder = EXCLUDED.holder,
+			updated_at = EXCLUDED.updated_at
+		WHERE worker_fences.token <= EXCLUDED.token
+	`,
+		sql.Named("name", leaderboardWorkerFence),
+		sql.Named("token", fence.Token),
+		sql.Named("holder", fence.Holder),
+		sql.Named("updated_at", time.Now().UTC()),
+	)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrFenced
+	}
+
+	return nil
+}
+
+// LatestFence returns the newest worker lock term that has written, 0 before any has. New
+// terms are issued above it, so losing the counter that issues them cannot fence every
+// future leader out.
+func (r *LeaderboardRepository) LatestFence(ctx context.Context) (int64, error) {
+	var token int64
+	err := r.db.GetMasterDB(ctx).
+		Raw("SELECT COALESCE(MAX(token), 0) FROM worker_fences WHERE name = ?", leaderboardWorkerFence).
+		Scan(&token).Error
+	if err != nil {
+		log.Printf("[ERROR] LatestFence: failed to read worker fence | err=%v", err)
+		return 0, err
+	}
+
+	return token, nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestCheckFence(t *testing.T) {
+	tests := []struct {
+		name   string
+		tokens []int64
+		// want is the error of the last write, latest the token recorded after it
+		want   error
+		latest int64
+	}{
+		{
+			name:   "the first term writes",
+			tokens: []int64{3},
+			latest: 3,
+		},
+		{
+			name:   "a term writes again",
+			tokens: []int64{3, 3},
+			latest: 3,
+		},
+		{
+			name:   "a newer term writes",
+			tokens: []int64{3, 4},
+			latest: 4,
+		},
+		{
+			name:   "an older term is fenced",
+			tokens: []int64{3, 4, 3},
+			want:   ErrFenced,
+			latest: 4,
+		},
+		{
+			name:   "a term issued above the latest writes",
+			tokens: []int64{7, 2, 8},
+			latest: 8,
+		},
+	}
+
+	ctx := context.Background()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testDB(t)
+			repo := NewLeaderboardRepository(db)
+
+			var err error
+			for _, token := range tt.tokens {
+				err = db.GetMasterDB(ctx).Transaction(func(tx *gorm.DB) error {
+					return checkFence(tx, Fence{Token: token, Holder: "test"})
+				})
+			}
+			if !errors.Is(err, tt.want) {
+				t.Errorf("last write = %v, want %v", err, tt.want)
+			}
+
+			latest, err := repo.LatestFence(ctx)
+			if err != nil {
+				t.Fatalf("latest fence: %v", err)
+			}
+			if latest != tt.latest {
+				t.Errorf("latest fence = %d, want %d", latest, tt.latest)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// boardPeriodFilter selects a board period's rows from leaderboard or rank_history
+const boardPeriodFilter = "board = @board AND time_window = @window AND period_start = @period_start"
+
+// incrementalAggregation describes how sessions newer than a board's watermark are folded
+// into existing standings: score and reachedAt aggregate the new sessions per user, and the
+// merge expressions combine them with the stored row inside ON CONFLICT DO UPDATE
+type incrementalAggregation struct {
+	score        string
+	reachedAt    string
+	mergeScore   string
+	mergeReached string
+}
+
+// incrementalSQL returns the incremental form of a board's aggregation, ok is false for
+// aggregations that depend on sessions other than the new ones
+func incrementalSQL(board models.Board) (agg incrementalAggregation, ok bool) {
+	score := sessionScore(board)
+
+	switch board.Aggregation {
+	case constants.AggregationSum:
+		return incrementalAggregation{
+			score:        fmt.Sprintf("SUM(%s)", score),
+			reachedAt:    "COALESCE(MAX(timestamp) FILTER (WHERE score <> 0), MIN(timestamp))",
+			mergeScore:   "leaderboard.total_score + EXCLUDED.total_score",
+			mergeReached: "CASE WHEN EXCLUDED.total_score <> 0 THEN GREATEST(leaderboard.reached_at, EXCLUDED.reached_at) ELSE leaderboard.reached_at END",
+		}, true
+	case constants.AggregationBest:
+		return incrementalAggregation{
+			score:        fmt.Sprintf("MAX(%s)", score),
+			reachedAt:    fmt.Sprintf("(ARRAY_AGG(timestamp ORDER BY %s DESC, timestamp ASC))[1]", score),
+			mergeScore:   "GREATEST(leaderboard.total_score, EXCLUDED.total_score)",
+			mergeReached: "CASE WHEN EXCLUDED.total_score > leaderboard.total_score THEN EXCLUDED.reached_at ELSE leaderboard.reached_at END",
+		}, true
+	case constants.AggregationLatest:
+		return incrementalAggregation{
+			score:        fmt.Sprintf("(ARRAY_AGG(%s ORDER BY timestamp DESC, id DESC))[1]", score),
+			reachedAt:    "MAX(timestamp)",
+			mergeScore:   "CASE WHEN leaderboard.reached_at IS NULL OR EXCLUDED.reached_at >= leaderboard.reached_at THEN EXCLUDED.total_score ELSE leaderboard.total_score END",
+			mergeReached: "GREATEST(leaderboard.reached_at, EXCLUDED.reached_at)",
+		}, true
+	default:
+		return incrementalAggregation{}, false
+	}
+}
+
+// movedBand summarises the standings changed by new sessions: the lowest and highest score any
+// changed user moved from or to, and how many users were ranked for the first time
+type movedBand struct {
+	Joined int64
+	Low    sql.NullInt64
+	High   sql.NullInt64
+}
+
+// applyNewSessions folds the sessions visible to tx but not to the snapshot of the board
+// period's watermark into its standings, and re-ranks only the users whose rank they can have
+// changed. applied is false when the board has no watermark yet or its aggregation cannot be
+// applied incrementally, leaving the caller to recalculate it in full.
+//
+// Sessions are told apart by the transaction that wrote them rather than by id, so a session
+// committed after one with a higher id is still folded in exactly once.
+func applyNewSessions(tx *gorm.DB, p boardPeriod, snapshot string) (applied bool, err error) {
+	agg, ok := incrementalSQL(p.board)
+	if !ok {
+		return false, nil
+	}
+
+	var watermark string
+	err = tx.Raw(
+		"SELECT CAST(last_snapshot AS TEXT) FROM leaderboard_watermarks WHERE "+boardPeriodFilter,
+		p.args()...,
+	).Row().Scan(&watermark)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if watermark == snapshot {
+		return true, nil
+	}
+
+	// Every CTE reads the standings as they were before the upsert
+	upsert := fmt.Sprintf(`
+		WITH deltas AS (
+			SELECT
+				user_id,
+				%s as total_score,
+				%s as reached_at
+			FROM game_sessions
+			WHERE xact_id >= pg_snapshot_xmin(CAST(@watermark AS pg_snapshot))
+				AND NOT pg_visible_in_snapshot(xact_id, CAST(@watermark AS pg_snapshot))
+				AND %s
+			GROUP BY user_id
+		),
+		previous AS (
+			SELECT user_id, total_score
+			FROM leaderboard
+			WHERE %s AND user_id IN (SELECT user_id FROM deltas)
+		),
+		upserted AS (
+			INSERT INTO leaderboard (user_id, board, game_mode, time_window, period_start, total_score, reached_at)
+			SELECT user_id, @board, @game_mode, @window, @period_start, total_score, reached_at FROM deltas
+			ON CONFLICT (board, time_window, period_start, user_id)
+			DO UPDATE SET
+				total_score = %s,
+				reached_at = %s
+			RETURNING user_id, total_score
+		)
+		SELECT
+			COUNT(*) FILTER (WHERE previous.user_id IS NULL) as joined,
+			LEAST(MIN(upserted.total_score), MIN(previous.total_score)) as low,
+			GREATEST(MAX(upserted.total_score), MAX(previous.total_score)) as high
+		FROM upserted
+		LEFT JOIN previous ON previous.user_id = upserted.user_id
+	`, agg.score, agg.reachedAt, p.sessionFilter(), boardPeriodFilter, agg.mergeScore, agg.mergeReached)
+
+	var band movedBand
+	if err := tx.Raw(
+		upsert,
+		p.args(sql.Named("watermark", watermark))...,
+	).Scan(&band).Error; err != nil {
+		return false, err
+	}
+
+	if !band.High.Valid {
+		// None of the new sessions count towards this board period
+		return true, nil
+	}
+
+	if err := rerankBand(tx, p, band); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// rerankBand recomputes the ranks of a board period's users scoring inside the moved band,
+// offset by the users above it, and records the changed standings. Users below the band keep
+// their relative order, so their ranks only shift by the users newly ranked above them; dense
+// ranks also depend on which distinct scores exist above, so for dense boards the band runs
+// to the bottom of the board.
+func rerankBand(tx *gorm.DB, p boardPeriod, band movedBand) error {
+	low := band.Low.Int64
+	above := "COUNT(*)"
+	if p.board.RankType == constants.RankDense {
+		low = math.MinInt64
+		above = "COUNT(DISTINCT total_score)"
+	}
+
+	rerank := fmt.Sprintf(`
+		WITH above AS (
+			SELECT %s as users
+			FROM leaderboard
+			WHERE %s AND total_score > @high
+		),
+		ranked AS (
+			SELECT
+				user_id,
+				%s + (SELECT users FROM above) as new_rank
+			FROM leaderboard
+			WHERE %s AND total_score BETWEEN @low AND @high
+		),
+		updated AS (
+			UPDATE leaderboard
+			SET rank = ranked.new_rank
+			FROM ranked
+			WHERE leaderboard.board = @board
+				AND leaderboard.time_window = @window
+				AND leaderboard.period_start = @period_start
+				AND leaderboard.user_id = ranked.user_id
+			RETURNING leaderboard.user_id, leaderboard.total_score, leaderboard.rank
+		)
+	`+recordSnapshotSQL, above, boardPeriodFilter, rankSQL(p.board), boardPeriodFilter, "updated", "TRUE")
+
+	if err := tx.Exec(
+		rerank,
+		p.args(sql.Named("low", low), sql.Named("high", band.High.Int64))...,
+	).Error; err != nil {
+		return err
+	}
+
+	if band.Joined == 0 || p.board.RankType == constants.RankDense {
+		return nil
+	}
+
+	shift := fmt.Sprintf(`
+		WITH updated AS (
+			UPDATE leaderboard
+			SET rank = rank + @joined
+			WHERE %s AND total_score < @low
+			RETURNING user_id, total_score, rank
+		)
+	`+recordSnapshotSQL, boardPeriodFilter, "updated", "TRUE")
+
+	return tx.Exec(
+		shift,
+		p.args(sql.Named("low", low), sql.Named("joined", band.Joined))...,
+	).Error
+}
+
+// setWatermark records that a board period has absorbed every session visible to snapshot
+func setWatermark(tx *gorm.DB, p boardPeriod, snapshot string) error {
+	return tx.Exec(`
+		INSERT INTO leaderboard_watermarks (board, time_window, period_start, last_snapshot, updated_at)
+		VALUES (@board, @window, @period_start, CAST(@snapshot AS pg_snapshot), @updated_at)
+		ON CONFLICT (board, time_window, period_start)
+		DO UPDATE SET
+			last_snapshot = EXCLUDED.last_snapshot,
+			updated_at = EXCLUDED.updated_at
+	`, p.args(sql.Named("snapshot", snapshot), sql.Named("updated_at", time.Now().UTC()))...).Error
+}
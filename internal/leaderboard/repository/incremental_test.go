@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/models"
+)
+
+func TestRecalculateIncrementally(t *testing.T) {
+	boards := []models.Board{
+		{Name: "sum", Aggregation: constants.AggregationSum, RankType: constants.RankCompetition},
+		{Name: "best", Aggregation: constants.AggregationBest, RankType: constants.RankDense},
+		{
+			Name:          "best-ascending",
+			Aggregation:   constants.AggregationBest,
+			RankType:      constants.RankCompetition,
+			SortDirection: constants.SortAscending,
+		},
+		{
+			Name:        "latest",
+			Aggregation: constants.AggregationLatest,
+			RankType:    constants.RankOrdinal,
+			TieBreaker:  constants.TieBreakReachedFirst,
+		},
+		{Name: "average", Aggregation: constants.AggregationAverage, Sessions: 2, RankType: constants.RankCompetition},
+	}
+	for i := range boards {
+		boards[i].GameMode = constants.GameModeSolo
+	}
+
+	db := testDB(t)
+	ctx := context.Background()
+	master := db.GetMasterDB(ctx)
+	repo := NewLeaderboardRepository(db)
+	seedSessions(t, master, rankedSessions)
+
+	incrementally := func(t *testing.T) {
+		t.Helper()
+		if err := repo.RecalculateIncrementally(
+			ctx, boards, nil, constants.WindowAllTime, allTime, time.Time{}, testDay, testFence,
+		); err != nil {
+			t.Fatalf("recalculate incrementally: %v", err)
+		}
+	}
+
+	// matchesFull checks every board's standings against a full recalculation of the same sessions
+	matchesFull := func(t *testing.T) {
+		t.Helper()
+		folded := make(map[string]map[int]models.Leaderboard, len(boards))
+		reconcile := make(map[string]bool, len(boards))
+		for _, board := range boards {
+			folded[board.Name] = standings(t, master, board.Name)
+			reconcile[board.Name] = true
+		}
+
+		if err := repo.RecalculateIncrementally(
+			ctx, boards, reconcile, constants.WindowAllTime, allTime, time.Time{}, testDay, testFence,
+		); err != nil {
+			t.Fatalf("recalculate in full: %v", err)
+		}
+
+		for _, board := range boards {
+			full := standings(t, master, board.Name)
+			if len(folded[board.Name]) != len(full) {
+				t.Errorf("%s: ranked %d users incrementally, %d in full", board.Name, len(folded[board.Name]), len(full))
+			}
+
+			for userID, want := range full {
+				got, ok := folded[board.Name][userID]
+				if !ok {
+					t.Errorf("%s: user %d is not ranked incrementally", board.Name, userID)
+					continue
+				}
+				if got.TotalScore != want.TotalScore || got.Rank != want.Rank || !got.ReachedAt.Equal(want.ReachedAt) {
+					t.Errorf("%s: user %d scored %d at rank %d reached %s, in full %d at rank %d reached %s",
+						board.Name, userID, got.TotalScore, got.Rank, got.ReachedAt, want.TotalScore, want.Rank, want.ReachedAt)
+				}
+			}
+		}
+	}
+
+	if err := repo.RecalculateAllRanksWithIsolation(
+		ctx, boards, constants.WindowAllTime, allTime, time.Time{}, testDay, testFence,
+	); err != nil {
+		t.Fatalf("recalculate: %v", err)
+	}
+
+	// A session still being written when the later ones commit, so its id is lower than theirs
+	// while it only becomes visible after them
+	pending := master.Begin()
+	if pending.Error != nil {
+		t.Fatalf("begin: %v", pending.Error)
+	}
+	defer pending.Rollback()
+	seedSessions(t, pending, []played{{userID: 2, score: 50, minutes: 10}})
+
+	seedSessions(t, master, []played{
+		// a user ranked for the first time
+		{userID: 6, score: 12, minutes: 11},
+		// a session that adds nothing
+		{userID: 3, score: 0, minutes: 12},
+		// a session that moves a user up
+		{userID: 5, score: 35, minutes: 13},
+	})
+
+	t.Run("committed sessions are folded in", func(t *testing.T) {
+		incrementally(t)
+		matchesFull(t)
+	})
+
+	if err := pending.Commit().Error; err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	t.Run("a session committed after a later one is folded in", func(t *testing.T) {
+		incrementally(t)
+		matchesFull(t)
+	})
+
+	t.Run("a pass without new sessions changes nothing", func(t *testing.T) {
+		incrementally(t)
+		matchesFull(t)
+	})
+}
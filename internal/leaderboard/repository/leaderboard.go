@@ -3,11 +3,16 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"log"
+	"time"
 
+	"gaming-leaderboard/constants"
 	"gaming-leaderboard/internal/models"
 	"gaming-leaderboard/internal/repository"
 	"gaming-leaderboard/pkg/db/postgres"
+
+	"gorm.io/gorm"
 )
 
 type LeaderboardRepository struct {
@@ -22,8 +27,169 @@ func NewLeaderboardRepository(db *postgres.DbCluster) *LeaderboardRepository {
 	}
 }
 
-// RecalculateAllRanksWithIsolation recalculates with proper concurrency handling
-func (r *LeaderboardRepository) RecalculateAllRanksWithIsolation(ctx context.Context) error {
+// aggregation describes how a board folds a user's sessions into a score: sessions are
+// numbered per user in order, only the first keep of them count (all when keep is zero),
+// score aggregates the kept sessions and reachedAt is when that score was reached
+type aggregation struct {
+	order     string
+	keep      int
+	score     string
+	reachedAt string
+}
+
+func aggregationSQL(board models.Board) aggregation {
+	switch board.Aggregation {
+	case constants.AggregationBest:
+		// The first session to hit the best score
+		return aggregation{
+			score:     "MAX(score)",
+			reachedAt: "(ARRAY_AGG(timestamp ORDER BY score DESC, timestamp ASC))[1]",
+		}
+	case constants.AggregationLatest:
+		return aggregation{order: "timestamp DESC, id DESC", keep: 1, score: "MAX(score)", reachedAt: "MAX(timestamp)"}
+	case constants.AggregationAverage:
+		return aggregation{order: "timestamp DESC, id DESC", keep: board.Sessions, score: "ROUND(AVG(score))", reachedAt: "MAX(timestamp)"}
+	case constants.AggregationBestN:
+		return aggregation{order: sessionScore(board) + " DESC, timestamp ASC", keep: board.Sessions, score: "SUM(score)", reachedAt: "MAX(timestamp)"}
+	default:
+		// Sessions scoring zero leave the total where it was
+		return aggregation{
+			score:     "SUM(score)",
+			reachedAt: "COALESCE(MAX(timestamp) FILTER (WHERE score <> 0), MIN(timestamp))",
+		}
+	}
+}
+
+// sessionScore is a session's score as a board ranks it, negated on boards where the lowest
+// score leads so that every ranking puts the highest score first
+func sessionScore(board models.Board) string {
+	if board.SortDirection == constants.SortAscending {
+		return "-score"
+	}
+
+	return "score"
+}
+
+// TieBreakOrder is the ORDER BY clause that settles equal scores on a board
+func TieBreakOrder(board models.Board) string {
+	if board.TieBreaker == constants.TieBreakUserID {
+		return "user_id ASC"
+	}
+
+	return "reached_at ASC, user_id ASC"
+}
+
+// tieBreakAhead is the condition for the row of table ahead to sort before the row of table
+// behind on the same score, in TieBreakOrder
+func tieBreakAhead(board models.Board, ahead, behind string) string {
+	if board.TieBreaker == constants.TieBreakUserID {
+		return fmt.Sprintf("%s.user_id < %s.user_id", ahead, behind)
+	}
+
+	return fmt.Sprintf(
+		"(COALESCE(%[1]s.reached_at, 'infinity'), %[1]s.user_id) < (COALESCE(%[2]s.reached_at, 'infinity'), %[2]s.user_id)",
+		ahead, behind,
+	)
+}
+
+// Seek resumes a board listing after the entry with the given sort key, so deep pages are
+// read from the rank index instead of skipping every earlier row. Missing reached-at times
+// compare as infinity, matching where ORDER BY puts NULLs.
+func Seek(board models.Board, rank int, reachedAt *time.Time, userID int) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if board.TieBreaker == constants.TieBreakUserID {
+			return db.Where("(rank, user_id) > (?, ?)", rank, userID)
+		}
+
+		var reached interface{}
+		if reachedAt != nil {
+			reached = reachedAt.UTC()
+		}
+
+		return db.Where(
+			"(rank, COALESCE(reached_at, 'infinity'), user_id) > (?, COALESCE(CAST(? AS TIMESTAMP), 'infinity'), ?)",
+			rank, reached, userID,
+		)
+	}
+}
+
+// AmongFriends narrows a board listing to a user and their friend set, joined in the same query
+// so friend sets in the thousands are never sent over the wire
+func AmongFriends(userID int) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(
+			"(user_id = ? OR user_id IN (SELECT friend_id FROM friendships WHERE user_id = ?))",
+			userID, userID,
+		)
+	}
+}
+
+// rankSQL is the window function numbering a board's users by score
+func rankSQL(board models.Board) string {
+	switch board.RankType {
+	case constants.RankDense:
+		return "DENSE_RANK() OVER (ORDER BY total_score DESC)"
+	case constants.RankOrdinal:
+		return fmt.Sprintf("ROW_NUMBER() OVER (ORDER BY total_score DESC, %s)", TieBreakOrder(board))
+	default:
+		return "RANK() OVER (ORDER BY total_score DESC)"
+	}
+}
+
+// RecalculateAllRanksWithIsolation recalculates with proper concurrency handling.
+// Only sessions played in [periodStart, periodEnd) are ranked, a zero periodEnd leaves the
+// period open ended. Every board is ranked independently using its own aggregation, within
+// one transaction so all boards reflect the same snapshot of sessions. The result is also
+// recorded as the rank history snapshot of the day recordedOn falls on. Nothing is written
+// once a newer worker lock term than fence has, ErrFenced is returned instead.
+func (r *LeaderboardRepository) RecalculateAllRanksWithIsolation(
+	ctx context.Context,
+	boards []models.Board,
+	window string,
+	periodStart time.Time,
+	periodEnd time.Time,
+	recordedOn time.Time,
+	fence Fence,
+) error {
+	inFull := func(models.Board) bool { return false }
+	return r.recalculate(
+		ctx, "RecalculateAllRanksWithIsolation", boards, window, periodStart, periodEnd, recordedOn, fence, inFull,
+	)
+}
+
+// RecalculateIncrementally folds only the sessions recorded since each board's watermark into
+// its standings and re-ranks the band of scores they moved through. Boards named in reconcile,
+// boards without a watermark, and boards whose aggregation needs every session are
+// recalculated in full instead, within the same transaction.
+func (r *LeaderboardRepository) RecalculateIncrementally(
+	ctx context.Context,
+	boards []models.Board,
+	reconcile map[string]bool,
+	window string,
+	periodStart time.Time,
+	periodEnd time.Time,
+	recordedOn time.Time,
+	fence Fence,
+) error {
+	incremental := func(board models.Board) bool { return !reconcile[board.Name] }
+	return r.recalculate(
+		ctx, "RecalculateIncrementally", boards, window, periodStart, periodEnd, recordedOn, fence, incremental,
+	)
+}
+
+// recalculate ranks every board period within one transaction, folding new sessions into those
+// incremental selects. caller names the public method in log lines.
+func (r *LeaderboardRepository) recalculate(
+	ctx context.Context,
+	caller string,
+	boards []models.Board,
+	window string,
+	periodStart time.Time,
+	periodEnd time.Time,
+	recordedOn time.Time,
+	fence Fence,
+	incremental func(board models.Board) bool,
+) error {
 	tx := r.db.GetMasterDB(ctx).Begin(&sql.TxOptions{
 		Isolation: sql.LevelRepeatableRead,
 	})
@@ -38,40 +204,632 @@ func (r *LeaderboardRepository) RecalculateAllRanksWithIsolation(ctx context.Con
 		}
 	}()
 
-	query := `
-		WITH user_scores AS (
+	if err := checkFence(tx, fence); err != nil {
+		tx.Rollback()
+		log.Printf("[ERROR] %s: fence check failed | token=%d | err=%v", caller, fence.Token, err)
+		return err
+	}
+
+	// Every board is ranked from the sessions this transaction's snapshot sees, and the snapshot
+	// becomes the watermark the next incremental pass starts from
+	var snapshot string
+	if err := tx.Raw("SELECT CAST(pg_current_snapshot() AS TEXT)").Scan(&snapshot).Error; err != nil {
+		tx.Rollback()
+		log.Printf("[ERROR] %s: snapshot read failed | err=%v", caller, err)
+		return err
+	}
+
+	folded := 0
+	for _, board := range boards {
+		p := boardPeriod{
+			board:       board,
+			window:      window,
+			periodStart: periodStart,
+			periodEnd:   periodEnd,
+			recordedOn:  recordedOn,
+		}
+
+		applied := false
+		if incremental(board) {
+			var err error
+			if applied, err = applyNewSessions(tx, p, snapshot); err != nil {
+				tx.Rollback()
+				log.Printf("[ERROR] %s: incremental update failed | board=%s | window=%s | err=%v", caller, board.Name, window, err)
+				return err
+			}
+		}
+
+		if applied {
+			folded++
+		} else if err := recalculateBoard(tx, p); err != nil {
+			tx.Rollback()
+			log.Printf("[ERROR] %s: board=%s | window=%s | err=%v", caller, board.Name, window, err)
+			return err
+		}
+
+		if err := setWatermark(tx, p, snapshot); err != nil {
+			tx.Rollback()
+			log.Printf("[ERROR] %s: watermark update failed | board=%s | err=%v", caller, board.Name, err)
+			return err
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		log.Printf("[ERROR] %s: commit failed | err=%v", caller, err)
+		return err
+	}
+
+	log.Printf(
+		"[INFO] %s: completed successfully | window=%s | period_start=%v | boards=%d | incremental=%d",
+		caller, window, periodStart, len(boards), folded,
+	)
+	return nil
+}
+
+// boardPeriod is one board ranked over one window period
+type boardPeriod struct {
+	board       models.Board
+	window      string
+	periodStart time.Time
+	periodEnd   time.Time
+	recordedOn  time.Time
+}
+
+// args are the named parameters shared by every statement over the board period
+func (p boardPeriod) args(extra ...sql.NamedArg) []interface{} {
+	args := []interface{}{
+		sql.Named("board", p.board.Name),
+		sql.Named("game_mode", p.board.GameMode),
+		sql.Named("window", p.window),
+		sql.Named("period_start", p.periodStart.UTC()),
+		sql.Named("period_end", p.periodEnd.UTC()),
+		sql.Named("recorded_on", p.recordedOn.Format(time.DateOnly)),
+	}
+	for _, arg := range extra {
+		args = append(args, arg)
+	}
+
+	return args
+}
+
+// sessionFilter selects the sessions counted towards the board period, leaving out those of
+// banned and shadow-banned users
+func (p boardPeriod) sessionFilter() string {
+	return p.playedFilter() + " AND user_id NOT IN (SELECT user_id FROM leaderboard_bans)"
+}
+
+// playedFilter selects the board period's valid sessions of every user, banned or not
+func (p boardPeriod) playedFilter() string {
+	// Quarantined sessions only count once a review accepts them
+	filter := fmt.Sprintf(
+		"status = '%s' AND voided_at IS NULL AND timestamp >= @period_start",
+		constants.SessionStatusAccepted,
+	)
+	if !p.periodEnd.IsZero() {
+		filter += " AND timestamp < @period_end"
+	}
+	if p.board.GameMode != constants.GameModeOverall {
+		filter += " AND game_mode = @game_mode"
+	}
+
+	return filter
+}
+
+// userScoresSQL is the board_sessions and user_scores CTEs aggregating the sessions matching
+// filter into one score per user
+func userScoresSQL(board models.Board, filter string) string {
+	agg := aggregationSQL(board)
+
+	position, positionFilter := "", ""
+	if agg.keep > 0 {
+		position = fmt.Sprintf(", ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY %s) as position", agg.order)
+		positionFilter = "WHERE position <= @keep"
+	}
+
+	return fmt.Sprintf(`
+		board_sessions AS (
 			SELECT 
 				user_id,
-				SUM(score) as total_score
+				%s as score,
+				timestamp%s
 			FROM game_sessions
-			GROUP BY user_id
+			WHERE %s
 		),
+		user_scores AS (
+			SELECT 
+				user_id,
+				%s as total_score,
+				%s as reached_at
+			FROM board_sessions
+			%s
+			GROUP BY user_id
+		)`, sessionScore(board), position, filter, agg.score, agg.reachedAt, positionFilter)
+}
+
+// recalculateBoard ranks a board period from every one of its sessions and records the result.
+// Users left without a counted session, after a void or a ban, are dropped from the board and
+// from the day's rank history.
+func recalculateBoard(tx *gorm.DB, p boardPeriod) error {
+	args := p.args(sql.Named("keep", aggregationSQL(p.board).keep))
+
+	remove := fmt.Sprintf(`
+		WITH removed AS (
+			DELETE FROM leaderboard
+			WHERE %s AND NOT EXISTS (
+				SELECT 1 FROM game_sessions
+				WHERE %s AND game_sessions.user_id = leaderboard.user_id
+			)
+			RETURNING user_id
+		)
+		DELETE FROM rank_history
+		WHERE %s AND recorded_on = CAST(@recorded_on AS DATE) AND user_id IN (SELECT user_id FROM removed)
+	`, boardPeriodFilter, p.sessionFilter(), boardPeriodFilter)
+
+	if err := tx.Exec(remove, args...).Error; err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(`
+		WITH %s,
 		ranked_users AS (
 			SELECT 
 				user_id,
 				total_score,
-				RANK() OVER (ORDER BY total_score DESC) as new_rank
+				reached_at,
+				%s as new_rank
 			FROM user_scores
 		)
-		INSERT INTO leaderboard (user_id, total_score, rank)
-		SELECT user_id, total_score, new_rank FROM ranked_users
-		ON CONFLICT (user_id)
+		INSERT INTO leaderboard (user_id, board, game_mode, time_window, period_start, total_score, rank, reached_at)
+		SELECT user_id, @board, @game_mode, @window, @period_start, total_score, new_rank, reached_at FROM ranked_users
+		ON CONFLICT (board, time_window, period_start, user_id)
 		DO UPDATE SET
 			total_score = EXCLUDED.total_score,
-			rank = EXCLUDED.rank
+			rank = EXCLUDED.rank,
+			reached_at = EXCLUDED.reached_at
+	`, userScoresSQL(p.board, p.sessionFilter()), rankSQL(p.board))
+
+	if err := tx.Exec(query, args...).Error; err != nil {
+		return err
+	}
+
+	return tx.Exec(fmt.Sprintf(recordSnapshotSQL, "leaderboard", boardPeriodFilter), p.args()...).Error
+}
+
+// ShadowStanding returns the standing a shadow-banned user would hold on one board period,
+// placed after every ranked user scoring higher, or on ordinal boards also after those the
+// tie-breaker puts first. It is what the user is shown of their own
+// rank while everyone else's boards leave them out. found is false when none of the user's
+// sessions count towards the board period.
+func (r *LeaderboardRepository) ShadowStanding(
+	ctx context.Context,
+	board models.Board,
+	window string,
+	periodStart time.Time,
+	periodEnd time.Time,
+	userID int,
+) (standing models.Leaderboard, found bool, err error) {
+	p := boardPeriod{board: board, window: window, periodStart: periodStart, periodEnd: periodEnd}
+
+	above := "COUNT(*)"
+	ahead := "leaderboard.total_score > user_scores.total_score"
+	switch board.RankType {
+	case constants.RankDense:
+		above = "COUNT(DISTINCT leaderboard.total_score)"
+	case constants.RankOrdinal:
+		// Ordinal ranks are never shared, users on the same score are placed by the tie-breaker
+		ahead = fmt.Sprintf(
+			"(%s OR (leaderboard.total_score = user_scores.total_score AND %s))",
+			ahead, tieBreakAhead(board, "leaderboard", "user_scores"),
+		)
+	}
+
+	query := fmt.Sprintf(`
+		WITH %s
+		SELECT
+			user_id,
+			@board as board,
+			@game_mode as game_mode,
+			@window as time_window,
+			@period_start as period_start,
+			total_score,
+			reached_at,
+			(
+				SELECT %s FROM leaderboard
+				WHERE %s AND %s
+			) + 1 as rank
+		FROM user_scores
+	`, userScoresSQL(board, p.playedFilter()+" AND user_id = @user_id"), above, boardPeriodFilter, ahead)
+
+	var standings models.LeaderboardSlice
+	if err := r.db.GetSlaveDB(ctx).Raw(
+		query,
+		p.args(sql.Named("keep", aggregationSQL(board).keep), sql.Named("user_id", userID))...,
+	).Scan(&standings).Error; err != nil {
+		log.Printf("[ERROR] ShadowStanding: board=%s | window=%s | user_id=%d | err=%v", board.Name, window, userID, err)
+		return models.Leaderboard{}, false, err
+	}
+
+	if len(standings) == 0 {
+		return models.Leaderboard{}, false, nil
+	}
+
+	return *standings[0], true, nil
+}
+
+// Standing returns the score a user holds on one board period from the sessions that count
+// now, read from the master so a void or ban just committed is reflected. Its rank is left
+// unset. found is false when none of the user's sessions count towards the board period,
+// banned users included.
+func (r *LeaderboardRepository) Standing(
+	ctx context.Context,
+	board models.Board,
+	window string,
+	periodStart time.Time,
+	periodEnd time.Time,
+	userID int,
+) (standing models.Leaderboard, found bool, err error) {
+	p := boardPeriod{board: board, window: window, periodStart: periodStart, periodEnd: periodEnd}
+
+	query := fmt.Sprintf(`
+		WITH %s
+		SELECT
+			user_id,
+			@board as board,
+			@game_mode as game_mode,
+			@window as time_window,
+			@period_start as period_start,
+			total_score,
+			reached_at
+		FROM user_scores
+	`, userScoresSQL(board, p.sessionFilter()+" AND user_id = @user_id"))
+
+	var standings models.LeaderboardSlice
+	if err := r.db.GetMasterDB(ctx).Raw(
+		query,
+		p.args(sql.Named("keep", aggregationSQL(board).keep), sql.Named("user_id", userID))...,
+	).Scan(&standings).Error; err != nil {
+		log.Printf("[ERROR] Standing: board=%s | window=%s | user_id=%d | err=%v", board.Name, window, userID, err)
+		return models.Leaderboard{}, false, err
+	}
+
+	if len(standings) == 0 {
+		return models.Leaderboard{}, false, nil
+	}
+
+	return *standings[0], true, nil
+}
+
+// CountRanked returns the number of users present in the durable leaderboard for one board period
+func (r *LeaderboardRepository) CountRanked(
+	ctx context.Context,
+	board string,
+	window string,
+	periodStart time.Time,
+) (int64, error) {
+	var count int64
+	if err := r.db.GetSlaveDB(ctx).
+		Model(&models.Leaderboard{}).
+		Where(map[string]interface{}{
+			constants.Board:       board,
+			constants.TimeWindow:  window,
+			constants.PeriodStart: periodStart.UTC(),
+		}).
+		Count(&count).Error; err != nil {
+		log.Printf("[ERROR] CountRanked: board=%s | window=%s | err=%v", board, window, err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// SampleRanked returns up to limit of the top standings of one board period along with up to
+// limit of those that changed last, the ones a real-time ranking is likeliest to have lost
+func (r *LeaderboardRepository) SampleRanked(
+	ctx context.Context,
+	board string,
+	window string,
+	periodStart time.Time,
+	limit int,
+) (models.LeaderboardSlice, error) {
+	query := `
+		(
+			SELECT user_id, total_score, reached_at FROM leaderboard
+			WHERE board = @board AND time_window = @window AND period_start = @period_start
+			ORDER BY rank ASC
+			LIMIT @limit
+		)
+		UNION
+		(
+			SELECT user_id, total_score, reached_at FROM leaderboard
+			WHERE board = @board AND time_window = @window AND period_start = @period_start
+			ORDER BY reached_at DESC NULLS LAST
+			LIMIT @limit
+		)
 	`
 
-	if err := tx.Exec(query).Error; err != nil {
-		tx.Rollback()
-		log.Printf("[ERROR] RecalculateAllRanksWithIsolation: err=%v", err)
+	var sample models.LeaderboardSlice
+	if err := r.db.GetSlaveDB(ctx).Raw(
+		query,
+		sql.Named("board", board),
+		sql.Named("window", window),
+		sql.Named("period_start", periodStart.UTC()),
+		sql.Named("limit", limit),
+	).Scan(&sample).Error; err != nil {
+		log.Printf("[ERROR] SampleRanked: board=%s | window=%s | err=%v", board, window, err)
+		return nil, err
+	}
+
+	return sample, nil
+}
+
+// GetNeighbours returns the standings up to radius places either side of a user on one board
+// period, in board order. The result is empty when the user is not ranked there.
+func (r *LeaderboardRepository) GetNeighbours(
+	ctx context.Context,
+	board models.Board,
+	window string,
+	periodStart time.Time,
+	userID int,
+	radius int,
+) (models.LeaderboardSlice, error) {
+	query := fmt.Sprintf(`
+		WITH ordered AS (
+			SELECT 
+				*,
+				ROW_NUMBER() OVER (ORDER BY rank ASC, %s) as position
+			FROM leaderboard
+			WHERE board = @board AND time_window = @window AND period_start = @period_start
+		),
+		target AS (
+			SELECT position FROM ordered WHERE user_id = @user_id
+		)
+		SELECT ordered.id, user_id, board, game_mode, time_window, period_start, total_score, rank, reached_at
+		FROM ordered, target
+		WHERE ordered.position BETWEEN target.position - @radius AND target.position + @radius
+		ORDER BY ordered.position
+	`, TieBreakOrder(board))
+
+	var neighbours models.LeaderboardSlice
+	if err := r.db.GetSlaveDB(ctx).Raw(
+		query,
+		sql.Named("board", board.Name),
+		sql.Named("window", window),
+		sql.Named("period_start", periodStart.UTC()),
+		sql.Named("user_id", userID),
+		sql.Named("radius", radius),
+	).Scan(&neighbours).Error; err != nil {
+		log.Printf("[ERROR] GetNeighbours: board=%s | window=%s | user_id=%d | err=%v", board.Name, window, userID, err)
+		return nil, err
+	}
+
+	return neighbours, nil
+}
+
+// regionFilter narrows the board period's counted sessions to those recorded in the region the
+// SQL expression region evaluates to
+func (p boardPeriod) regionFilter(region string) string {
+	return p.sessionFilter() + " AND region = " + region
+}
+
+// RegionTop returns the best standings on one board period among the users who played there
+// from one region, in board order. Each user's score is aggregated from only the sessions
+// recorded in the region, read from the sessions themselves so the list neither waits for a
+// recalculation nor follows a user to the country they later moved to. The rank is the user's
+// global rank as of the last recalculation, zero until one ranks them.
+func (r *LeaderboardRepository) RegionTop(
+	ctx context.Context,
+	board models.Board,
+	window string,
+	periodStart time.Time,
+	periodEnd time.Time,
+	region string,
+	limit int,
+) (models.LeaderboardSlice, error) {
+	p := boardPeriod{board: board, window: window, periodStart: periodStart, periodEnd: periodEnd}
+
+	query := fmt.Sprintf(`
+		WITH %s
+		SELECT
+			user_scores.user_id,
+			@board as board,
+			@game_mode as game_mode,
+			@window as time_window,
+			@period_start as period_start,
+			user_scores.total_score,
+			COALESCE(leaderboard.rank, 0) as rank,
+			user_scores.reached_at
+		FROM user_scores
+		LEFT JOIN leaderboard ON leaderboard.user_id = user_scores.user_id
+			AND leaderboard.board = @board AND leaderboard.time_window = @window
+			AND leaderboard.period_start = @period_start
+		ORDER BY total_score DESC, %s
+		LIMIT @limit
+	`, userScoresSQL(board, p.regionFilter("@region")), TieBreakOrder(board))
+
+	var leaders models.LeaderboardSlice
+	if err := r.db.GetSlaveDB(ctx).Raw(
+		query,
+		p.args(
+			sql.Named("keep", aggregationSQL(board).keep),
+			sql.Named("region", region),
+			sql.Named("limit", limit),
+		)...,
+	).Scan(&leaders).Error; err != nil {
+		log.Printf("[ERROR] RegionTop: board=%s | window=%s | region=%s | err=%v", board.Name, window, region, err)
+		return nil, err
+	}
+
+	return leaders, nil
+}
+
+// regionalStanding is the region a user is ranked in on a board period and their rank there
+type regionalStanding struct {
+	Region string
+	Rank   int
+}
+
+// RegionalRank returns the region a user is ranked in on one board period, the region of their
+// latest counted session there, and the rank their sessions from that region hold among
+// everyone else's from it, numbered as the board's rank type would. found is false when none
+// of the user's counted sessions recorded a region.
+func (r *LeaderboardRepository) RegionalRank(
+	ctx context.Context,
+	board models.Board,
+	window string,
+	periodStart time.Time,
+	periodEnd time.Time,
+	userID int,
+) (region string, rank int, found bool, err error) {
+	p := boardPeriod{board: board, window: window, periodStart: periodStart, periodEnd: periodEnd}
+
+	query := fmt.Sprintf(`
+		WITH latest AS (
+			SELECT region FROM game_sessions
+			WHERE %s AND user_id = @user_id AND region IS NOT NULL
+			ORDER BY timestamp DESC, id DESC
+			LIMIT 1
+		),
+		%s,
+		ranked_users AS (
+			SELECT user_id, %s as rank FROM user_scores
+		)
+		SELECT latest.region, ranked_users.rank
+		FROM ranked_users, latest
+		WHERE ranked_users.user_id = @user_id
+	`, p.sessionFilter(), userScoresSQL(board, p.regionFilter("(SELECT region FROM latest)")), rankSQL(board))
+
+	var standings []regionalStanding
+	if err := r.db.GetSlaveDB(ctx).Raw(
+		query,
+		p.args(sql.Named("keep", aggregationSQL(board).keep), sql.Named("user_id", userID))...,
+	).Scan(&standings).Error; err != nil {
+		log.Printf("[ERROR] RegionalRank: board=%s | window=%s | user_id=%d | err=%v", board.Name, window, userID, err)
+		return "", 0, false, err
+	}
+
+	if len(standings) == 0 {
+		return "", 0, false, nil
+	}
+
+	return standings[0].Region, standings[0].Rank, true, nil
+}
+
+// CountAbove returns the number of users scoring higher than score on one board period
+func (r *LeaderboardRepository) CountAbove(
+	ctx context.Context,
+	board string,
+	window string,
+	periodStart time.Time,
+	score int,
+) (int64, error) {
+	var count int64
+	if err := r.db.GetSlaveDB(ctx).
+		Model(&models.Leaderboard{}).
+		Where(map[string]interface{}{
+			constants.Board:       board,
+			constants.TimeWindow:  window,
+			constants.PeriodStart: periodStart.UTC(),
+		}).
+		Where("total_score > ?", score).
+		Count(&count).Error; err != nil {
+		log.Printf("[ERROR] CountAbove: board=%s | window=%s | err=%v", board, window, err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// ScoreAtPosition returns the score of the user in the given 1-indexed position when one board
+// period is ordered by score, found is false when fewer users are ranked
+func (r *LeaderboardRepository) ScoreAtPosition(
+	ctx context.Context,
+	board string,
+	window string,
+	periodStart time.Time,
+	position int64,
+) (score int, found bool, err error) {
+	var scores []int
+	if err := r.db.GetSlaveDB(ctx).
+		Model(&models.Leaderboard{}).
+		Where(map[string]interface{}{
+			constants.Board:       board,
+			constants.TimeWindow:  window,
+			constants.PeriodStart: periodStart.UTC(),
+		}).
+		Order("total_score DESC").
+		Offset(int(position-1)).
+		Limit(1).
+		Pluck("total_score", &scores).Error; err != nil {
+		log.Printf("[ERROR] ScoreAtPosition: board=%s | window=%s | err=%v", board, window, err)
+		return 0, false, err
+	}
+
+	if len(scores) == 0 {
+		return 0, false, nil
+	}
+
+	return scores[0], true, nil
+}
+
+// PurgeRemovedBoards deletes the standings and watermarks of boards that are no longer defined.
+// Nothing is deleted once a newer worker lock term than fence has written, ErrFenced is
+// returned instead.
+func (r *LeaderboardRepository) PurgeRemovedBoards(ctx context.Context, boards []string, fence Fence) error {
+	var deleted int64
+	err := r.db.GetMasterDB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkFence(tx, fence); err != nil {
+			return err
+		}
+
+		if err := tx.Exec("DELETE FROM leaderboard_watermarks WHERE "+constants.Board+" NOT IN ?", boards).Error; err != nil {
+			return err
+		}
+
+		result := tx.Where(constants.Board+" NOT IN ?", boards).Delete(&models.Leaderboard{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		log.Printf("[ERROR] PurgeRemovedBoards: token=%d | err=%v", fence.Token, err)
 		return err
 	}
 
-	if err := tx.Commit().Error; err != nil {
-		log.Printf("[ERROR] RecalculateAllRanksWithIsolation: commit failed | err=%v", err)
+	if deleted > 0 {
+		log.Printf("[INFO] PurgeRemovedBoards: deleted=%d", deleted)
+	}
+	return nil
+}
+
+// PurgeExpiredPeriods deletes the standings and watermarks of window periods that started
+// before the cutoff. Nothing is deleted once a newer worker lock term than fence has written,
+// ErrFenced is returned instead.
+func (r *LeaderboardRepository) PurgeExpiredPeriods(ctx context.Context, window string, before time.Time, fence Fence) error {
+	var deleted int64
+	err := r.db.GetMasterDB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkFence(tx, fence); err != nil {
+			return err
+		}
+
+		if err := tx.Exec(
+			"DELETE FROM leaderboard_watermarks WHERE "+constants.TimeWindow+" = ? AND "+constants.PeriodStart+" < ?",
+			window,
+			before.UTC(),
+		).Error; err != nil {
+			return err
+		}
+
+		result := tx.Where(constants.TimeWindow+" = ? AND "+constants.PeriodStart+" < ?", window, before.UTC()).
+			Delete(&models.Leaderboard{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		log.Printf("[ERROR] PurgeExpiredPeriods: window=%s | token=%d | err=%v", window, fence.Token, err)
 		return err
 	}
 
-	log.Printf("[INFO] RecalculateAllRanksWithIsolation: completed successfully")
+	if deleted > 0 {
+		log.Printf("[INFO] PurgeExpiredPeriods: window=%s | deleted=%d", window, deleted)
+	}
 	return nil
 }
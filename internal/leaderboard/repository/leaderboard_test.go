@@ -0,0 +1,279 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/models"
+)
+
+// rankedSessions are played so that every aggregation orders the users differently. Sessions
+// that are voided, quarantined or of another mode never count.
+var rankedSessions = []played{
+	{userID: 1, score: 10, minutes: 0},
+	{userID: 1, score: 30, minutes: 5},
+	{userID: 1, score: 15, minutes: 8},
+	{userID: 2, score: 25, minutes: 1},
+	{userID: 2, score: 1000, minutes: 9, voided: true},
+	{userID: 3, score: 20, minutes: 2},
+	{userID: 3, score: 20, minutes: 6},
+	{userID: 3, score: 1000, minutes: 9, status: constants.SessionStatusQuarantined},
+	{userID: 4, score: 40, minutes: 3},
+	{userID: 4, score: 1000, minutes: 9, gameMode: constants.GameModeTeam},
+	{userID: 5, score: 5, minutes: 4},
+	{userID: 5, score: 30, minutes: 7},
+}
+
+func TestRecalculateAllRanks(t *testing.T) {
+	tests := []struct {
+		name  string
+		board models.Board
+		want  map[int]standing
+	}{
+		{
+			name:  "sum with competition ranks",
+			board: models.Board{Aggregation: constants.AggregationSum, RankType: constants.RankCompetition},
+			want:  map[int]standing{1: {55, 1}, 4: {40, 2}, 3: {40, 2}, 5: {35, 4}, 2: {25, 5}},
+		},
+		{
+			name:  "sum with dense ranks",
+			board: models.Board{Aggregation: constants.AggregationSum, RankType: constants.RankDense},
+			want:  map[int]standing{1: {55, 1}, 4: {40, 2}, 3: {40, 2}, 5: {35, 3}, 2: {25, 4}},
+		},
+		{
+			name: "sum with ordinal ranks broken by who reached the score first",
+			board: models.Board{
+				Aggregation: constants.AggregationSum,
+				RankType:    constants.RankOrdinal,
+				TieBreaker:  constants.TieBreakReachedFirst,
+			},
+			want: map[int]standing{1: {55, 1}, 4: {40, 2}, 3: {40, 3}, 5: {35, 4}, 2: {25, 5}},
+		},
+		{
+			name: "sum with ordinal ranks broken by user id",
+			board: models.Board{
+				Aggregation: constants.AggregationSum,
+				RankType:    constants.RankOrdinal,
+				TieBreaker:  constants.TieBreakUserID,
+			},
+			want: map[int]standing{1: {55, 1}, 3: {40, 2}, 4: {40, 3}, 5: {35, 4}, 2: {25, 5}},
+		},
+		{
+			name:  "best",
+			board: models.Board{Aggregation: constants.AggregationBest, RankType: constants.RankCompetition},
+			want:  map[int]standing{4: {40, 1}, 1: {30, 2}, 5: {30, 2}, 2: {25, 4}, 3: {20, 5}},
+		},
+		{
+			name:  "latest",
+			board: models.Board{Aggregation: constants.AggregationLatest, RankType: constants.RankCompetition},
+			want:  map[int]standing{4: {40, 1}, 5: {30, 2}, 2: {25, 3}, 3: {20, 4}, 1: {15, 5}},
+		},
+		{
+			name:  "average of the last two sessions",
+			board: models.Board{Aggregation: constants.AggregationAverage, Sessions: 2, RankType: constants.RankCompetition},
+			want:  map[int]standing{4: {40, 1}, 2: {25, 2}, 1: {23, 3}, 3: {20, 4}, 5: {18, 5}},
+		},
+		{
+			name:  "sum of the best two sessions",
+			board: models.Board{Aggregation: constants.AggregationBestN, Sessions: 2, RankType: constants.RankCompetition},
+			want:  map[int]standing{1: {45, 1}, 3: {40, 2}, 4: {40, 2}, 5: {35, 4}, 2: {25, 5}},
+		},
+		{
+			name: "best of a board the lowest score leads",
+			board: models.Board{
+				Aggregation:   constants.AggregationBest,
+				RankType:      constants.RankCompetition,
+				SortDirection: constants.SortAscending,
+			},
+			want: map[int]standing{5: {-5, 1}, 1: {-10, 2}, 3: {-20, 3}, 2: {-25, 4}, 4: {-40, 5}},
+		},
+	}
+
+	db := testDB(t)
+	ctx := context.Background()
+	seedSessions(t, db.GetMasterDB(ctx), rankedSessions)
+
+	boards := make([]models.Board, 0, len(tests))
+	for i := range tests {
+		tests[i].board.Name = fmt.Sprintf("board-%d", i)
+		tests[i].board.GameMode = constants.GameModeSolo
+		boards = append(boards, tests[i].board)
+	}
+
+	repo := NewLeaderboardRepository(db)
+	if err := repo.RecalculateAllRanksWithIsolation(
+		ctx, boards, constants.WindowAllTime, allTime, time.Time{}, testDay, testFence,
+	); err != nil {
+		t.Fatalf("recalculate: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := standings(t, db.GetMasterDB(ctx), tt.board.Name)
+			if len(got) != len(tt.want) {
+				t.Errorf("ranked %d users, want %d", len(got), len(tt.want))
+			}
+
+			for userID, want := range tt.want {
+				row, ok := got[userID]
+				if !ok {
+					t.Errorf("user %d is not ranked", userID)
+					continue
+				}
+				if row.TotalScore != want.score || row.Rank != want.rank {
+					t.Errorf("user %d scored %d at rank %d, want %d at rank %d",
+						userID, row.TotalScore, row.Rank, want.score, want.rank)
+				}
+			}
+		})
+	}
+}
+
+func TestSeek(t *testing.T) {
+	tests := []struct {
+		name       string
+		tieBreaker string
+		want       []int
+	}{
+		{
+			name:       "reached-at tie-breaker pages ties by who reached the score first",
+			tieBreaker: constants.TieBreakReachedFirst,
+			want:       []int{1, 4, 3, 5, 2},
+		},
+		{
+			name:       "user id tie-breaker pages ties by user",
+			tieBreaker: constants.TieBreakUserID,
+			want:       []int{1, 3, 4, 5, 2},
+		},
+	}
+
+	db := testDB(t)
+	ctx := context.Background()
+	seedSessions(t, db.GetMasterDB(ctx), rankedSessions)
+	repo := NewLeaderboardRepository(db)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			board := models.Board{
+				Name:        "seek-" + tt.tieBreaker,
+				GameMode:    constants.GameModeSolo,
+				Aggregation: constants.AggregationSum,
+				RankType:    constants.RankCompetition,
+				TieBreaker:  tt.tieBreaker,
+			}
+			if err := repo.RecalculateAllRanksWithIsolation(
+				ctx, []models.Board{board}, constants.WindowAllTime, allTime, time.Time{}, testDay, testFence,
+			); err != nil {
+				t.Fatalf("recalculate: %v", err)
+			}
+
+			// Pages of two, each resumed after the last entry of the previous one
+			got := make([]int, 0, len(tt.want))
+			query := db.GetMasterDB(ctx).
+				Where("board = ? AND time_window = ? AND period_start = ?", board.Name, constants.WindowAllTime, allTime).
+				Order("rank ASC, " + TieBreakOrder(board)).
+				Limit(2)
+
+			var page []models.Leaderboard
+			if err := query.Find(&page).Error; err != nil {
+				t.Fatalf("read first page: %v", err)
+			}
+			for len(page) > 0 {
+				for _, row := range page {
+					got = append(got, row.UserID)
+				}
+
+				last := page[len(page)-1]
+				page = nil
+				if err := query.Scopes(Seek(board, last.Rank, &last.ReachedAt, last.UserID)).Find(&page).Error; err != nil {
+					t.Fatalf("read page after user %d: %v", last.UserID, err)
+				}
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("paged users %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShadowStanding(t *testing.T) {
+	tests := []struct {
+		name       string
+		rankType   string
+		tieBreaker string
+		want       int
+	}{
+		{
+			name:     "competition rank follows every higher score",
+			rankType: constants.RankCompetition,
+			want:     2,
+		},
+		{
+			name:     "dense rank follows every higher distinct score",
+			rankType: constants.RankDense,
+			want:     2,
+		},
+		{
+			name:       "ordinal rank follows the ties reached first",
+			rankType:   constants.RankOrdinal,
+			tieBreaker: constants.TieBreakReachedFirst,
+			want:       3,
+		},
+		{
+			name:       "ordinal rank follows the ties with lower user ids",
+			rankType:   constants.RankOrdinal,
+			tieBreaker: constants.TieBreakUserID,
+			want:       4,
+		},
+	}
+
+	db := testDB(t)
+	ctx := context.Background()
+	master := db.GetMasterDB(ctx)
+
+	// The shadow-banned user ties users 3 and 4 on 40, reaching it after 4 and before 3
+	seedSessions(t, master, append(rankedSessions, played{userID: 6, score: 40, minutes: 4}))
+	ban := models.LeaderboardBan{UserID: 6, Kind: constants.BanKindShadow, Reason: "test", Actor: "test"}
+	if err := master.Create(&ban).Error; err != nil {
+		t.Fatalf("ban user 6: %v", err)
+	}
+
+	repo := NewLeaderboardRepository(db)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			board := models.Board{
+				Name:        "shadow-" + tt.rankType + "-" + tt.tieBreaker,
+				GameMode:    constants.GameModeSolo,
+				Aggregation: constants.AggregationSum,
+				RankType:    tt.rankType,
+				TieBreaker:  tt.tieBreaker,
+			}
+			if err := repo.RecalculateAllRanksWithIsolation(
+				ctx, []models.Board{board}, constants.WindowAllTime, allTime, time.Time{}, testDay, testFence,
+			); err != nil {
+				t.Fatalf("recalculate: %v", err)
+			}
+
+			if _, ranked := standings(t, master, board.Name)[6]; ranked {
+				t.Fatalf("shadow-banned user 6 is ranked for everyone")
+			}
+
+			standing, found, err := repo.ShadowStanding(ctx, board, constants.WindowAllTime, allTime, time.Time{}, 6)
+			if err != nil {
+				t.Fatalf("shadow standing: %v", err)
+			}
+			if !found {
+				t.Fatalf("shadow standing of user 6 not found")
+			}
+			if standing.TotalScore != 40 || standing.Rank != tt.want {
+				t.Errorf("user 6 scored %d at rank %d, want 40 at rank %d", standing.TotalScore, standing.Rank, tt.want)
+			}
+		})
+	}
+}
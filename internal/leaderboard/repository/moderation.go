@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"log"
+
+	"gaming-leaderboard/internal/models"
+	"gaming-leaderboard/internal/repository"
+	"gaming-leaderboard/pkg/db/postgres"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BansRepository struct {
+	repository.Interface[models.LeaderboardBan]
+	db *postgres.DbCluster
+}
+
+func NewBansRepository(db *postgres.DbCluster) *BansRepository {
+	return &BansRepository{
+		Interface: &repository.Repository[models.LeaderboardBan]{Db: db},
+		db:        db,
+	}
+}
+
+type ModerationActionsRepository struct {
+	repository.Interface[models.ModerationAction]
+}
+
+func NewModerationActionsRepository(db *postgres.DbCluster) *ModerationActionsRepository {
+	return &ModerationActionsRepository{
+		Interface: &repository.Repository[models.ModerationAction]{Db: db},
+	}
+}
+
+// Ban records ban and audits it as action in one transaction. A user already banned with
+// another kind is switched to ban's. userFound is false when there is no such user, and
+// changed false when the user was already banned with the same kind.
+func (r *BansRepository) Ban(
+	ctx context.Context,
+	ban *models.LeaderboardBan,
+	action *models.ModerationAction,
+) (userFound bool, changed bool, err error) {
+	err = r.db.GetMasterDB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Raw("SELECT EXISTS (SELECT 1 FROM users WHERE id = ?)", ban.UserID).Scan(&userFound).Error; err != nil {
+			return err
+		}
+		if !userFound {
+			return nil
+		}
+
+		upsert := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"kind", "reason", "actor", "created_at"}),
+			Where:     clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "leaderboard_bans.kind <> EXCLUDED.kind"}}},
+		}).Create(ban)
+		if upsert.Error != nil {
+			return upsert.Error
+		}
+
+		changed = upsert.RowsAffected == 1
+		if !changed {
+			return nil
+		}
+
+		return tx.Create(action).Error
+	})
+	if err != nil {
+		log.Printf("[ERROR] Ban: user_id=%d | kind=%s | err=%v", ban.UserID, ban.Kind, err)
+		return false, false, err
+	}
+
+	return userFound, changed, nil
+}
+
+// Unban lifts a user's ban and audits it as action in one transaction, returning the lifted
+// ban. found is false when the user was not banned.
+func (r *BansRepository) Unban(
+	ctx context.Context,
+	userID int,
+	action *models.ModerationAction,
+) (ban models.LeaderboardBan, found bool, err error) {
+	err = r.db.GetMasterDB(ctx).Transaction(func(tx *gorm.DB) error {
+		deleted := tx.Clauses(clause.Returning{}).Where("user_id = ?", userID).Delete(&ban)
+		if deleted.Error != nil {
+			return deleted.Error
+		}
+
+		found = deleted.RowsAffected == 1
+		if !found {
+			return nil
+		}
+
+		return tx.Create(action).Error
+	})
+	if err != nil {
+		log.Printf("[ERROR] Unban: user_id=%d | err=%v", userID, err)
+		return models.LeaderboardBan{}, false, err
+	}
+
+	return ban, found, nil
+}
+
+// Bans returns the bans in force against any of userIDs, keyed by user
+func (r *BansRepository) Bans(ctx context.Context, userIDs []int) (map[int]models.LeaderboardBan, error) {
+	bans := make(map[int]models.LeaderboardBan)
+	if len(userIDs) == 0 {
+		return bans, nil
+	}
+
+	var found []models.LeaderboardBan
+	if err := r.db.GetSlaveDB(ctx).Where("user_id IN ?", userIDs).Find(&found).Error; err != nil {
+		log.Printf("[ERROR] Bans: users=%d | err=%v", len(userIDs), err)
+		return nil, err
+	}
+
+	for _, ban := range found {
+		bans[ban.UserID] = ban
+	}
+
+	return bans, nil
+}
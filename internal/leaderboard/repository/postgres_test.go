@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/models"
+	"gaming-leaderboard/pkg/db/postgres"
+
+	"gorm.io/gorm"
+)
+
+// testFence is the worker lock term the tests recalculate under, every test schema has its own
+// fence so the same term can be reused
+var testFence = Fence{Token: 1, Holder: "test"}
+
+// allTime is the period all-time boards are ranked over
+var allTime = time.Unix(0, 0).UTC()
+
+// testDB opens the database the TEST_POSTGRES_* variables name, the docker-compose one by
+// default, in a schema of its own that is dropped once the test ends. The test is skipped when
+// TEST_POSTGRES_HOST is not set.
+func testDB(t *testing.T) *postgres.DbCluster {
+	t.Helper()
+
+	host := os.Getenv("TEST_POSTGRES_HOST")
+	if host == "" {
+		t.Skip("TEST_POSTGRES_HOST is not set, skipping the Postgres test")
+	}
+
+	ctx := context.Background()
+	cluster := postgres.InitializeDBInstance(ctx, postgres.DBConfig{
+		Host:               host,
+		Port:               envOr("TEST_POSTGRES_PORT", "5433"),
+		Username:           envOr("TEST_POSTGRES_USER", "admin"),
+		Password:           envOr("TEST_POSTGRES_PASSWORD", "admin"),
+		Dbname:             envOr("TEST_POSTGRES_DB", "crud"),
+		MaxOpenConnections: 4,
+		MaxIdleConnections: 1,
+	}, &[]postgres.DBConfig{}, nil)
+
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	db, err := cluster.ForSchema(ctx, schema, 4, 1, nil)
+	if err != nil {
+		t.Fatalf("open schema %s: %v", schema, err)
+	}
+
+	t.Cleanup(func() {
+		closeDB(t, db.GetMasterDB(ctx))
+		if err := cluster.GetMasterDB(ctx).Exec(fmt.Sprintf(`DROP SCHEMA "%s" CASCADE`, schema)).Error; err != nil {
+			t.Errorf("drop schema %s: %v", schema, err)
+		}
+		closeDB(t, cluster.GetMasterDB(ctx))
+	})
+
+	return db
+}
+
+func envOr(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+
+	return fallback
+}
+
+func closeDB(t *testing.T, db *gorm.DB) {
+	sqlDB, err := db.DB()
+	if err == nil {
+		err = sqlDB.Close()
+	}
+	if err != nil {
+		t.Errorf("close connections: %v", err)
+	}
+}
+
+// played is one session of a test user, minutes after the start of the test's day
+type played struct {
+	userID  int
+	score   int
+	minutes int
+	// gameMode is solo unless set
+	gameMode string
+	// status is accepted unless set
+	status string
+	voided bool
+}
+
+// testDay is when the test sessions are played
+var testDay = time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+// seedSessions records sessions, creating their users as needed
+func seedSessions(t *testing.T, db *gorm.DB, sessions []played) {
+	t.Helper()
+
+	for _, session := range sessions {
+		user := models.User{ID: session.userID, Username: fmt.Sprintf("user-%d", session.userID)}
+		if err := db.Where(models.User{ID: session.userID}).FirstOrCreate(&user).Error; err != nil {
+			t.Fatalf("create user %d: %v", session.userID, err)
+		}
+
+		record := models.GameSession{
+			UserID:    session.userID,
+			Score:     session.score,
+			GameMode:  session.gameMode,
+			Timestamp: testDay.Add(time.Duration(session.minutes) * time.Minute),
+			Status:    session.status,
+		}
+		if record.GameMode == "" {
+			record.GameMode = constants.GameModeSolo
+		}
+		if record.Status == "" {
+			record.Status = constants.SessionStatusAccepted
+		}
+		if session.voided {
+			voidedAt := testDay
+			record.VoidedAt = &voidedAt
+		}
+
+		if err := db.Create(&record).Error; err != nil {
+			t.Fatalf("record session of user %d: %v", session.userID, err)
+		}
+	}
+}
+
+// standing is what a test expects of one user's row on a board
+type standing struct {
+	score int
+	rank  int
+}
+
+// standings reads every row of a board's all-time standings by user
+func standings(t *testing.T, db *gorm.DB, board string) map[int]models.Leaderboard {
+	t.Helper()
+
+	var rows []models.Leaderboard
+	if err := db.Where("board = ? AND time_window = ? AND period_start = ?", board, constants.WindowAllTime, allTime).
+		Find(&rows).Error; err != nil {
+		t.Fatalf("read standings of %s: %v", board, err)
+	}
+
+	byUser := make(map[int]models.Leaderboard, len(rows))
+	for _, row := range rows {
+		byUser[row.UserID] = row
+	}
+
+	return byUser
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"gaming-leaderboard/internal/models"
+	"gaming-leaderboard/internal/repository"
+	"gaming-leaderboard/pkg/db/postgres"
+)
+
+type RankHistoryRepository struct {
+	repository.Interface[models.RankSnapshot]
+	db *postgres.DbCluster
+}
+
+func NewRankHistoryRepository(db *postgres.DbCluster) *RankHistoryRepository {
+	return &RankHistoryRepository{
+		Interface: &repository.Repository[models.RankSnapshot]{Db: db},
+		db:        db,
+	}
+}
+
+// GetPreviousRanks returns each user's rank on one board period as of their latest snapshot
+// recorded before the given day. Users without such a snapshot are left out.
+func (r *RankHistoryRepository) GetPreviousRanks(
+	ctx context.Context,
+	board string,
+	window string,
+	periodStart time.Time,
+	userIDs []int,
+	before time.Time,
+) (map[int]int, error) {
+	ranks := make(map[int]int, len(userIDs))
+	if len(userIDs) == 0 {
+		return ranks, nil
+	}
+
+	query := `
+		SELECT DISTINCT ON (user_id) user_id, rank
+		FROM rank_history
+		WHERE board = @board
+			AND time_window = @window
+			AND period_start = @period_start
+			AND user_id IN @user_ids
+			AND recorded_on < CAST(@before AS DATE)
+		ORDER BY user_id, recorded_on DESC
+	`
+
+	var rows []models.RankSnapshot
+	if err := r.db.GetSlaveDB(ctx).Raw(
+		query,
+		sql.Named("board", board),
+		sql.Named("window", window),
+		sql.Named("period_start", periodStart.UTC()),
+		sql.Named("user_ids", userIDs),
+		sql.Named("before", before.Format(time.DateOnly)),
+	).Scan(&rows).Error; err != nil {
+		log.Printf("[ERROR] GetPreviousRanks: board=%s | window=%s | err=%v", board, window, err)
+		return nil, err
+	}
+
+	for _, row := range rows {
+		ranks[row.UserID] = row.Rank
+	}
+
+	return ranks, nil
+}
+
+// PurgeBefore deletes snapshots recorded before the given day
+func (r *RankHistoryRepository) PurgeBefore(ctx context.Context, before time.Time) error {
+	tx := r.db.GetMasterDB(ctx).
+		Where("recorded_on < CAST(? AS DATE)", before.Format(time.DateOnly)).
+		Delete(&models.RankSnapshot{})
+	if tx.Error != nil {
+		log.Printf("[ERROR] RankHistory PurgeBefore: err=%v", tx.Error)
+		return tx.Error
+	}
+
+	if tx.RowsAffected > 0 {
+		log.Printf("[INFO] RankHistory PurgeBefore: deleted=%d", tx.RowsAffected)
+	}
+	return nil
+}
+
+// recordSnapshotSQL copies standings of a board period, selected from a table or CTE and a
+// filter, into the history. There is one snapshot per user and day, overwritten until the day ends.
+const recordSnapshotSQL = `
+	INSERT INTO rank_history (user_id, board, time_window, period_start, recorded_on, total_score, rank)
+	SELECT user_id, @board, @window, @period_start, CAST(@recorded_on AS DATE), total_score, rank
+	FROM %s
+	WHERE %s
+	ON CONFLICT (board, time_window, period_start, user_id, recorded_on)
+	DO UPDATE SET
+		total_score = EXCLUDED.total_score,
+		rank = EXCLUDED.rank
+`
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/models"
+	"gaming-leaderboard/internal/repository"
+	"gaming-leaderboard/pkg/db/postgres"
+)
+
+type SeasonsRepository struct {
+	repository.Interface[models.Season]
+	db *postgres.DbCluster
+}
+
+func NewSeasonsRepository(db *postgres.DbCluster) *SeasonsRepository {
+	return &SeasonsRepository{
+		Interface: &repository.Repository[models.Season]{Db: db},
+		db:        db,
+	}
+}
+
+type SeasonStandingsRepository struct {
+	repository.Interface[models.SeasonStanding]
+}
+
+func NewSeasonStandingsRepository(db *postgres.DbCluster) *SeasonStandingsRepository {
+	return &SeasonStandingsRepository{
+		Interface: &repository.Repository[models.SeasonStanding]{Db: db},
+	}
+}
+
+// GetBoundary returns the instant the live all-time board starts counting from: the start of
+// the active season, else the end of the most recently closed one, else the zero time
+func (r *SeasonsRepository) GetBoundary(ctx context.Context) (time.Time, error) {
+	var boundary sql.NullTime
+
+	query := `
+		SELECT COALESCE(
+			(SELECT start_at FROM seasons WHERE status = @active),
+			(SELECT MAX(end_at) FROM seasons WHERE status = @closed)
+		)
+	`
+
+	if err := r.db.GetMasterDB(ctx).Raw(
+		query,
+		sql.Named("active", constants.SeasonStatusActive),
+		sql.Named("closed", constants.SeasonStatusClosed),
+	).Scan(&boundary).Error; err != nil {
+		log.Printf("[ERROR] GetBoundary: err=%v", err)
+		return time.Time{}, err
+	}
+
+	if !boundary.Valid {
+		return time.Time{}, nil
+	}
+
+	return boundary.Time, nil
+}
+
+// CloseWithStandings freezes the season's all-time standings into the archive, marks it
+// closed and clears its live rows, all in one transaction. Nothing is written once a newer
+// worker lock term than fence has, ErrFenced is returned instead.
+func (r *SeasonsRepository) CloseWithStandings(ctx context.Context, season models.Season, closedAt time.Time, fence Fence) error {
+	tx := r.db.GetMasterDB(ctx).Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := checkFence(tx, fence); err != nil {
+		tx.Rollback()
+		log.Printf("[ERROR] CloseWithStandings: fence check failed | season_id=%d | token=%d | err=%v", season.ID, fence.Token, err)
+		return err
+	}
+
+	archive := `
+		INSERT INTO season_standings (season_id, user_id, board, game_mode, total_score, rank, reached_at)
+		SELECT @season_id, user_id, board, game_mode, total_score, rank, reached_at
+		FROM leaderboard
+		WHERE time_window = @window AND period_start = @period_start
+	`
+
+	if err := tx.Exec(
+		archive,
+		sql.Named("season_id", season.ID),
+		sql.Named("window", constants.WindowAllTime),
+		sql.Named("period_start", season.StartAt.UTC()),
+	).Error; err != nil {
+		tx.Rollback()
+		log.Printf("[ERROR] CloseWithStandings: archive failed | season_id=%d | err=%v", season.ID, err)
+		return err
+	}
+
+	if err := tx.Model(&models.Season{}).
+		Where("id = ?", season.ID).
+		Updates(map[string]interface{}{
+			constants.Status: constants.SeasonStatusClosed,
+			"end_at":         closedAt.UTC(),
+		}).Error; err != nil {
+		tx.Rollback()
+		log.Printf("[ERROR] CloseWithStandings: status update failed | season_id=%d | err=%v", season.ID, err)
+		return err
+	}
+
+	if err := tx.Where(
+		constants.TimeWindow+" = ? AND "+constants.PeriodStart+" = ?",
+		constants.WindowAllTime,
+		season.StartAt.UTC(),
+	).Delete(&models.Leaderboard{}).Error; err != nil {
+		tx.Rollback()
+		log.Printf("[ERROR] CloseWithStandings: live cleanup failed | season_id=%d | err=%v", season.ID, err)
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		log.Printf("[ERROR] CloseWithStandings: commit failed | season_id=%d | err=%v", season.ID, err)
+		return err
+	}
+
+	log.Printf("[INFO] CloseWithStandings: season closed | season_id=%d", season.ID)
+	return nil
+}
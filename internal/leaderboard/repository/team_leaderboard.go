@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/models"
+	"gaming-leaderboard/internal/repository"
+	"gaming-leaderboard/pkg/db/postgres"
+
+	"gorm.io/gorm"
+)
+
+type TeamLeaderboardRepository struct {
+	repository.Interface[models.TeamStanding]
+	db *postgres.DbCluster
+}
+
+func NewTeamLeaderboardRepository(db *postgres.DbCluster) *TeamLeaderboardRepository {
+	return &TeamLeaderboardRepository{
+		Interface: &repository.Repository[models.TeamStanding]{Db: db},
+		db:        db,
+	}
+}
+
+// splitSQL is the expression combining a team's member scores into the team's score
+func splitSQL(board models.TeamBoard) string {
+	switch board.Split {
+	case constants.SplitAverage:
+		return "ROUND(AVG(total_score))"
+	default:
+		// Top splits are the sum over the members kept
+		return "SUM(total_score)"
+	}
+}
+
+// teamRankSQL is the window function numbering a board's teams by score, ordinal ranks settle
+// ties by which team reached its score first
+func teamRankSQL(board models.TeamBoard) string {
+	switch board.RankType {
+	case constants.RankDense:
+		return "DENSE_RANK() OVER (ORDER BY total_score DESC)"
+	case constants.RankOrdinal:
+		return "ROW_NUMBER() OVER (ORDER BY total_score DESC, reached_at ASC, team_id ASC)"
+	default:
+		return "RANK() OVER (ORDER BY total_score DESC)"
+	}
+}
+
+// RecalculateTeamBoards ranks every team board over one window period from the team-mode
+// sessions played in [periodStart, periodEnd), a zero periodEnd leaving it open ended. Sessions
+// count for the team they were played for, even once their player has left it. Nothing is
+// written once a newer worker lock term than fence has, ErrFenced is returned instead.
+func (r *TeamLeaderboardRepository) RecalculateTeamBoards(
+	ctx context.Context,
+	boards []models.TeamBoard,
+	window string,
+	periodStart time.Time,
+	periodEnd time.Time,
+	fence Fence,
+) error {
+	if len(boards) == 0 {
+		return nil
+	}
+
+	err := r.db.GetMasterDB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkFence(tx, fence); err != nil {
+			return err
+		}
+
+		for _, board := range boards {
+			if err := recalculateTeamBoard(tx, board, window, periodStart, periodEnd); err != nil {
+				return fmt.Errorf("board %s: %w", board.Name, err)
+			}
+		}
+
+		return nil
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+	if err != nil {
+		log.Printf("[ERROR] RecalculateTeamBoards: window=%s | period_start=%v | err=%v", window, periodStart, err)
+		return err
+	}
+
+	return nil
+}
+
+// recalculateTeamBoard replaces a team board period's standings with ones ranked from every one
+// of its sessions
+func recalculateTeamBoard(
+	tx *gorm.DB,
+	board models.TeamBoard,
+	window string,
+	periodStart time.Time,
+	periodEnd time.Time,
+) error {
+	// The sessions a team board counts are those a team-mode user board would
+	p := boardPeriod{
+		board:       models.Board{Name: board.Name, GameMode: constants.GameModeTeam},
+		window:      window,
+		periodStart: periodStart,
+		periodEnd:   periodEnd,
+	}
+	agg := aggregationSQL(models.Board{Aggregation: board.Aggregation, Sessions: board.Sessions})
+
+	position, positionFilter := "", ""
+	if agg.keep > 0 {
+		position = fmt.Sprintf(", ROW_NUMBER() OVER (PARTITION BY team_id, user_id ORDER BY %s) as position", agg.order)
+		positionFilter = "WHERE position <= @keep"
+	}
+
+	memberFilter := ""
+	if board.Split == constants.SplitTop {
+		memberFilter = "WHERE member_position <= @members"
+	}
+
+	if err := tx.Exec("DELETE FROM team_leaderboard WHERE "+boardPeriodFilter, p.args()...).Error; err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(`
+		WITH board_sessions AS (
+			SELECT
+				team_id,
+				user_id,
+				score,
+				timestamp%s
+			FROM game_sessions
+			WHERE team_id IS NOT NULL AND %s
+		),
+		member_scores AS (
+			SELECT
+				team_id,
+				user_id,
+				%s as total_score,
+				%s as reached_at
+			FROM board_sessions
+			%s
+			GROUP BY team_id, user_id
+		),
+		ranked_members AS (
+			SELECT
+				*,
+				ROW_NUMBER() OVER (PARTITION BY team_id ORDER BY total_score DESC, reached_at ASC, user_id ASC) as member_position
+			FROM member_scores
+		),
+		team_scores AS (
+			SELECT
+				team_id,
+				%s as total_score,
+				MAX(reached_at) as reached_at,
+				COUNT(*) as members
+			FROM ranked_members
+			%s
+			GROUP BY team_id
+		)
+		INSERT INTO team_leaderboard (team_id, board, time_window, period_start, total_score, rank, reached_at, members)
+		SELECT team_id, @board, @window, @period_start, total_score, %s, reached_at, members FROM team_scores
+	`, position, p.sessionFilter(), agg.score, agg.reachedAt, positionFilter, splitSQL(board), memberFilter, teamRankSQL(board))
+
+	return tx.Exec(query, p.args(sql.Named("keep", agg.keep), sql.Named("members", board.Members))...).Error
+}
+
+// PurgeRemovedBoards deletes the standings of team boards that are no longer configured.
+// Nothing is deleted once a newer worker lock term than fence has written, ErrFenced is
+// returned instead.
+func (r *TeamLeaderboardRepository) PurgeRemovedBoards(ctx context.Context, boards []string, fence Fence) error {
+	err := r.db.GetMasterDB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkFence(tx, fence); err != nil {
+			return err
+		}
+
+		db := tx.Where("TRUE")
+		if len(boards) > 0 {
+			db = db.Where(constants.Board+" NOT IN ?", boards)
+		}
+
+		return db.Delete(&models.TeamStanding{}).Error
+	})
+	if err != nil {
+		log.Printf("[ERROR] PurgeRemovedBoards: team boards token=%d | err=%v", fence.Token, err)
+		return err
+	}
+
+	return nil
+}
+
+// PurgeExpiredPeriods deletes the team standings of window periods that started before the
+// cutoff. Nothing is deleted once a newer worker lock term than fence has written, ErrFenced is
+// returned instead.
+func (r *TeamLeaderboardRepository) PurgeExpiredPeriods(ctx context.Context, window string, before time.Time, fence Fence) error {
+	err := r.db.GetMasterDB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkFence(tx, fence); err != nil {
+			return err
+		}
+
+		return tx.Where(constants.TimeWindow+" = ? AND "+constants.PeriodStart+" < ?", window, before.UTC()).
+			Delete(&models.TeamStanding{}).Error
+	})
+	if err != nil {
+		log.Printf("[ERROR] PurgeExpiredPeriods: team boards window=%s | token=%d | err=%v", window, fence.Token, err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"time"
+
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/models"
+	"gaming-leaderboard/pkg/apperror"
+	oredis "gaming-leaderboard/pkg/redis"
+)
+
+var boardNamePattern = regexp.MustCompile(`^[a-z0-9_-]{1,64}$`)
+
+// ValidateBoards checks board definitions before the service starts ranking them, filling in
+// competition ranking with ties broken by who reached the score first, the highest score
+// leading and the default top list size where left unset
+func ValidateBoards(boards []models.Board) error {
+	if len(boards) == 0 {
+		return fmt.Errorf("at least one board must be configured")
+	}
+
+	names := make(map[string]struct{}, len(boards))
+	for i := range boards {
+		board := &boards[i]
+
+		if !boardNamePattern.MatchString(board.Name) {
+			return fmt.Errorf("board name %q must match %s", board.Name, boardNamePattern)
+		}
+
+		if _, ok := names[board.Name]; ok {
+			return fmt.Errorf("board %q is declared twice", board.Name)
+		}
+		names[board.Name] = struct{}{}
+
+		switch board.GameMode {
+		case constants.GameModeSolo, constants.GameModeTeam, constants.GameModeOverall:
+		default:
+			return fmt.Errorf("board %q has unknown game mode %q", board.Name, board.GameMode)
+		}
+
+		switch board.Aggregation {
+		case constants.AggregationSum, constants.AggregationBest, constants.AggregationLatest:
+		case constants.AggregationAverage, constants.AggregationBestN:
+			if board.Sessions < 1 {
+				return fmt.Errorf("board %q needs a positive session count for %s aggregation", board.Name, board.Aggregation)
+			}
+		default:
+			return fmt.Errorf("board %q has unknown aggregation %q", board.Name, board.Aggregation)
+		}
+
+		switch board.RankType {
+		case "":
+			board.RankType = constants.RankCompetition
+		case constants.RankCompetition, constants.RankDense, constants.RankOrdinal:
+		default:
+			return fmt.Errorf("board %q has unknown rank type %q", board.Name, board.RankType)
+		}
+
+		switch board.TieBreaker {
+		case "":
+			board.TieBreaker = constants.TieBreakReachedFirst
+		case constants.TieBreakReachedFirst, constants.TieBreakUserID:
+		default:
+			return fmt.Errorf("board %q has unknown tie breaker %q", board.Name, board.TieBreaker)
+		}
+
+		if err := validateWindows(*board); err != nil {
+			return err
+		}
+
+		switch board.SortDirection {
+		case "":
+			board.SortDirection = constants.SortDescending
+		case constants.SortDescending, constants.SortAscending:
+		default:
+			return fmt.Errorf("board %q has unknown sort direction %q", board.Name, board.SortDirection)
+		}
+
+		// Scores are ranked negated on ascending boards, which score thresholds cannot follow
+		if board.SortDirection == constants.SortAscending && board.TierBasis == constants.TierBasisScore {
+			return fmt.Errorf("board %q cannot place tiers by score when the lowest score leads", board.Name)
+		}
+
+		switch {
+		case board.SizeCap == 0:
+			board.SizeCap = constants.TopLeaderboardLimit
+		case board.SizeCap < 0 || board.SizeCap > constants.MaxBoardSizeCap:
+			return fmt.Errorf("board %q needs a size cap between 1 and %d", board.Name, constants.MaxBoardSizeCap)
+		}
+
+		if board.RefreshSeconds < 0 {
+			return fmt.Errorf("board %q cannot have a negative refresh interval", board.Name)
+		}
+
+		if board.CacheSeconds < 0 {
+			return fmt.Errorf("board %q cannot have a negative cache ttl", board.Name)
+		}
+
+		if err := validateTiers(*board); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateWindows checks a board names each window it is ranked over once, and only known ones
+func validateWindows(board models.Board) error {
+	seen := make(map[string]struct{}, len(board.Windows))
+	for _, window := range board.Windows {
+		switch window {
+		case constants.WindowDaily, constants.WindowWeekly, constants.WindowMonthly, constants.WindowAllTime:
+		default:
+			return fmt.Errorf("board %q has unknown window %q", board.Name, window)
+		}
+
+		if _, ok := seen[window]; ok {
+			return fmt.Errorf("board %q lists window %q twice", board.Name, window)
+		}
+		seen[window] = struct{}{}
+	}
+
+	return nil
+}
+
+// validateTiers checks tiers are named once each and their thresholds get easier to meet
+// from one tier to the next. The last tier's threshold is never checked against.
+func validateTiers(board models.Board) error {
+	if len(board.Tiers) == 0 {
+		return nil
+	}
+
+	if board.TierBasis != constants.TierBasisPercentile && board.TierBasis != constants.TierBasisScore {
+		return fmt.Errorf("board %q has unknown tier basis %q", board.Name, board.TierBasis)
+	}
+
+	names := make(map[string]struct{}, len(board.Tiers))
+	for i, tier := range board.Tiers {
+		if tier.Name == "" {
+			return fmt.Errorf("board %q has a tier without a name", board.Name)
+		}
+
+		if _, ok := names[tier.Name]; ok {
+			return fmt.Errorf("board %q declares tier %q twice", board.Name, tier.Name)
+		}
+		names[tier.Name] = struct{}{}
+
+		if i == len(board.Tiers)-1 {
+			break
+		}
+
+		switch board.TierBasis {
+		case constants.TierBasisPercentile:
+			if tier.TopPercent <= 0 || tier.TopPercent > 100 {
+				return fmt.Errorf("board %q tier %q needs a top percent in (0, 100]", board.Name, tier.Name)
+			}
+			if i > 0 && tier.TopPercent <= board.Tiers[i-1].TopPercent {
+				return fmt.Errorf("board %q tier %q must cover a larger top percent than the tier above", board.Name, tier.Name)
+			}
+		case constants.TierBasisScore:
+			if i > 0 && tier.MinScore >= board.Tiers[i-1].MinScore {
+				return fmt.Errorf("board %q tier %q must need a lower score than the tier above", board.Name, tier.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Boards lists every board that is ranked as last loaded from the leaderboard definitions,
+// the first one is the default
+func (s *LeaderboardService) Boards() []models.Board {
+	s.boardsMu.RLock()
+	defer s.boardsMu.RUnlock()
+
+	return s.boards
+}
+
+// boardNames lists the name of every board that is ranked
+func (s *LeaderboardService) boardNames() []string {
+	boards := s.Boards()
+
+	names := make([]string, 0, len(boards))
+	for _, board := range boards {
+		names = append(names, board.Name)
+	}
+
+	return names
+}
+
+// boardsOver lists the boards ranked over a window
+func (s *LeaderboardService) boardsOver(window string) []models.Board {
+	boards := make([]models.Board, 0)
+	for _, board := range s.Boards() {
+		if rankedOver(board, window) {
+			boards = append(boards, board)
+		}
+	}
+
+	return boards
+}
+
+// rankedOver reports whether a board is ranked over a window, boards naming no window are
+// ranked over every one
+func rankedOver(board models.Board, window string) bool {
+	if len(board.Windows) == 0 {
+		return true
+	}
+
+	return slices.Contains(board.Windows, window)
+}
+
+// resolveBoard finds the requested board by name, else the first board of the requested
+// game mode, else the default board
+func (s *LeaderboardService) resolveBoard(name string, gameMode string) (models.Board, apperror.Error) {
+	for _, board := range s.Boards() {
+		switch {
+		case name != "" && board.Name == name:
+			return board, apperror.Error{}
+		case name == "" && gameMode != "" && board.GameMode == gameMode:
+			return board, apperror.Error{}
+		case name == "" && gameMode == "":
+			return board, apperror.Error{}
+		}
+	}
+
+	if name != "" {
+		return models.Board{}, apperror.New(fmt.Errorf("board %s not found", name), http.StatusNotFound)
+	}
+
+	return models.Board{}, apperror.New(
+		fmt.Errorf("no board is configured for game mode %s", gameMode),
+		http.StatusBadRequest,
+	)
+}
+
+// sessionBoards lists the boards a session of the given game mode counts towards
+func (s *LeaderboardService) sessionBoards(gameMode string) []models.Board {
+	boards := make([]models.Board, 0)
+	for _, board := range s.Boards() {
+		if board.GameMode == gameMode || board.GameMode == constants.GameModeOverall {
+			boards = append(boards, board)
+		}
+	}
+
+	return boards
+}
+
+// rankedScore is how a board ranks a score. Rankings always put the highest score first, so
+// boards where the lowest score leads rank it negated. Negating again gives back the score as
+// played, which is how every score leaves the service.
+func rankedScore(board models.Board, score int) int {
+	if board.SortDirection == constants.SortAscending {
+		return -score
+	}
+
+	return score
+}
+
+// shownScores turns the ranked scores of a board's entries back into the scores as played
+func shownScores(board models.Board, entries models.LeaderboardSlice) models.LeaderboardSlice {
+	for _, entry := range entries {
+		entry.TotalScore = rankedScore(board, entry.TotalScore)
+	}
+
+	return entries
+}
+
+// realtimeUpdate is how a board's ranking sorted set absorbs a new session score. Averages and
+// best-N sums depend on more than the previous score, so those rankings are instead rebuilt
+// from Postgres after every recalculation.
+func realtimeUpdate(board models.Board) (mode oredis.ZUpdateMode, ok bool) {
+	switch board.Aggregation {
+	case constants.AggregationSum:
+		return oredis.ZUpdateIncr, true
+	case constants.AggregationBest:
+		return oredis.ZUpdateMax, true
+	case constants.AggregationLatest:
+		return oredis.ZUpdateSet, true
+	default:
+		return 0, false
+	}
+}
+
+// cacheTTL is how long a list or rank of board read from Postgres stays cached, its own ttl
+// when the board sets one and otherwise fallback
+func cacheTTL(board models.Board, fallback time.Duration) time.Duration {
+	if board.CacheSeconds > 0 {
+		return time.Duration(board.CacheSeconds) * time.Second
+	}
+
+	return fallback
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gaming-leaderboard/internal/models"
+	"gaming-leaderboard/pkg/apperror"
+)
+
+// Cursor is the sort key of the last entry of a leaderboard page, opaque to clients
+type Cursor struct {
+	Rank      int        `json:"r"`
+	ReachedAt *time.Time `json:"t,omitempty"`
+	UserID    int        `json:"u"`
+}
+
+// cursorAfter returns the cursor resuming a listing after entry
+func cursorAfter(entry *models.Leaderboard) Cursor {
+	cursor := Cursor{Rank: entry.Rank, UserID: entry.UserID}
+	if !entry.ReachedAt.IsZero() {
+		reachedAt := entry.ReachedAt.UTC()
+		cursor.ReachedAt = &reachedAt
+	}
+
+	return cursor
+}
+
+func (c Cursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor parses a cursor previously handed out with a page
+func DecodeCursor(encoded string) (Cursor, apperror.Error) {
+	var cursor Cursor
+
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Cursor{}, apperror.New(fmt.Errorf("invalid cursor"), http.StatusBadRequest)
+	}
+
+	if err := json.Unmarshal(raw, &cursor); err != nil || cursor.Rank < 1 || cursor.UserID < 1 {
+		return Cursor{}, apperror.New(fmt.Errorf("invalid cursor"), http.StatusBadRequest)
+	}
+
+	return cursor, apperror.Error{}
+}
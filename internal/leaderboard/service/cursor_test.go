@@ -0,0 +1,69 @@
+package service
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func TestDecodeCursor(t *testing.T) {
+	reachedAt := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+
+	tests := []struct {
+		name    string
+		encoded string
+		want    Cursor
+		invalid bool
+	}{
+		{
+			name:    "round trip",
+			encoded: Cursor{Rank: 3, ReachedAt: &reachedAt, UserID: 42}.Encode(),
+			want:    Cursor{Rank: 3, ReachedAt: &reachedAt, UserID: 42},
+		},
+		{
+			name:    "round trip without reached-at",
+			encoded: Cursor{Rank: 3, UserID: 42}.Encode(),
+			want:    Cursor{Rank: 3, UserID: 42},
+		},
+		{name: "empty", encoded: "", invalid: true},
+		{name: "not base64", encoded: "not a cursor!", invalid: true},
+		{name: "padded base64", encoded: base64.URLEncoding.EncodeToString([]byte(`{"r":3,"u":42}`)), invalid: true},
+		{name: "not json", encoded: encode("rank 3"), invalid: true},
+		{name: "wrong types", encoded: encode(`{"r":"3","u":42}`), invalid: true},
+		{name: "missing rank", encoded: encode(`{"u":42}`), invalid: true},
+		{name: "zero rank", encoded: encode(`{"r":0,"u":42}`), invalid: true},
+		{name: "missing user", encoded: encode(`{"r":3}`), invalid: true},
+		{name: "negative user", encoded: encode(`{"r":3,"u":-1}`), invalid: true},
+		{name: "malformed reached-at", encoded: encode(`{"r":3,"t":"yesterday","u":42}`), invalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor, cusErr := DecodeCursor(tt.encoded)
+
+			if tt.invalid {
+				if !cusErr.Exists() {
+					t.Fatalf("DecodeCursor(%q) = %+v, want an error", tt.encoded, cursor)
+				}
+				if cusErr.Error() != "invalid cursor" {
+					t.Errorf("error = %q, want %q", cusErr.Error(), "invalid cursor")
+				}
+				return
+			}
+
+			if cusErr.Exists() {
+				t.Fatalf("DecodeCursor(%q): %v", tt.encoded, cusErr)
+			}
+			if cursor.Rank != tt.want.Rank || cursor.UserID != tt.want.UserID {
+				t.Errorf("cursor = %+v, want %+v", cursor, tt.want)
+			}
+			if (cursor.ReachedAt == nil) != (tt.want.ReachedAt == nil) ||
+				(cursor.ReachedAt != nil && !cursor.ReachedAt.Equal(*tt.want.ReachedAt)) {
+				t.Errorf("reached at = %v, want %v", cursor.ReachedAt, tt.want.ReachedAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,420 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/controller/request"
+	"gaming-leaderboard/internal/models"
+	"gaming-leaderboard/pkg/apperror"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+// WatchBoards loads the leaderboard definitions and keeps reloading them in the background,
+// so boards changed through any instance are served here too
+func (s *LeaderboardService) WatchBoards(ctx context.Context) {
+	if err := s.loadBoards(ctx); err != nil {
+		log.Printf("[ERROR] leaderboard definitions load failed | err=%v", err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(constants.BoardsReloadInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.loadBoards(ctx); err != nil {
+					log.Printf("[WARN] leaderboard definitions reload failed | err=%v", err)
+				}
+			}
+		}
+	}()
+}
+
+// loadBoards replaces the boards and team boards ranked with the stored leaderboard
+// definitions, seeding the definitions of each kind from the configured ones the first time
+func (s *LeaderboardService) loadBoards(ctx context.Context) error {
+	if err := s.seedDefinitions(ctx); err != nil {
+		return err
+	}
+
+	definitions, err := s.definitionsRepository.All(ctx)
+	if err != nil {
+		return err
+	}
+
+	boards, teamBoards := boardsOf(definitions)
+	if len(boards) == 0 {
+		return fmt.Errorf("no board is defined")
+	}
+
+	s.boardsMu.Lock()
+	s.boards = boards
+	s.teamBoards = teamBoards
+	s.boardsMu.Unlock()
+
+	return nil
+}
+
+// boardsOf splits definitions into the boards and team boards they declare, each versioned by
+// its definition
+func boardsOf(definitions []models.LeaderboardDefinition) ([]models.Board, []models.TeamBoard) {
+	boards := make([]models.Board, 0, len(definitions))
+	teamBoards := make([]models.TeamBoard, 0)
+	for _, definition := range definitions {
+		if definition.Kind == constants.DefinitionKindTeam {
+			teamBoards = append(teamBoards, definition.TeamBoard())
+			continue
+		}
+		board := definition.Board
+		board.Version = definition.Version()
+		boards = append(boards, board)
+	}
+
+	return boards, teamBoards
+}
+
+// seedDefinitions stores the configured boards and team boards as definitions, each kind only
+// the first time so boards retired through the admin API are not brought back
+func (s *LeaderboardService) seedDefinitions(ctx context.Context) error {
+	seeded, err := s.definitionsRepository.SeededKinds(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !seeded[constants.DefinitionKindBoard] {
+		definitions := make([]models.LeaderboardDefinition, 0, len(s.config.Boards))
+		for _, board := range s.config.Boards {
+			definitions = append(definitions, models.LeaderboardDefinition{Board: board})
+		}
+
+		if err := s.definitionsRepository.Seed(ctx, constants.DefinitionKindBoard, definitions); err != nil {
+			return err
+		}
+		log.Printf("[INFO] leaderboard definitions seeded | boards=%d", len(definitions))
+	}
+
+	if !seeded[constants.DefinitionKindTeam] {
+		definitions := make([]models.LeaderboardDefinition, 0, len(s.config.TeamBoards))
+		for _, board := range s.config.TeamBoards {
+			definitions = append(definitions, teamDefinition(board))
+		}
+
+		if err := s.definitionsRepository.Seed(ctx, constants.DefinitionKindTeam, definitions); err != nil {
+			return err
+		}
+		log.Printf("[INFO] team board definitions seeded | team_boards=%d", len(definitions))
+	}
+
+	return nil
+}
+
+// reloadBoards picks up a definition changed on this instance at once rather than on the next
+// periodic reload
+func (s *LeaderboardService) reloadBoards(ctx context.Context) {
+	if err := s.loadBoards(ctx); err != nil {
+		log.Printf("[WARN] leaderboard definitions reload failed | err=%v", err)
+		if txn := newrelic.FromContext(ctx); txn != nil {
+			txn.NoticeError(err)
+		}
+	}
+}
+
+// DefinitionsService manages the leaderboard definitions through the admin API. A changed
+// definition is served on this instance at once, and the worker asked to rank it.
+type DefinitionsService struct {
+	leaderboardService *LeaderboardService
+	leaderboardWorker  *LeaderboardWorker
+}
+
+func NewDefinitionsService(
+	leaderboardService *LeaderboardService,
+	leaderboardWorker *LeaderboardWorker,
+) *DefinitionsService {
+	return &DefinitionsService{
+		leaderboardService: leaderboardService,
+		leaderboardWorker:  leaderboardWorker,
+	}
+}
+
+// ListDefinitions returns every leaderboard definition, the first of each kind is the default
+// board or team board
+func (d *DefinitionsService) ListDefinitions(ctx context.Context) ([]models.LeaderboardDefinition, apperror.Error) {
+	definitions, err := d.leaderboardService.definitionsRepository.All(ctx)
+	if err != nil {
+		return nil, apperror.New(fmt.Errorf("unable to list boards"), http.StatusInternalServerError)
+	}
+
+	return definitions, apperror.Error{}
+}
+
+// GetDefinition returns one leaderboard definition
+func (d *DefinitionsService) GetDefinition(ctx context.Context, definitionID int) (models.LeaderboardDefinition, apperror.Error) {
+	definitions, cusErr := d.leaderboardService.definitionsRepository.GetAll(ctx, map[string]interface{}{"id": definitionID})
+	if cusErr.Exists() {
+		return models.LeaderboardDefinition{}, apperror.New(fmt.Errorf("unable to get board"), http.StatusInternalServerError)
+	}
+
+	if len(definitions) == 0 {
+		return models.LeaderboardDefinition{}, apperror.New(fmt.Errorf("board %d not found", definitionID), http.StatusNotFound)
+	}
+
+	return *definitions[0], apperror.Error{}
+}
+
+// CreateDefinition stores a new board or team board, which the worker ranks from every
+// recorded session on its next run
+func (d *DefinitionsService) CreateDefinition(
+	ctx context.Context,
+	req request.CreateLeaderboardDefinitionRequest,
+) (models.LeaderboardDefinition, apperror.Error) {
+	kind := req.Kind
+	if kind == "" {
+		kind = constants.DefinitionKindBoard
+	}
+
+	definition := newDefinition(kind, req.Name, req.LeaderboardDefinitionRequest)
+	if err := validateDefinition(&definition); err != nil {
+		return models.LeaderboardDefinition{}, apperror.New(fmt.Errorf("invalid board: %w", err), http.StatusBadRequest)
+	}
+
+	created, err := d.leaderboardService.definitionsRepository.CreateUnique(ctx, &definition)
+	if err != nil {
+		return models.LeaderboardDefinition{}, apperror.New(fmt.Errorf("unable to create board"), http.StatusInternalServerError)
+	}
+
+	if !created {
+		return models.LeaderboardDefinition{}, apperror.New(fmt.Errorf("board %q already exists", definition.Name), http.StatusConflict)
+	}
+
+	d.leaderboardService.reloadBoards(ctx)
+
+	if definition.Kind == constants.DefinitionKindTeam {
+		// Team boards are otherwise only ranked on the reconcile cadence
+		if err := d.leaderboardWorker.RequestFullRecalculation(ctx); err != nil {
+			log.Printf("[WARN] full recalculation not requested | board=%s | err=%v", definition.Name, err)
+		}
+	} else if err := d.leaderboardWorker.MarkPending(ctx); err != nil {
+		// A board without a watermark is recalculated in full whenever it was last reconciled, so
+		// the next run needs no more than that
+		log.Printf("[WARN] new board not marked pending | board=%s | err=%v", definition.Name, err)
+	}
+
+	log.Printf("[INFO] board created | definition_id=%d | board=%s", definition.ID, definition.Name)
+	return definition, apperror.Error{}
+}
+
+// UpdateDefinition replaces how a board or team board ranks. Its standings were ranked the old
+// way, so every board is recalculated in full on the worker's next run.
+// Lists cached from the board are keyed by its definition's version, so none ranked the old way
+// are served once the definitions are reloaded.
+func (d *DefinitionsService) UpdateDefinition(
+	ctx context.Context,
+	definitionID int,
+	req request.LeaderboardDefinitionRequest,
+) (models.LeaderboardDefinition, apperror.Error) {
+	current, cusErr := d.GetDefinition(ctx, definitionID)
+	if cusErr.Exists() {
+		return models.LeaderboardDefinition{}, cusErr
+	}
+
+	definition := newDefinition(current.Kind, current.Name, req)
+	if err := validateDefinition(&definition); err != nil {
+		return models.LeaderboardDefinition{}, apperror.New(fmt.Errorf("invalid board: %w", err), http.StatusBadRequest)
+	}
+
+	saved, found, err := d.leaderboardService.definitionsRepository.Update(ctx, definitionID, definition)
+	if err != nil {
+		return models.LeaderboardDefinition{}, apperror.New(fmt.Errorf("unable to update board"), http.StatusInternalServerError)
+	}
+
+	if !found {
+		return models.LeaderboardDefinition{}, apperror.New(fmt.Errorf("board %d not found", definitionID), http.StatusNotFound)
+	}
+
+	d.leaderboardService.reloadBoards(ctx)
+
+	if err := d.leaderboardWorker.RequestFullRecalculation(ctx); err != nil {
+		log.Printf("[WARN] full recalculation not requested | board=%s | err=%v", saved.Name, err)
+	}
+
+	log.Printf("[INFO] board updated | definition_id=%d | board=%s", saved.ID, saved.Name)
+	return saved, apperror.Error{}
+}
+
+// DeleteDefinition stops ranking a board or team board. Its standings are deleted along with it
+// and its real-time rankings dropped at once, anything a run in progress still writes for it is
+// purged on the worker's next run. The last board cannot be deleted, as there would then be no
+// default board, while every team board can.
+func (d *DefinitionsService) DeleteDefinition(ctx context.Context, definitionID int) (models.LeaderboardDefinition, apperror.Error) {
+	definitions, cusErr := d.ListDefinitions(ctx)
+	if cusErr.Exists() {
+		return models.LeaderboardDefinition{}, cusErr
+	}
+
+	boards := make([]models.LeaderboardDefinition, 0, len(definitions))
+	for _, definition := range definitions {
+		if definition.Kind != constants.DefinitionKindTeam {
+			boards = append(boards, definition)
+		}
+	}
+
+	if len(boards) == 1 && boards[0].ID == definitionID {
+		return models.LeaderboardDefinition{}, apperror.New(
+			fmt.Errorf("board %s is the last board and cannot be deleted", boards[0].Name),
+			http.StatusConflict,
+		)
+	}
+
+	deleted, found, err := d.leaderboardService.definitionsRepository.Delete(ctx, definitionID)
+	if err != nil {
+		return models.LeaderboardDefinition{}, apperror.New(fmt.Errorf("unable to delete board"), http.StatusInternalServerError)
+	}
+
+	if !found {
+		return models.LeaderboardDefinition{}, apperror.New(fmt.Errorf("board %d not found", definitionID), http.StatusNotFound)
+	}
+
+	d.leaderboardService.reloadBoards(ctx)
+	if deleted.Kind == constants.DefinitionKindTeam {
+		d.leaderboardService.dropTeamRankings(ctx, deleted.TeamBoard())
+	} else {
+		d.leaderboardService.dropRankings(ctx, deleted.Board)
+	}
+
+	if err := d.leaderboardWorker.MarkPending(ctx); err != nil {
+		log.Printf("[WARN] deleted board not marked pending | board=%s | err=%v", deleted.Name, err)
+	}
+
+	log.Printf("[INFO] board deleted | definition_id=%d | board=%s", deleted.ID, deleted.Name)
+	return deleted, apperror.Error{}
+}
+
+// dropRankings drops the real-time rankings and cached top list of a board's current periods
+func (s *LeaderboardService) dropRankings(ctx context.Context, board models.Board) {
+	periods, err := s.currentPeriods(ctx, time.Now())
+	if err != nil {
+		log.Printf("[WARN] board rankings not dropped | board=%s | err=%v", board.Name, err)
+		return
+	}
+
+	keys := make([]string, 0)
+	for _, period := range periods {
+		scope := Scope{Board: board, Period: period}
+		keys = append(keys, keysFor(scope).all()...)
+		keys = append(keys, fmt.Sprintf(constants.LeaderboardTopKeyFormat, scope.versioned(), board.SizeCap))
+	}
+
+	if _, err := s.redisClient.Unlink(ctx, keys); err != nil {
+		log.Printf("[WARN] board rankings not dropped | board=%s | err=%v", board.Name, err)
+		if txn := newrelic.FromContext(ctx); txn != nil {
+			txn.NoticeError(err)
+		}
+	}
+}
+
+// dropTeamRankings drops the cached top lists of a team board's current periods
+func (s *LeaderboardService) dropTeamRankings(ctx context.Context, board models.TeamBoard) {
+	periods, err := s.currentPeriods(ctx, time.Now())
+	if err != nil {
+		log.Printf("[WARN] team board rankings not dropped | board=%s | err=%v", board.Name, err)
+		return
+	}
+
+	scopes := make([]TeamScope, 0, len(periods))
+	for _, period := range periods {
+		scopes = append(scopes, TeamScope{Board: board, Period: period})
+	}
+
+	if err := s.InvalidateTeamTopCache(ctx, scopes); err != nil {
+		log.Printf("[WARN] team board rankings not dropped | board=%s | err=%v", board.Name, err)
+	}
+}
+
+// validateDefinition checks a board as ValidateBoards does, or a team board as
+// ValidateTeamBoards does, filling in its defaults. Names are kept apart by the definitions
+// table, which holds both kinds.
+func validateDefinition(definition *models.LeaderboardDefinition) error {
+	if definition.Kind != constants.DefinitionKindTeam {
+		if definition.Split != "" || definition.Members != 0 {
+			return fmt.Errorf("board %q cannot set a split or members, only team boards do", definition.Name)
+		}
+
+		boards := []models.Board{definition.Board}
+		if err := ValidateBoards(boards); err != nil {
+			return err
+		}
+
+		definition.Board = boards[0]
+		return nil
+	}
+
+	board := definition.Board
+	if board.GameMode != constants.GameModeTeam {
+		return fmt.Errorf("team board %q must count %s sessions", board.Name, constants.GameModeTeam)
+	}
+
+	if len(board.Windows) > 0 || board.TieBreaker != "" || board.SortDirection != "" ||
+		board.RefreshSeconds != 0 || board.CacheSeconds != 0 || board.TierBasis != "" || len(board.Tiers) > 0 {
+		return fmt.Errorf("team board %q only sets an aggregation, sessions, split, members, rank type and size cap", board.Name)
+	}
+
+	teamBoards := []models.TeamBoard{definition.TeamBoard()}
+	if err := ValidateTeamBoards(teamBoards, nil); err != nil {
+		return err
+	}
+
+	*definition = teamDefinition(teamBoards[0])
+	return nil
+}
+
+// teamDefinition is the definition a team board is stored as
+func teamDefinition(board models.TeamBoard) models.LeaderboardDefinition {
+	return models.LeaderboardDefinition{
+		Kind: constants.DefinitionKindTeam,
+		Board: models.Board{
+			Name:        board.Name,
+			GameMode:    constants.GameModeTeam,
+			Aggregation: board.Aggregation,
+			Sessions:    board.Sessions,
+			RankType:    board.RankType,
+			SizeCap:     board.SizeCap,
+		},
+		Split:   board.Split,
+		Members: board.Members,
+	}
+}
+
+// newDefinition is the definition of kind a definition request declares
+func newDefinition(kind string, name string, req request.LeaderboardDefinitionRequest) models.LeaderboardDefinition {
+	board := models.Board{
+		Name:           name,
+		GameMode:       req.GameMode,
+		Windows:        req.Windows,
+		Aggregation:    req.Aggregation,
+		Sessions:       req.Sessions,
+		RankType:       req.RankType,
+		TieBreaker:     req.TieBreaker,
+		SortDirection:  req.SortDirection,
+		SizeCap:        req.SizeCap,
+		RefreshSeconds: req.RefreshSeconds,
+		CacheSeconds:   req.CacheSeconds,
+		TierBasis:      req.TierBasis,
+		Tiers:          req.Tiers,
+	}
+
+	return models.LeaderboardDefinition{
+		Kind:    kind,
+		Board:   board,
+		Split:   req.Split,
+		Members: req.Members,
+	}
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/models"
+)
+
+func TestEditedDefinitionChangesCacheKeys(t *testing.T) {
+	created := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	period := PeriodAt(constants.WindowDaily, created, time.UTC)
+
+	tests := []struct {
+		name       string
+		definition models.LeaderboardDefinition
+		key        func(boards []models.Board, teamBoards []models.TeamBoard) string
+	}{
+		{
+			name: "board top list",
+			definition: models.LeaderboardDefinition{
+				Kind:  constants.DefinitionKindBoard,
+				Board: models.Board{Name: "global", GameMode: constants.GameModeSolo, SizeCap: 100},
+			},
+			key: func(boards []models.Board, _ []models.TeamBoard) string {
+				scope := Scope{Board: boards[0], Period: period}
+				return fmt.Sprintf(constants.LeaderboardTopKeyFormat, scope.versioned(), scope.Board.SizeCap)
+			},
+		},
+		{
+			name: "board user standing",
+			definition: models.LeaderboardDefinition{
+				Kind:  constants.DefinitionKindBoard,
+				Board: models.Board{Name: "global", GameMode: constants.GameModeSolo, SizeCap: 100},
+			},
+			key: func(boards []models.Board, _ []models.TeamBoard) string {
+				scope := Scope{Board: boards[0], Period: period}
+				return fmt.Sprintf(constants.LeaderboardUserKeyFormat, scope.versioned(), "7")
+			},
+		},
+		{
+			name: "team board top list",
+			definition: models.LeaderboardDefinition{
+				Kind:  constants.DefinitionKindTeam,
+				Board: models.Board{Name: "squads", GameMode: constants.GameModeTeam, SizeCap: 50},
+			},
+			key: func(_ []models.Board, teamBoards []models.TeamBoard) string {
+				scope := TeamScope{Board: teamBoards[0], Period: period}
+				return fmt.Sprintf(constants.TeamTopKeyFormat, scope.versioned(), scope.Board.SizeCap)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := tt.definition
+			before.UpdatedAt = created
+
+			// An edit stores the definition again, moving its updated_at on
+			after := before
+			after.UpdatedAt = created.Add(time.Microsecond)
+
+			beforeKey := tt.key(boardsOf([]models.LeaderboardDefinition{before}))
+			afterKey := tt.key(boardsOf([]models.LeaderboardDefinition{after}))
+			if beforeKey == afterKey {
+				t.Errorf("cached before and after the edit under the same key %q", beforeKey)
+			}
+
+			againKey := tt.key(boardsOf([]models.LeaderboardDefinition{after}))
+			if againKey != afterKey {
+				t.Errorf("the same definition is cached under %q and %q", afterKey, againKey)
+			}
+		})
+	}
+}
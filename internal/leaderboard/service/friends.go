@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/leaderboard/repository"
+	"gaming-leaderboard/internal/models"
+	"gaming-leaderboard/pkg/apperror"
+	oredis "gaming-leaderboard/pkg/redis"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+// GetFriendsLeaderboard ranks a user and their friend set by the scores the board holds for
+// them, so friends are compared with the board's own aggregation. Entries' ranks are their
+// places among friends, numbered with the board's rank type, and friends not on the board are
+// left out.
+func (s *LeaderboardService) GetFriendsLeaderboard(
+	ctx context.Context,
+	userID int,
+	scope Scope,
+) (models.LeaderboardSlice, apperror.Error) {
+	existing, err := s.usersRepository.Existing(ctx, []int{userID})
+	if err != nil {
+		return nil, apperror.New(fmt.Errorf("unable to get friends leaderboard"), http.StatusInternalServerError)
+	}
+
+	if !existing[userID] {
+		return nil, apperror.New(fmt.Errorf("user %d not found", userID), http.StatusNotFound)
+	}
+
+	friends, cusErr := s.friendsStandings(ctx, userID, scope)
+	if cusErr.Exists() {
+		return nil, cusErr
+	}
+
+	// A shadow-banned user still sees themselves among their friends
+	self := false
+	for _, entry := range friends {
+		self = self || entry.UserID == userID
+	}
+	if !self {
+		if shadow, found := s.shadowStanding(ctx, strconv.Itoa(userID), scope); found {
+			friends = append(friends, &shadow)
+		}
+	}
+
+	rankAmong(scope.Board, friends)
+	s.withProfiles(ctx, friends)
+	return shownScores(scope.Board, friends), apperror.Error{}
+}
+
+// friendsStandings reads the standings of a user and their friend set from the real-time
+// ranking in one pipeline, falling back to the cached Postgres leaderboard in one query when
+// none of them is ranked there
+func (s *LeaderboardService) friendsStandings(
+	ctx context.Context,
+	userID int,
+	scope Scope,
+) (models.LeaderboardSlice, apperror.Error) {
+
+	txn := newrelic.FromContext(ctx)
+
+	friendIDs, err := s.friendshipsRepository.FriendIDs(ctx, userID)
+	if err != nil {
+		if txn != nil {
+			txn.NoticeError(err)
+		}
+		return nil, apperror.New(fmt.Errorf("unable to get friends leaderboard"), http.StatusInternalServerError)
+	}
+
+	// Real-time ranking lookup
+	ranked, found, err := s.getFriendsFromRankings(ctx, scope, append(friendIDs, userID))
+	if err == nil && found {
+		if txn != nil {
+			txn.AddAttribute("ranking_hit", true)
+		}
+		return ranked, apperror.Error{}
+	}
+
+	if err != nil {
+		log.Printf("[WARN] friends ranking read failed | user_id=%d | err=%v", userID, err)
+		if txn != nil {
+			txn.NoticeError(err)
+		}
+	}
+
+	// DB fetch
+	leaders, cusErr := s.repository.GetAll(ctx, scopeFilter(scope), repository.AmongFriends(userID))
+	if cusErr.Exists() {
+		if txn != nil {
+			txn.NoticeError(cusErr)
+		}
+		return nil, cusErr
+	}
+
+	return leaders, apperror.Error{}
+}
+
+// getFriendsFromRankings reads the scores of the given users from the sorted set, found is
+// false when none of them is ranked there
+func (s *LeaderboardService) getFriendsFromRankings(
+	ctx context.Context,
+	scope Scope,
+	userIDs []int,
+) (entries models.LeaderboardSlice, found bool, err error) {
+	ids := make([]string, 0, len(userIDs))
+	for _, id := range userIDs {
+		ids = append(ids, strconv.Itoa(id))
+	}
+
+	scores, err := s.redisClient.PipedZScore(ctx, keysFor(scope).ranking, ids)
+	if err != nil || len(scores) == 0 {
+		return nil, false, err
+	}
+
+	members := make([]oredis.ZMember, 0, len(scores))
+	for _, id := range ids {
+		if score, ok := scores[id]; ok {
+			members = append(members, oredis.ZMember{Member: id, Score: score})
+		}
+	}
+
+	entries, err = s.rankingEntries(ctx, scope, members)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return entries, true, nil
+}
+
+// rankAmong puts entries in board order and numbers them from 1 as the board's rank type would
+func rankAmong(board models.Board, entries models.LeaderboardSlice) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return ranksBefore(board, entries[i], entries[j])
+	})
+
+	for i, rank := range ranksAmong(board, entries) {
+		entries[i].Rank = rank
+	}
+}
+
+// ranksAmong numbers entries already in board order from 1 as the board's rank type would
+func ranksAmong(board models.Board, entries models.LeaderboardSlice) []int {
+	ranks := make([]int, len(entries))
+	for i, entry := range entries {
+		if i == 0 {
+			ranks[i] = 1
+			continue
+		}
+
+		switch {
+		case board.RankType == constants.RankOrdinal:
+			ranks[i] = i + 1
+		case entry.TotalScore == entries[i-1].TotalScore:
+			ranks[i] = ranks[i-1]
+		case board.RankType == constants.RankDense:
+			ranks[i] = ranks[i-1] + 1
+		default:
+			ranks[i] = i + 1
+		}
+	}
+
+	return ranks
+}
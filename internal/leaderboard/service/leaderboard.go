@@ -4,10 +4,16 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
 
 	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/controller/request"
 	"gaming-leaderboard/internal/leaderboard/repository"
 	"gaming-leaderboard/internal/models"
+	usersRepo "gaming-leaderboard/internal/users/repository"
 	"gaming-leaderboard/pkg/apperror"
 	oredis "gaming-leaderboard/pkg/redis"
 
@@ -15,35 +21,273 @@ import (
 	"gorm.io/gorm"
 )
 
+// Config controls how boards are ranked and how long windowed periods stay queryable
+type Config struct {
+	// Boards seed the leaderboard definitions while the game has none, the definitions decide
+	// which boards are ranked from then on
+	Boards []models.Board
+	// TeamBoards seed the team board definitions once, as Boards do the boards'. Team boards
+	// rank teams from their members' team-mode sessions.
+	TeamBoards []models.TeamBoard
+	// Location is the timezone whose midnight bounds daily, weekly and monthly windows
+	Location *time.Location
+	// Retention is how long a window period remains queryable after it ends
+	Retention map[string]time.Duration
+	// HistoryRetention is how long daily rank snapshots are kept, zero keeps them forever
+	HistoryRetention time.Duration
+	// ReconcileInterval is how often the worker recalculates a board in full rather than folding
+	// in only new sessions, unless the board sets its own refresh interval. Zero always
+	// recalculates in full.
+	ReconcileInterval time.Duration
+}
+
 type LeaderboardService struct {
-	repository  *repository.LeaderboardRepository
-	redisClient oredis.Cache
+	repository                *repository.LeaderboardRepository
+	seasonsRepository         *repository.SeasonsRepository
+	seasonStandingsRepository *repository.SeasonStandingsRepository
+	rankHistoryRepository     *repository.RankHistoryRepository
+	bansRepository            *repository.BansRepository
+	usersRepository           *usersRepo.UsersRepository
+	friendshipsRepository     *usersRepo.FriendshipsRepository
+	teamLeaderboardRepository *repository.TeamLeaderboardRepository
+	definitionsRepository     *repository.DefinitionsRepository
+	redisClient               oredis.Cache
+	config                    Config
+
+	// boards and teamBoards are the leaderboard definitions as last loaded, replaced whole on
+	// every reload
+	boardsMu   sync.RWMutex
+	boards     []models.Board
+	teamBoards []models.TeamBoard
 }
 
 func NewLeaderboardService(
 	repository *repository.LeaderboardRepository,
+	seasonsRepository *repository.SeasonsRepository,
+	seasonStandingsRepository *repository.SeasonStandingsRepository,
+	rankHistoryRepository *repository.RankHistoryRepository,
+	bansRepository *repository.BansRepository,
+	usersRepository *usersRepo.UsersRepository,
+	friendshipsRepository *usersRepo.FriendshipsRepository,
+	teamLeaderboardRepository *repository.TeamLeaderboardRepository,
+	definitionsRepository *repository.DefinitionsRepository,
 	redisClient oredis.Cache,
+	config Config,
 ) *LeaderboardService {
 	return &LeaderboardService{
-		repository:  repository,
-		redisClient: redisClient,
+		repository:                repository,
+		seasonsRepository:         seasonsRepository,
+		seasonStandingsRepository: seasonStandingsRepository,
+		rankHistoryRepository:     rankHistoryRepository,
+		bansRepository:            bansRepository,
+		usersRepository:           usersRepository,
+		friendshipsRepository:     friendshipsRepository,
+		teamLeaderboardRepository: teamLeaderboardRepository,
+		definitionsRepository:     definitionsRepository,
+		redisClient:               redisClient,
+		config:                    config,
+	}
+}
+
+// Windows lists every time window boards are ranked over
+func (s *LeaderboardService) Windows() []string {
+	return []string{
+		constants.WindowDaily,
+		constants.WindowWeekly,
+		constants.WindowMonthly,
+		constants.WindowAllTime,
+	}
+}
+
+// ResolveScope validates the requested board, defaulting to the current all-time default board
+func (s *LeaderboardService) ResolveScope(ctx context.Context, query request.LeaderboardQuery) (Scope, apperror.Error) {
+	board, cusErr := s.resolveBoard(query.Board, query.Mode)
+	if cusErr.Exists() {
+		return Scope{}, cusErr
+	}
+
+	period, cusErr := s.resolvePeriod(ctx, query.Window, query.Period)
+	if cusErr.Exists() {
+		return Scope{}, cusErr
+	}
+
+	if !rankedOver(board, period.Window) {
+		return Scope{}, apperror.New(
+			fmt.Errorf("board %s is not ranked over the %s window", board.Name, period.Window),
+			http.StatusNotFound,
+		)
+	}
+
+	return Scope{Board: board, Period: period}, apperror.Error{}
+}
+
+// resolvePeriod finds the period of window containing the day date names, defaulting to the
+// current all-time period. Periods past their retention are not found.
+func (s *LeaderboardService) resolvePeriod(ctx context.Context, window string, date string) (Period, apperror.Error) {
+	if window == "" {
+		window = constants.WindowAllTime
+	}
+
+	at := time.Now()
+	if date != "" {
+		day, err := time.ParseInLocation(time.DateOnly, date, s.config.Location)
+		if err != nil {
+			return Period{}, apperror.New(fmt.Errorf("invalid period: %w", err), http.StatusBadRequest)
+		}
+		at = day
+	}
+
+	// The live all-time board is the current season's
+	if window == constants.WindowAllTime {
+		boundary, err := s.seasonBoundary(ctx)
+		if err != nil {
+			return Period{}, apperror.New(err, http.StatusInternalServerError)
+		}
+		return s.allTimePeriod(boundary), apperror.Error{}
+	}
+
+	period := PeriodAt(window, at, s.config.Location)
+	if period.Bounded() && period.End.Add(s.config.Retention[window]).Before(time.Now()) {
+		return Period{}, apperror.New(
+			fmt.Errorf("%s period %s is no longer retained", window, period.Label()),
+			http.StatusNotFound,
+		)
+	}
+
+	return period, apperror.Error{}
+}
+
+// currentPeriods returns the period of every window containing t, in Windows order.
+// The all-time period starts at the current season boundary rather than the epoch.
+func (s *LeaderboardService) currentPeriods(ctx context.Context, t time.Time) ([]Period, error) {
+	boundary, err := s.seasonBoundary(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	periods := make([]Period, 0, len(s.Windows()))
+	for _, window := range s.Windows() {
+		if window == constants.WindowAllTime {
+			periods = append(periods, s.allTimePeriod(boundary))
+			continue
+		}
+		periods = append(periods, PeriodAt(window, t, s.config.Location))
+	}
+
+	return periods, nil
+}
+
+// allTimePeriod is the all-time period counting from a season boundary, the epoch when there is none
+func (s *LeaderboardService) allTimePeriod(boundary time.Time) Period {
+	period := PeriodAt(constants.WindowAllTime, boundary, s.config.Location)
+	if !boundary.IsZero() {
+		period.Start = boundary.In(s.config.Location)
+	}
+
+	return period
+}
+
+// sessionScopes lists the boards a session of the given mode played at t contributes to
+func (s *LeaderboardService) sessionScopes(ctx context.Context, gameMode string, t time.Time) ([]Scope, error) {
+	periods, err := s.currentPeriods(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+
+	boards := s.sessionBoards(gameMode)
+
+	scopes := make([]Scope, 0, len(boards)*len(periods))
+	for _, board := range boards {
+		for _, period := range periods {
+			if rankedOver(board, period.Window) {
+				scopes = append(scopes, Scope{Board: board, Period: period})
+			}
+		}
+	}
+
+	return scopes, nil
+}
+
+// periodScopes lists every board ranked over the given periods
+func (s *LeaderboardService) periodScopes(periods []Period) []Scope {
+	scopes := make([]Scope, 0)
+	for _, period := range periods {
+		for _, board := range s.boardsOver(period.Window) {
+			scopes = append(scopes, Scope{Board: board, Period: period})
+		}
+	}
+
+	return scopes
+}
+
+// expiresAt is when the cached ranking of a period can be dropped, zero for all-time
+func (s *LeaderboardService) expiresAt(period Period) time.Time {
+	if !period.Bounded() {
+		return time.Time{}
+	}
+
+	return period.End.Add(s.config.Retention[period.Window])
+}
+
+// scopeFilter selects the durable leaderboard rows of a board
+func scopeFilter(scope Scope) map[string]interface{} {
+	return map[string]interface{}{
+		constants.Board:       scope.Board.Name,
+		constants.TimeWindow:  scope.Period.Window,
+		constants.PeriodStart: scope.Period.Start.UTC(),
 	}
 }
 
-// GetTopLeaderboards retrieves top leaderboards with caching
+// GetTopLeaderboards retrieves top leaderboards with each entry's user profile and how its rank
+// moved since the last recorded day
 func (s *LeaderboardService) GetTopLeaderboards(
 	ctx context.Context,
+	scope Scope,
+) (models.LeaderboardSlice, apperror.Error) {
+	leaders, cusErr := s.topLeaderboards(ctx, scope)
+	if cusErr.Exists() {
+		return nil, cusErr
+	}
+
+	s.withProfiles(ctx, leaders)
+	s.withRankDeltas(ctx, scope, leaders)
+	return shownScores(scope.Board, leaders), apperror.Error{}
+}
+
+// topLeaderboards retrieves the board's top list, as long as its size cap, from the real-time
+// ranking, falling back to the cached Postgres leaderboard when the ranking is unavailable
+func (s *LeaderboardService) topLeaderboards(
+	ctx context.Context,
+	scope Scope,
 ) (models.LeaderboardSlice, apperror.Error) {
 
 	txn := newrelic.FromContext(ctx)
+
+	// Real-time ranking lookup
+	ranked, found, err := s.getTopFromRankings(ctx, scope, scope.Board.SizeCap)
+	if err == nil && found {
+		if txn != nil {
+			txn.AddAttribute("ranking_hit", true)
+		}
+		return ranked, apperror.Error{}
+	}
+
+	if err != nil {
+		log.Printf("[WARN] leaderboard ranking read failed | err=%v", err)
+		if txn != nil {
+			txn.NoticeError(err)
+		}
+	}
+
 	cacheKey := fmt.Sprintf(
 		constants.LeaderboardTopKeyFormat,
-		constants.TopLeaderboardLimit,
+		scope.versioned(),
+		scope.Board.SizeCap,
 	)
 
 	// Cache lookup
 	var cachedLeaders models.LeaderboardSlice
-	found, err := s.redisClient.Get(ctx, cacheKey, &cachedLeaders)
+	found, err = s.redisClient.Get(ctx, cacheKey, &cachedLeaders)
 	if err == nil && found {
 		log.Println("[INFO] leaderboard top fetched from cache")
 
@@ -61,8 +305,8 @@ func (s *LeaderboardService) GetTopLeaderboards(
 		}
 	}
 
-	leaders, cusErr := s.repository.GetAll(ctx, nil, func(db *gorm.DB) *gorm.DB {
-		return db.Order("rank ASC").Limit(constants.TopLeaderboardLimit)
+	leaders, cusErr := s.repository.GetAll(ctx, scopeFilter(scope), func(db *gorm.DB) *gorm.DB {
+		return db.Order("rank ASC, " + repository.TieBreakOrder(scope.Board)).Limit(scope.Board.SizeCap)
 	})
 	if cusErr.Exists() {
 		if txn != nil {
@@ -71,8 +315,9 @@ func (s *LeaderboardService) GetTopLeaderboards(
 		return nil, cusErr
 	}
 
-	// Cache set (non-blocking)
-	if _, err := s.redisClient.Set(ctx, cacheKey, leaders, constants.OneHour); err != nil {
+	// Cache set (non-blocking), without profiles: GetTopLeaderboards fills them in on every read
+	// so a profile change shows without waiting for the list to expire
+	if _, err := s.redisClient.Set(ctx, cacheKey, leaders, cacheTTL(scope.Board, constants.OneHour)); err != nil {
 		log.Printf("[WARN] leaderboard cache set failed | err=%v", err)
 		if txn != nil {
 			txn.NoticeError(err)
@@ -82,21 +327,164 @@ func (s *LeaderboardService) GetTopLeaderboards(
 	return leaders, apperror.Error{}
 }
 
-// GetUserRankByUserID retrieves user rank with caching
+// GetLeaderboardPage retrieves one page of the full Postgres leaderboard in board order,
+// starting after cursor (from the top when empty). The returned cursor is empty on the last page.
+func (s *LeaderboardService) GetLeaderboardPage(
+	ctx context.Context,
+	scope Scope,
+	limit int,
+	cursor string,
+) (models.LeaderboardSlice, string, apperror.Error) {
+
+	scopes := make([]func(db *gorm.DB) *gorm.DB, 0, 2)
+	if cursor != "" {
+		after, cusErr := DecodeCursor(cursor)
+		if cusErr.Exists() {
+			return nil, "", cusErr
+		}
+		scopes = append(scopes, repository.Seek(scope.Board, after.Rank, after.ReachedAt, after.UserID))
+	}
+
+	// One extra row tells whether another page follows
+	scopes = append(scopes, func(db *gorm.DB) *gorm.DB {
+		return db.Order("rank ASC, " + repository.TieBreakOrder(scope.Board)).Limit(limit + 1)
+	})
+
+	leaders, cusErr := s.repository.GetAll(ctx, scopeFilter(scope), scopes...)
+	if cusErr.Exists() {
+		if txn := newrelic.FromContext(ctx); txn != nil {
+			txn.NoticeError(cusErr)
+		}
+		return nil, "", cusErr
+	}
+
+	s.withProfiles(ctx, leaders)
+
+	if len(leaders) <= limit {
+		return shownScores(scope.Board, leaders), "", apperror.Error{}
+	}
+
+	leaders = leaders[:limit]
+	return shownScores(scope.Board, leaders), cursorAfter(leaders[len(leaders)-1]).Encode(), apperror.Error{}
+}
+
+// GetUserRankByUserID retrieves user rank with the user's profile, how the rank moved since the
+// last recorded day and the rank among the users from the user's country
 func (s *LeaderboardService) GetUserRankByUserID(
 	ctx context.Context,
 	userID string,
+	scope Scope,
+) (models.Leaderboard, apperror.Error) {
+	leader, cusErr := s.rankedUser(ctx, userID, scope)
+	if cusErr.Exists() {
+		return models.Leaderboard{}, cusErr
+	}
+
+	leader.TotalScore = rankedScore(scope.Board, leader.TotalScore)
+	return leader, apperror.Error{}
+}
+
+// rankedUser is GetUserRankByUserID with the user's score left as the board ranks it
+func (s *LeaderboardService) rankedUser(
+	ctx context.Context,
+	userID string,
+	scope Scope,
+) (models.Leaderboard, apperror.Error) {
+	leader, cusErr := s.userRank(ctx, userID, scope)
+	if cusErr.Exists() {
+		shadow, found := s.shadowStanding(ctx, userID, scope)
+		if !found {
+			return models.Leaderboard{}, cusErr
+		}
+		leader = shadow
+	}
+
+	s.withProfiles(ctx, models.LeaderboardSlice{&leader})
+	s.withRankDeltas(ctx, scope, models.LeaderboardSlice{&leader})
+	s.withRegionalRank(ctx, scope, &leader)
+	return leader, apperror.Error{}
+}
+
+// shadowStanding returns the standing a shadow-banned user is shown in place of the one their
+// ban keeps off the board, found is false for every other user
+func (s *LeaderboardService) shadowStanding(
+	ctx context.Context,
+	userID string,
+	scope Scope,
+) (models.Leaderboard, bool) {
+	id, err := strconv.Atoi(userID)
+	if err != nil {
+		return models.Leaderboard{}, false
+	}
+
+	bans, err := s.bansRepository.Bans(ctx, []int{id})
+	if err != nil {
+		log.Printf("[WARN] ban lookup failed | user_id=%s | err=%v", userID, err)
+		return models.Leaderboard{}, false
+	}
+
+	if bans[id].Kind != constants.BanKindShadow {
+		return models.Leaderboard{}, false
+	}
+
+	standing, found, err := s.repository.ShadowStanding(
+		ctx,
+		scope.Board,
+		scope.Period.Window,
+		scope.Period.Start,
+		scope.Period.End,
+		id,
+	)
+	if err != nil {
+		if txn := newrelic.FromContext(ctx); txn != nil {
+			txn.NoticeError(err)
+		}
+		return models.Leaderboard{}, false
+	}
+
+	return standing, found
+}
+
+// userRank retrieves user rank from the real-time ranking,
+// falling back to the cached Postgres leaderboard when the user is not ranked there
+func (s *LeaderboardService) userRank(
+	ctx context.Context,
+	userID string,
+	scope Scope,
 ) (models.Leaderboard, apperror.Error) {
 
 	txn := newrelic.FromContext(ctx)
+
+	// Real-time ranking lookup
+	ranked, found, err := s.getUserFromRankings(ctx, scope, userID)
+	if err == nil && found {
+		if txn != nil {
+			txn.AddAttribute("ranking_hit", true)
+			txn.AddAttribute("user_id", userID)
+		}
+		return ranked, apperror.Error{}
+	}
+
+	if err != nil {
+		log.Printf(
+			"[WARN] user ranking read failed | user_id=%s | err=%v",
+			userID,
+			err,
+		)
+		if txn != nil {
+			txn.NoticeError(err)
+		}
+	}
+
 	cacheKey := fmt.Sprintf(
 		constants.LeaderboardUserKeyFormat,
+		scope.versioned(),
 		userID,
 	)
 
 	// Cache lookup
 	var cachedLeader models.Leaderboard
-	found, err := s.redisClient.Get(ctx, cacheKey, &cachedLeader)
+	found, err = s.redisClient.Get(ctx, cacheKey, &cachedLeader)
 	if err == nil && found {
 		if txn != nil {
 			txn.AddAttribute("cache_hit", true)
@@ -117,9 +505,8 @@ func (s *LeaderboardService) GetUserRankByUserID(
 	}
 
 	// DB fetch
-	filter := map[string]interface{}{
-		constants.UserID: userID,
-	}
+	filter := scopeFilter(scope)
+	filter[constants.UserID] = userID
 
 	leader, cusErr := s.repository.Get(ctx, filter)
 	if cusErr.Exists() {
@@ -130,7 +517,7 @@ func (s *LeaderboardService) GetUserRankByUserID(
 	}
 
 	// Cache set (non-blocking)
-	if _, err := s.redisClient.Set(ctx, cacheKey, leader, constants.OneHour); err != nil {
+	if _, err := s.redisClient.Set(ctx, cacheKey, leader, cacheTTL(scope.Board, constants.OneHour)); err != nil {
 		log.Printf(
 			"[WARN] user leaderboard cache set failed | user_id=%s | err=%v",
 			userID,
@@ -144,11 +531,144 @@ func (s *LeaderboardService) GetUserRankByUserID(
 	return leader, apperror.Error{}
 }
 
-// InvalidateUserCache
-func (s *LeaderboardService) InvalidateUserCache(ctx context.Context, userID string) error {
-	cacheKey := fmt.Sprintf(constants.LeaderboardUserKeyFormat, userID)
+// GetUserNeighbours retrieves the entries up to radius places either side of a user, with their
+// users' profiles
+func (s *LeaderboardService) GetUserNeighbours(
+	ctx context.Context,
+	userID string,
+	scope Scope,
+	radius int,
+) (models.LeaderboardSlice, apperror.Error) {
+	neighbours, cusErr := s.userNeighbours(ctx, userID, scope, radius)
+	if cusErr.Exists() {
+		return nil, cusErr
+	}
+
+	s.withProfiles(ctx, neighbours)
+	return shownScores(scope.Board, neighbours), apperror.Error{}
+}
+
+// userNeighbours retrieves the entries up to radius places either side of a user from the
+// real-time ranking, falling back to the cached Postgres leaderboard when the user is not ranked there
+func (s *LeaderboardService) userNeighbours(
+	ctx context.Context,
+	userID string,
+	scope Scope,
+	radius int,
+) (models.LeaderboardSlice, apperror.Error) {
+
+	txn := newrelic.FromContext(ctx)
+
+	id, err := strconv.Atoi(userID)
+	if err != nil {
+		return nil, apperror.New(fmt.Errorf("invalid user id %q", userID), http.StatusBadRequest)
+	}
+
+	// Real-time ranking lookup
+	ranked, found, err := s.getNeighboursFromRankings(ctx, scope, userID, radius)
+	if err == nil && found {
+		if txn != nil {
+			txn.AddAttribute("ranking_hit", true)
+			txn.AddAttribute("user_id", userID)
+		}
+		return ranked, apperror.Error{}
+	}
+
+	if err != nil {
+		log.Printf(
+			"[WARN] user neighbours ranking read failed | user_id=%s | err=%v",
+			userID,
+			err,
+		)
+		if txn != nil {
+			txn.NoticeError(err)
+		}
+	}
+
+	cacheKey := fmt.Sprintf(
+		constants.LeaderboardAroundKeyFormat,
+		scope.versioned(),
+		userID,
+		radius,
+	)
+
+	// Cache lookup
+	var cachedNeighbours models.LeaderboardSlice
+	found, err = s.redisClient.Get(ctx, cacheKey, &cachedNeighbours)
+	if err == nil && found {
+		if txn != nil {
+			txn.AddAttribute("cache_hit", true)
+			txn.AddAttribute("user_id", userID)
+		}
+		return cachedNeighbours, apperror.Error{}
+	}
+
+	if err != nil {
+		log.Printf(
+			"[WARN] user neighbours cache get failed | user_id=%s | err=%v",
+			userID,
+			err,
+		)
+		if txn != nil {
+			txn.NoticeError(err)
+		}
+	}
+
+	// DB fetch
+	neighbours, err := s.repository.GetNeighbours(ctx, scope.Board, scope.Period.Window, scope.Period.Start, id, radius)
+	if err != nil {
+		if txn != nil {
+			txn.NoticeError(err)
+		}
+		return nil, apperror.New(err, http.StatusInternalServerError)
+	}
+
+	if len(neighbours) == 0 {
+		return nil, apperror.New(fmt.Errorf("user %s is not ranked on %s", userID, scope), http.StatusNotFound)
+	}
+
+	// Cache set (non-blocking), short lived as other users' scores move the window
+	if _, err := s.redisClient.Set(ctx, cacheKey, neighbours, cacheTTL(scope.Board, constants.OneMinute)); err != nil {
+		log.Printf(
+			"[WARN] user neighbours cache set failed | user_id=%s | err=%v",
+			userID,
+			err,
+		)
+		if txn != nil {
+			txn.NoticeError(err)
+		}
+	}
+
+	return neighbours, apperror.Error{}
+}
+
+// InvalidateUserCache drops the cached rank of a user on every current board a session of gameMode contributes to
+func (s *LeaderboardService) InvalidateUserCache(ctx context.Context, userID string, gameMode string) error {
+	return s.InvalidateUsersCache(ctx, map[string][]string{gameMode: {userID}})
+}
+
+// InvalidateUsersCache drops the cached standings of several users at once, userIDs lists
+// the users whose standings changed per game mode
+func (s *LeaderboardService) InvalidateUsersCache(ctx context.Context, userIDs map[string][]string) error {
+	keys := make([]string, 0)
+	for gameMode, users := range userIDs {
+		scopes, err := s.sessionScopes(ctx, gameMode, time.Now())
+		if err != nil {
+			return err
+		}
+
+		for _, scope := range scopes {
+			for _, userID := range users {
+				keys = append(keys, fmt.Sprintf(constants.LeaderboardUserKeyFormat, scope.versioned(), userID))
+			}
+		}
+	}
 
-	if _, err := s.redisClient.Unlink(ctx, []string{cacheKey}); err != nil {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	if _, err := s.redisClient.Unlink(ctx, keys); err != nil {
 		if txn := newrelic.FromContext(ctx); txn != nil {
 			txn.NoticeError(err)
 		}
@@ -157,14 +677,22 @@ func (s *LeaderboardService) InvalidateUserCache(ctx context.Context, userID str
 	return nil
 }
 
-// InvalidateTopCache
-func (s *LeaderboardService) InvalidateTopCache(ctx context.Context) error {
-	cacheKey := fmt.Sprintf(
-		constants.LeaderboardTopKeyFormat,
-		constants.TopLeaderboardLimit,
-	)
+// InvalidateTopCache drops the cached top list of the given boards
+func (s *LeaderboardService) InvalidateTopCache(ctx context.Context, scopes []Scope) error {
+	keys := make([]string, 0)
+	for _, scope := range scopes {
+		keys = append(keys, fmt.Sprintf(
+			constants.LeaderboardTopKeyFormat,
+			scope.versioned(),
+			scope.Board.SizeCap,
+		))
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
 
-	if _, err := s.redisClient.Unlink(ctx, []string{cacheKey}); err != nil {
+	if _, err := s.redisClient.Unlink(ctx, keys); err != nil {
 		if txn := newrelic.FromContext(ctx); txn != nil {
 			txn.NoticeError(err)
 		}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"gaming-leaderboard/constants"
+	leaderboardRepo "gaming-leaderboard/internal/leaderboard/repository"
+	onewrelic "gaming-leaderboard/pkg/newrelic"
+	oredis "gaming-leaderboard/pkg/redis"
+
+	"github.com/google/uuid"
+)
+
+// term is one uninterrupted stretch of this instance holding the worker lock. Its context is
+// cancelled as soon as the lock is lost, and its fence stamps every write made during it.
+type term struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	fence  leaderboardRepo.Fence
+	since  time.Time
+}
+
+// fenceStore reads the newest worker lock term that has written (see LatestFence)
+type fenceStore interface {
+	LatestFence(ctx context.Context) (int64, error)
+}
+
+// leadership campaigns for the worker lock so only one replica runs the leaderboard worker.
+// The lock expires unless renewed, renewals run every third of its ttl on their own goroutine
+// so long recalculations keep it.
+type leadership struct {
+	redisClient oredis.Cache
+	fences      fenceStore
+	instance    string
+	ttl         time.Duration
+
+	mu      sync.Mutex
+	term    *term
+	renewed time.Time
+}
+
+// LeadershipStatus reports which instance runs the leaderboard worker
+type LeadershipStatus struct {
+	Instance string     `json:"instance"`
+	Leader   bool       `json:"leader"`
+	Holder   string     `json:"holder,omitempty"`
+	Token    int64      `json:"token,omitempty"`
+	Since    *time.Time `json:"since,omitempty"`
+}
+
+func newLeadership(redisClient oredis.Cache, fences fenceStore, ttl time.Duration) *leadership {
+	return &leadership{
+		redisClient: redisClient,
+		fences:      fences,
+		instance:    instanceID(),
+		ttl:         ttl,
+	}
+}
+
+// instanceID names this process uniquely, even across restarts of the same host
+func instanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), uuid.New().String()[:8])
+}
+
+// campaign keeps trying to take the lock and renewing it once held, releasing it when ctx ends
+func (l *leadership) campaign(ctx context.Context) {
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+
+	l.refresh(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			l.resign()
+			return
+		case <-ticker.C:
+			l.refresh(ctx)
+		}
+	}
+}
+
+// refresh takes or renews the lock and follows any change of term. A new term's token is
+// issued above the newest one that wrote to Postgres, since the Redis counter can be lost.
+func (l *leadership) refresh(ctx context.Context) {
+	floor, err := l.fences.LatestFence(ctx)
+
+	var token int64
+	if err == nil {
+		token, err = l.redisClient.AcquireLock(
+			ctx,
+			constants.LeaderboardWorkerLockKey,
+			constants.LeaderboardWorkerFencingKey,
+			l.instance,
+			l.ttl,
+			floor,
+		)
+	}
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	switch {
+	case err != nil:
+		// The lock may still be ours, but another instance can take it once its ttl passes
+		// without a renewal, so stop at half of it to leave a margin
+		if l.term != nil && now.Sub(l.renewed) >= l.ttl/2 {
+			l.stepDown("renewal failed")
+		}
+	case token == 0:
+		if l.term != nil {
+			l.stepDown("lock held by another instance")
+		}
+	case l.term == nil || l.term.fence.Token != token:
+		if l.term != nil {
+			l.stepDown("lock expired")
+		}
+		l.begin(ctx, token, now)
+	default:
+		l.renewed = now
+	}
+
+	leader := 0.0
+	if l.term != nil {
+		leader = 1
+	}
+	onewrelic.RecordCustomMetric("Custom/LeaderboardWorker/Leader", leader)
+}
+
+// begin starts a term under token. Callers must hold l.mu.
+func (l *leadership) begin(ctx context.Context, token int64, now time.Time) {
+	termCtx, cancel := context.WithCancel(ctx)
+	l.term = &term{
+		ctx:    termCtx,
+		cancel: cancel,
+		fence:  leaderboardRepo.Fence{Token: token, Holder: l.instance},
+		since:  now,
+	}
+	l.renewed = now
+
+	log.Printf("[INFO] Leaderboard worker leadership acquired | instance=%s | token=%d", l.instance, token)
+	l.recordTransition(true, token, "acquired")
+}
+
+// stepDown ends the current term. Callers must hold l.mu.
+func (l *leadership) stepDown(reason string) {
+	token := l.term.fence.Token
+	l.term.cancel()
+	l.term = nil
+
+	log.Printf("[WARN] Leaderboard worker leadership lost | instance=%s | token=%d | reason=%s", l.instance, token, reason)
+	l.recordTransition(false, token, reason)
+}
+
+func (l *leadership) recordTransition(leader bool, token int64, reason string) {
+	onewrelic.RecordCustomEvent("LeaderboardWorkerLeadership", map[string]interface{}{
+		"instance": l.instance,
+		"leader":   leader,
+		"token":    token,
+		"reason":   reason,
+	})
+}
+
+// resign releases the lock so another instance can take over without waiting out its ttl
+func (l *leadership) resign() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.term == nil {
+		return
+	}
+
+	token := l.term.fence.Token
+	l.stepDown("shutting down")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := l.redisClient.ReleaseLock(ctx, constants.LeaderboardWorkerLockKey, l.instance, token); err != nil {
+		log.Printf("[WARN] Leaderboard worker lock release failed | instance=%s | err=%v", l.instance, err)
+	}
+}
+
+// current returns the running term, ok is false while another instance leads
+func (l *leadership) current() (t term, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.term == nil {
+		return term{}, false
+	}
+
+	return *l.term, true
+}
+
+// status reports this instance's view of the lock, and its holder as Redis sees it
+func (l *leadership) status(ctx context.Context) (LeadershipStatus, error) {
+	status := LeadershipStatus{Instance: l.instance}
+
+	if t, ok := l.current(); ok {
+		since := t.since.UTC()
+		status.Leader = true
+		status.Since = &since
+	}
+
+	lock, found, err := l.redisClient.LockHolder(ctx, constants.LeaderboardWorkerLockKey)
+	if err != nil {
+		return status, err
+	}
+
+	if found {
+		status.Holder = lock.Owner
+		status.Token = lock.Token
+	}
+
+	return status, nil
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	oredis "gaming-leaderboard/pkg/redis"
+)
+
+// lockCache answers AcquireLock from token and err, and records the floor it was given. The
+// other Cache methods are not used by leadership and panic.
+type lockCache struct {
+	oredis.Cache
+	token int64
+	err   error
+	floor int64
+}
+
+func (c *lockCache) AcquireLock(ctx context.Context, key, fencingKey, owner string, ttl time.Duration, floor int64) (int64, error) {
+	c.floor = floor
+	return c.token, c.err
+}
+
+// fences answers LatestFence from token and err
+type fences struct {
+	token int64
+	err   error
+}
+
+func (f *fences) LatestFence(ctx context.Context) (int64, error) {
+	return f.token, f.err
+}
+
+// refreshStep is one refresh of the lock, what it answers and the term it should leave
+type refreshStep struct {
+	token      int64
+	err        error
+	fenceErr   error
+	sinceRenew time.Duration
+	// leader is whether a term should run afterwards, under wantToken
+	leader    bool
+	wantToken int64
+	// steppedDown is whether the term running before the refresh should have been ended
+	steppedDown bool
+}
+
+func TestLeadershipRefresh(t *testing.T) {
+	ttl := 30 * time.Second
+	errUnavailable := errors.New("unavailable")
+
+	tests := []struct {
+		name  string
+		steps []refreshStep
+	}{
+		{
+			name: "a won lock begins a term",
+			steps: []refreshStep{
+				{token: 4, leader: true, wantToken: 4},
+			},
+		},
+		{
+			name: "a renewed lock keeps the term",
+			steps: []refreshStep{
+				{token: 4, leader: true, wantToken: 4},
+				{token: 4, leader: true, wantToken: 4},
+			},
+		},
+		{
+			name: "a lock held by another instance is not led",
+			steps: []refreshStep{
+				{token: 0},
+			},
+		},
+		{
+			name: "a lock taken by another instance ends the term",
+			steps: []refreshStep{
+				{token: 4, leader: true, wantToken: 4},
+				{token: 0, steppedDown: true},
+			},
+		},
+		{
+			name: "a lock that expired and was won again begins a new term",
+			steps: []refreshStep{
+				{token: 4, leader: true, wantToken: 4},
+				{token: 6, leader: true, wantToken: 6, steppedDown: true},
+			},
+		},
+		{
+			name: "a failed renewal keeps the term for half the ttl",
+			steps: []refreshStep{
+				{token: 4, leader: true, wantToken: 4},
+				{err: errUnavailable, sinceRenew: ttl / 4, leader: true, wantToken: 4},
+			},
+		},
+		{
+			name: "a failed renewal ends the term after half the ttl",
+			steps: []refreshStep{
+				{token: 4, leader: true, wantToken: 4},
+				{err: errUnavailable, sinceRenew: ttl / 2, steppedDown: true},
+			},
+		},
+		{
+			name: "an unreadable fence is a failed renewal",
+			steps: []refreshStep{
+				{token: 4, leader: true, wantToken: 4},
+				{fenceErr: errUnavailable, sinceRenew: ttl / 2, steppedDown: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &lockCache{}
+			store := &fences{}
+			l := newLeadership(cache, store, ttl)
+
+			for n, step := range tt.steps {
+				cache.token, cache.err = step.token, step.err
+				store.err = step.fenceErr
+
+				before, led := l.current()
+				l.renewed = time.Now().Add(-step.sinceRenew)
+				l.refresh(context.Background())
+
+				after, ok := l.current()
+				if ok != step.leader {
+					t.Fatalf("step %d: leader = %v, want %v", n, ok, step.leader)
+				}
+				if ok && after.fence.Token != step.wantToken {
+					t.Errorf("step %d: token = %d, want %d", n, after.fence.Token, step.wantToken)
+				}
+				if led && (before.ctx.Err() != nil) != step.steppedDown {
+					t.Errorf("step %d: term cancelled = %v, want %v", n, before.ctx.Err() != nil, step.steppedDown)
+				}
+			}
+		})
+	}
+}
+
+func TestLeadershipIssuesTermsAboveLatestFence(t *testing.T) {
+	cache := &lockCache{token: 13}
+	l := newLeadership(cache, &fences{token: 12}, 30*time.Second)
+
+	l.refresh(context.Background())
+
+	if cache.floor != 12 {
+		t.Errorf("lock acquired above %d, want above the latest fence 12", cache.floor)
+	}
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"gaming-leaderboard/constants"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+// pendingUpdates is when submissions not yet ranked by the worker arrived: the first since the
+// last recalculation and the most recent. Both are zero when nothing is pending. full is set
+// when a change to already recorded sessions needs every board recalculated from scratch. The
+// signal lives in Redis so submissions reach the worker whichever instance accepted them.
+type pendingUpdates struct {
+	first time.Time
+	last  time.Time
+	full  bool
+}
+
+func (p pendingUpdates) empty() bool {
+	return p.first.IsZero() && p.last.IsZero()
+}
+
+// due reports whether submissions have paused for the debounce, or the first of them has
+// waited the max latency
+func (p pendingUpdates) due(now time.Time, config WorkerConfig) bool {
+	if p.empty() {
+		return false
+	}
+
+	return now.Sub(p.last) >= config.Debounce || now.Sub(p.first) >= config.MaxLatency
+}
+
+func parsePending(values map[string]string) pendingUpdates {
+	var p pendingUpdates
+	if v, err := strconv.ParseInt(values[constants.PendingFirstField], 10, 64); err == nil {
+		p.first = time.UnixMilli(v)
+	}
+	if v, err := strconv.ParseInt(values[constants.PendingLastField], 10, 64); err == nil {
+		p.last = time.UnixMilli(v)
+	}
+	p.full = values[constants.PendingFullField] != ""
+
+	// A claim can land between the two writes of a mark, leaving only the latest
+	if p.first.IsZero() {
+		p.first = p.last
+	}
+
+	return p
+}
+
+// MarkPending records that a submission is waiting to be ranked by the worker
+func (w *LeaderboardWorker) MarkPending(ctx context.Context) error {
+	now := time.Now()
+	return w.signalPending(ctx, pendingUpdates{first: now, last: now})
+}
+
+// RequestFullRecalculation records that already ranked sessions changed, so the next run
+// recalculates every board from scratch and rebuilds the real-time rankings
+func (w *LeaderboardWorker) RequestFullRecalculation(ctx context.Context) error {
+	now := time.Now()
+	return w.signalPending(ctx, pendingUpdates{first: now, last: now, full: true})
+}
+
+func (w *LeaderboardWorker) signalPending(ctx context.Context, p pendingUpdates) error {
+	redisClient := w.leaderboardService.redisClient
+
+	if _, err := redisClient.HSetNX(ctx, constants.LeaderboardPendingKey, constants.PendingFirstField, p.first.UnixMilli()); err != nil {
+		if txn := newrelic.FromContext(ctx); txn != nil {
+			txn.NoticeError(err)
+		}
+		return err
+	}
+
+	// The full flag lands with the latest mark, so a claim never takes it alone
+	values := map[string]interface{}{
+		constants.PendingLastField: p.last.UnixMilli(),
+	}
+	if p.full {
+		values[constants.PendingFullField] = 1
+	}
+
+	if err := redisClient.HSet(ctx, constants.LeaderboardPendingKey, values); err != nil {
+		if txn := newrelic.FromContext(ctx); txn != nil {
+			txn.NoticeError(err)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// pending reads the pending submissions without claiming them
+func (w *LeaderboardWorker) pending(ctx context.Context) (pendingUpdates, error) {
+	values, err := w.leaderboardService.redisClient.HMGet(
+		ctx,
+		constants.LeaderboardPendingKey,
+		[]string{constants.PendingFirstField, constants.PendingLastField, constants.PendingFullField},
+	)
+	if err != nil {
+		return pendingUpdates{}, err
+	}
+
+	return parsePending(values), nil
+}
+
+// claimPending takes the pending submissions for a recalculation, submissions arriving from
+// then on mark the leaderboard pending again
+func (w *LeaderboardWorker) claimPending(ctx context.Context) (pendingUpdates, error) {
+	values, err := w.leaderboardService.redisClient.HPopAll(ctx, constants.LeaderboardPendingKey)
+	if err != nil {
+		return pendingUpdates{}, err
+	}
+
+	return parsePending(values), nil
+}
+
+// restorePending hands back a claim whose recalculation failed. Submissions marked since the
+// claim arrived after it, so the claim's first replaces theirs while their latest is kept, and
+// the max latency still runs from the claimed submissions.
+func (w *LeaderboardWorker) restorePending(ctx context.Context, p pendingUpdates) {
+	if p.empty() {
+		return
+	}
+
+	redisClient := w.leaderboardService.redisClient
+
+	values := map[string]interface{}{
+		constants.PendingFirstField: p.first.UnixMilli(),
+	}
+	if p.full {
+		values[constants.PendingFullField] = 1
+	}
+
+	err := redisClient.HSet(ctx, constants.LeaderboardPendingKey, values)
+	if err == nil {
+		_, err = redisClient.HSetNX(ctx, constants.LeaderboardPendingKey, constants.PendingLastField, p.last.UnixMilli())
+	}
+	if err != nil {
+		log.Printf("[WARN] pending leaderboard updates restore failed | err=%v", err)
+	}
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"gaming-leaderboard/constants"
+	oredis "gaming-leaderboard/pkg/redis"
+)
+
+func TestPendingUpdatesDue(t *testing.T) {
+	config := WorkerConfig{Debounce: 2 * time.Second, MaxLatency: 10 * time.Second}
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		pending pendingUpdates
+		want    bool
+	}{
+		{
+			name: "nothing pending",
+			want: false,
+		},
+		{
+			name:    "submissions still arriving within the debounce",
+			pending: pendingUpdates{first: now.Add(-5 * time.Second), last: now.Add(-time.Second)},
+			want:    false,
+		},
+		{
+			name:    "submissions paused for the debounce",
+			pending: pendingUpdates{first: now.Add(-5 * time.Second), last: now.Add(-2 * time.Second)},
+			want:    true,
+		},
+		{
+			name:    "submissions arriving past the max latency",
+			pending: pendingUpdates{first: now.Add(-10 * time.Second), last: now},
+			want:    true,
+		},
+		{
+			name:    "full recalculation within the debounce waits like any submission",
+			pending: pendingUpdates{first: now.Add(-time.Second), last: now.Add(-time.Second), full: true},
+			want:    false,
+		},
+		{
+			name:    "full recalculation paused for the debounce",
+			pending: pendingUpdates{first: now.Add(-3 * time.Second), last: now.Add(-3 * time.Second), full: true},
+			want:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.pending.due(now, config); got != tt.want {
+				t.Errorf("due = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePending(t *testing.T) {
+	first := time.UnixMilli(1_792_238_400_000)
+	last := first.Add(3 * time.Second)
+
+	tests := []struct {
+		name   string
+		values map[string]string
+		want   pendingUpdates
+	}{
+		{
+			name: "nothing pending",
+		},
+		{
+			name: "first and latest submission",
+			values: map[string]string{
+				constants.PendingFirstField: strconv.FormatInt(first.UnixMilli(), 10),
+				constants.PendingLastField:  strconv.FormatInt(last.UnixMilli(), 10),
+			},
+			want: pendingUpdates{first: first, last: last},
+		},
+		{
+			name: "full flag",
+			values: map[string]string{
+				constants.PendingFirstField: strconv.FormatInt(first.UnixMilli(), 10),
+				constants.PendingLastField:  strconv.FormatInt(last.UnixMilli(), 10),
+				constants.PendingFullField:  "1",
+			},
+			want: pendingUpdates{first: first, last: last, full: true},
+		},
+		{
+			name: "a claim between the writes of a mark keeps only the latest",
+			values: map[string]string{
+				constants.PendingLastField: strconv.FormatInt(last.UnixMilli(), 10),
+			},
+			want: pendingUpdates{first: last, last: last},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parsePending(tt.values)
+			if !got.first.Equal(tt.want.first) || !got.last.Equal(tt.want.last) || got.full != tt.want.full {
+				t.Errorf("parsePending = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// pendingCache keeps hashes in memory for the pending signal. The other Cache methods are not
+// used by it and panic.
+type pendingCache struct {
+	oredis.Cache
+	hashes map[string]map[string]string
+}
+
+func (c *pendingCache) hash(key string) map[string]string {
+	if c.hashes[key] == nil {
+		c.hashes[key] = make(map[string]string)
+	}
+
+	return c.hashes[key]
+}
+
+func (c *pendingCache) HSetNX(ctx context.Context, key string, field string, value interface{}) (bool, error) {
+	if _, ok := c.hash(key)[field]; ok {
+		return false, nil
+	}
+	c.hash(key)[field] = toString(value)
+
+	return true, nil
+}
+
+func (c *pendingCache) HSet(ctx context.Context, key string, values map[string]interface{}) error {
+	for field, value := range values {
+		c.hash(key)[field] = toString(value)
+	}
+
+	return nil
+}
+
+func (c *pendingCache) HMGet(ctx context.Context, key string, fields []string) (map[string]string, error) {
+	values := make(map[string]string, len(fields))
+	for _, field := range fields {
+		if value, ok := c.hash(key)[field]; ok {
+			values[field] = value
+		}
+	}
+
+	return values, nil
+}
+
+func (c *pendingCache) HPopAll(ctx context.Context, key string) (map[string]string, error) {
+	values := c.hash(key)
+	delete(c.hashes, key)
+
+	return values, nil
+}
+
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case int:
+		return strconv.Itoa(v)
+	default:
+		return v.(string)
+	}
+}
+
+func TestClaimAndRestorePending(t *testing.T) {
+	ctx := context.Background()
+	cache := &pendingCache{hashes: make(map[string]map[string]string)}
+	w := &LeaderboardWorker{leaderboardService: &LeaderboardService{redisClient: cache}}
+
+	if err := w.MarkPending(ctx); err != nil {
+		t.Fatalf("mark pending: %v", err)
+	}
+	if err := w.RequestFullRecalculation(ctx); err != nil {
+		t.Fatalf("request full recalculation: %v", err)
+	}
+
+	marked, err := w.pending(ctx)
+	if err != nil {
+		t.Fatalf("read pending: %v", err)
+	}
+	if marked.empty() || !marked.full {
+		t.Fatalf("pending = %+v, want a full recalculation pending", marked)
+	}
+
+	claimed, err := w.claimPending(ctx)
+	if err != nil {
+		t.Fatalf("claim pending: %v", err)
+	}
+	if !claimed.first.Equal(marked.first) || !claimed.last.Equal(marked.last) || !claimed.full {
+		t.Errorf("claimed %+v, want %+v", claimed, marked)
+	}
+
+	left, err := w.pending(ctx)
+	if err != nil {
+		t.Fatalf("read pending: %v", err)
+	}
+	if !left.empty() {
+		t.Errorf("pending after claim = %+v, want nothing", left)
+	}
+
+	// A submission arriving after the claim is kept, the restored claim keeps its first
+	time.Sleep(2 * time.Millisecond)
+	if err := w.MarkPending(ctx); err != nil {
+		t.Fatalf("mark pending: %v", err)
+	}
+	w.restorePending(ctx, claimed)
+
+	restored, err := w.pending(ctx)
+	if err != nil {
+		t.Fatalf("read pending: %v", err)
+	}
+	if !restored.full {
+		t.Errorf("restored claim lost its full flag")
+	}
+	if !restored.first.Equal(claimed.first) {
+		t.Errorf("restored first = %v, want the claimed %v", restored.first, claimed.first)
+	}
+	if !restored.last.After(claimed.last) {
+		t.Errorf("restored last = %v, want the submission after the claim", restored.last)
+	}
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/models"
+	oredis "gaming-leaderboard/pkg/redis"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+// withProfiles fills in the user of every entry that lacks one. Profiles are read from the
+// profile cache in one pipeline, and those missing from it from Postgres in one query before
+// being cached. Entries of users that cannot be found are left as they are.
+func (s *LeaderboardService) withProfiles(ctx context.Context, entries models.LeaderboardSlice) {
+	txn := newrelic.FromContext(ctx)
+
+	lookups := make([]*oredis.KVOut, 0, len(entries))
+	seen := make(map[int]bool, len(entries))
+	for _, entry := range entries {
+		if entry.User.ID != 0 || seen[entry.UserID] {
+			continue
+		}
+		seen[entry.UserID] = true
+
+		lookups = append(lookups, &oredis.KVOut{
+			Key: fmt.Sprintf(constants.UserProfileKeyFormat, entry.UserID),
+			Val: &models.User{},
+		})
+	}
+
+	if len(lookups) == 0 {
+		return
+	}
+
+	profiles := make(map[int]models.User, len(lookups))
+	if err := s.redisClient.PipedMGet(ctx, lookups); err != nil {
+		log.Printf("[WARN] user profile cache get failed | users=%d | err=%v", len(lookups), err)
+		if txn != nil {
+			txn.NoticeError(err)
+		}
+	}
+
+	for _, lookup := range lookups {
+		if lookup.OK() {
+			profile := lookup.Val.(*models.User)
+			profiles[profile.ID] = *profile
+		}
+	}
+
+	missing := make([]int, 0)
+	for userID := range seen {
+		if _, ok := profiles[userID]; !ok {
+			missing = append(missing, userID)
+		}
+	}
+
+	if len(missing) > 0 {
+		users, cusErr := s.usersRepository.GetAll(ctx, map[string]interface{}{"id": missing})
+		if cusErr.Exists() {
+			if txn != nil {
+				txn.NoticeError(cusErr)
+			}
+		}
+
+		fresh := make([]oredis.KVIn, 0, len(users))
+		for _, user := range users {
+			profiles[user.ID] = *user
+			fresh = append(fresh, oredis.KVIn{
+				Key: fmt.Sprintf(constants.UserProfileKeyFormat, user.ID),
+				Val: user,
+			})
+		}
+
+		// Cache set (non-blocking)
+		if len(fresh) > 0 {
+			if err := s.redisClient.PipedMSet(ctx, fresh, constants.OneHour); err != nil {
+				log.Printf("[WARN] user profile cache set failed | users=%d | err=%v", len(fresh), err)
+				if txn != nil {
+					txn.NoticeError(err)
+				}
+			}
+		}
+	}
+
+	for _, entry := range entries {
+		if profile, ok := profiles[entry.UserID]; ok && entry.User.ID == 0 {
+			entry.User = profile
+		}
+	}
+}
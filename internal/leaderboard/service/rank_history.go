@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/controller/request"
+	"gaming-leaderboard/internal/models"
+	"gaming-leaderboard/pkg/apperror"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"gorm.io/gorm"
+)
+
+// historyDay is the day, in the configured timezone, a rank snapshot taken at t is recorded under
+func (s *LeaderboardService) historyDay(t time.Time) time.Time {
+	return PeriodAt(constants.WindowDaily, t, s.config.Location).Start
+}
+
+// withRankDeltas fills in each entry's rank as of the end of the last recorded day and how far
+// it has moved since. Entries are left without deltas when the history cannot be read.
+func (s *LeaderboardService) withRankDeltas(ctx context.Context, scope Scope, entries models.LeaderboardSlice) {
+	userIDs := make([]int, 0, len(entries))
+	for _, entry := range entries {
+		userIDs = append(userIDs, entry.UserID)
+	}
+
+	previous, err := s.rankHistoryRepository.GetPreviousRanks(
+		ctx,
+		scope.Board.Name,
+		scope.Period.Window,
+		scope.Period.Start,
+		userIDs,
+		s.historyDay(time.Now()),
+	)
+	if err != nil {
+		log.Printf("[WARN] previous ranks read failed | scope=%s | err=%v", scope, err)
+		if txn := newrelic.FromContext(ctx); txn != nil {
+			txn.NoticeError(err)
+		}
+		return
+	}
+
+	for _, entry := range entries {
+		rank, ok := previous[entry.UserID]
+		if !ok {
+			continue
+		}
+
+		delta := rank - entry.Rank
+		entry.PreviousRank = &rank
+		entry.RankDelta = &delta
+	}
+}
+
+// GetRankHistory retrieves a user's daily rank snapshots on a board between two days, inclusive,
+// defaulting to the last 30 days
+func (s *LeaderboardService) GetRankHistory(
+	ctx context.Context,
+	userID string,
+	query request.RankHistoryQuery,
+) (models.RankSnapshotSlice, apperror.Error) {
+	id, err := strconv.Atoi(userID)
+	if err != nil {
+		return nil, apperror.New(fmt.Errorf("invalid user id %q", userID), http.StatusBadRequest)
+	}
+
+	board, cusErr := s.resolveBoard(query.Board, query.Mode)
+	if cusErr.Exists() {
+		return nil, cusErr
+	}
+
+	window := query.Window
+	if window == "" {
+		window = constants.WindowAllTime
+	}
+
+	if !rankedOver(board, window) {
+		return nil, apperror.New(
+			fmt.Errorf("board %s is not ranked over the %s window", board.Name, window),
+			http.StatusNotFound,
+		)
+	}
+
+	to := s.historyDay(time.Now())
+	if query.To != "" {
+		if to, err = time.ParseInLocation(time.DateOnly, query.To, s.config.Location); err != nil {
+			return nil, apperror.New(fmt.Errorf("invalid to: %w", err), http.StatusBadRequest)
+		}
+	}
+
+	from := to.AddDate(0, 0, -constants.DefaultHistoryDays)
+	if query.From != "" {
+		if from, err = time.ParseInLocation(time.DateOnly, query.From, s.config.Location); err != nil {
+			return nil, apperror.New(fmt.Errorf("invalid from: %w", err), http.StatusBadRequest)
+		}
+	}
+
+	if from.After(to) {
+		return nil, apperror.New(fmt.Errorf("from must not be after to"), http.StatusBadRequest)
+	}
+
+	if to.Sub(from) > constants.MaxHistoryDays*constants.OneDay {
+		return nil, apperror.New(
+			fmt.Errorf("history can span at most %d days", constants.MaxHistoryDays),
+			http.StatusBadRequest,
+		)
+	}
+
+	filter := map[string]interface{}{
+		constants.UserID:     id,
+		constants.Board:      board.Name,
+		constants.TimeWindow: window,
+	}
+
+	snapshots, cusErr := s.rankHistoryRepository.GetAll(ctx, filter, func(db *gorm.DB) *gorm.DB {
+		return db.
+			Where(constants.RecordedOn+" BETWEEN CAST(? AS DATE) AND CAST(? AS DATE)",
+				from.Format(time.DateOnly),
+				to.Format(time.DateOnly),
+			).
+			Order("recorded_on ASC, period_start ASC")
+	})
+	if cusErr.Exists() {
+		if txn := newrelic.FromContext(ctx); txn != nil {
+			txn.NoticeError(cusErr)
+		}
+		return nil, cusErr
+	}
+
+	for _, snapshot := range snapshots {
+		snapshot.TotalScore = rankedScore(board, snapshot.TotalScore)
+	}
+
+	return snapshots, apperror.Error{}
+}
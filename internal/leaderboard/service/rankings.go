@@ -0,0 +1,731 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"gaming-leaderboard/constants"
+	leaderboardRepo "gaming-leaderboard/internal/leaderboard/repository"
+	"gaming-leaderboard/internal/models"
+	oredis "gaming-leaderboard/pkg/redis"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"gorm.io/gorm"
+)
+
+// rankingKeys are the Redis keys holding one board's real-time ranking
+type rankingKeys struct {
+	ranking string // sorted set of user ids by score
+	scores  string // sorted set of the distinct scores held, counted for dense ranks
+	reached string // hash of when each user reached their score, used to break ties
+	journal string // list of the updates made while the ranking is rebuilt
+}
+
+func keysFor(scope Scope) rankingKeys {
+	ranking := fmt.Sprintf(constants.RankingKeyFormat, scope)
+	return rankingKeys{
+		ranking: ranking,
+		scores:  fmt.Sprintf(constants.RankingScoresKeyFormat, scope),
+		reached: fmt.Sprintf(constants.RankingReachedKeyFormat, scope),
+		journal: fmt.Sprintf(constants.RankingJournalKeyFormat, ranking),
+	}
+}
+
+// staging are the keys a ranking is rebuilt into before being swapped in, apart for every
+// worker lock term so a deposed leader's rebuild cannot mix into its successor's
+func (k rankingKeys) staging(fence leaderboardRepo.Fence) rankingKeys {
+	return rankingKeys{
+		ranking: fmt.Sprintf(constants.RankingRebuildKeyFormat, k.ranking, fence.Token),
+		scores:  fmt.Sprintf(constants.RankingRebuildKeyFormat, k.scores, fence.Token),
+		reached: fmt.Sprintf(constants.RankingRebuildKeyFormat, k.reached, fence.Token),
+	}
+}
+
+func (k rankingKeys) all() []string {
+	return []string{k.ranking, k.scores, k.reached}
+}
+
+// scoreMember is the distinct scores set member and score range bound of a score
+func scoreMember(score float64) string {
+	return strconv.FormatInt(int64(score), 10)
+}
+
+// reachedAtMicros encodes a reached-at time with the microsecond precision Postgres keeps
+func reachedAtMicros(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.Round(time.Microsecond).UnixMicro()
+}
+
+func reachedAtTime(micros string) time.Time {
+	v, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil || v == 0 {
+		return time.Time{}
+	}
+
+	return time.UnixMicro(v).UTC()
+}
+
+// ranksBefore reports whether a is listed ahead of b on the board: higher scores first, then
+// the board's tie-breaker, then user id. Unknown reached-at times sort last, as NULLs do in Postgres.
+func ranksBefore(board models.Board, a, b *models.Leaderboard) bool {
+	if a.TotalScore != b.TotalScore {
+		return a.TotalScore > b.TotalScore
+	}
+
+	if board.TieBreaker != constants.TieBreakUserID && !a.ReachedAt.Equal(b.ReachedAt) {
+		switch {
+		case a.ReachedAt.IsZero():
+			return false
+		case b.ReachedAt.IsZero():
+			return true
+		default:
+			return a.ReachedAt.Before(b.ReachedAt)
+		}
+	}
+
+	return a.UserID < b.UserID
+}
+
+// RecordScore applies a session's score to the real-time ranking of every board it contributes to
+func (s *LeaderboardService) RecordScore(
+	ctx context.Context,
+	userID int,
+	gameMode string,
+	score int,
+	playedAt time.Time,
+) error {
+	return s.RecordScores(ctx, []ScoreRecord{{
+		UserID:   userID,
+		GameMode: gameMode,
+		Score:    score,
+		PlayedAt: playedAt,
+	}})
+}
+
+// ScoreRecord is one session's score to apply to the real-time rankings
+type ScoreRecord struct {
+	UserID   int
+	GameMode string
+	Score    int
+	PlayedAt time.Time
+}
+
+// RecordScores applies several sessions' scores to the real-time rankings in one round trip
+func (s *LeaderboardService) RecordScores(ctx context.Context, records []ScoreRecord) error {
+	type sessionKind struct {
+		gameMode string
+		playedAt int64
+	}
+
+	// Sessions submitted together share a mode and time, so resolve their boards once
+	kindScopes := make(map[sessionKind][]Scope)
+
+	updates := make([]oredis.ZUpdate, 0, len(records))
+	for _, record := range records {
+		kind := sessionKind{gameMode: record.GameMode, playedAt: record.PlayedAt.UnixNano()}
+		scopes, ok := kindScopes[kind]
+		if !ok {
+			var err error
+			if scopes, err = s.sessionScopes(ctx, record.GameMode, record.PlayedAt); err != nil {
+				return err
+			}
+			kindScopes[kind] = scopes
+		}
+
+		for _, scope := range scopes {
+			mode, ok := realtimeUpdate(scope.Board)
+			if !ok {
+				continue
+			}
+
+			keys := keysFor(scope)
+			updates = append(updates, oredis.ZUpdate{
+				Key:          keys.ranking,
+				DistinctKey:  keys.scores,
+				ChangedAtKey: keys.reached,
+				Member:       strconv.Itoa(record.UserID),
+				Score:        float64(rankedScore(scope.Board, record.Score)),
+				Mode:         mode,
+				ChangedAt:    reachedAtMicros(record.PlayedAt),
+				ExpireAt:     s.expiresAt(scope.Period),
+				JournalKey:   keys.journal,
+			})
+		}
+	}
+
+	if err := s.redisClient.PipedZUpdate(ctx, updates); err != nil {
+		if txn := newrelic.FromContext(ctx); txn != nil {
+			txn.NoticeError(err)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// RestandInRankings sets a user's score on the real-time ranking of every board a session of
+// gameMode played at contributes to, recomputed from the sessions that still count, after a
+// void or ban changed which of them do. Users left without a counted session are taken off
+// the ranking, as a recalculation would.
+func (s *LeaderboardService) RestandInRankings(ctx context.Context, userID int, gameMode string, at time.Time) error {
+	scopes, err := s.sessionScopes(ctx, gameMode, at)
+	if err != nil {
+		return err
+	}
+
+	member := strconv.Itoa(userID)
+	updates := make([]oredis.ZUpdate, 0, len(scopes))
+	for _, scope := range scopes {
+		if _, ok := realtimeUpdate(scope.Board); !ok {
+			continue
+		}
+
+		standing, found, err := s.repository.Standing(
+			ctx,
+			scope.Board,
+			scope.Period.Window,
+			scope.Period.Start,
+			scope.Period.End,
+			userID,
+		)
+		if err != nil {
+			return err
+		}
+
+		keys := keysFor(scope)
+		update := oredis.ZUpdate{
+			Key:          keys.ranking,
+			DistinctKey:  keys.scores,
+			ChangedAtKey: keys.reached,
+			Member:       member,
+			Mode:         oredis.ZUpdateRemove,
+			JournalKey:   keys.journal,
+		}
+		if found {
+			// Setting rather than removing keeps later increments adding to the whole score
+			update.Mode = oredis.ZUpdateSet
+			update.Score = float64(standing.TotalScore)
+			update.ChangedAt = reachedAtMicros(standing.ReachedAt)
+			update.ExpireAt = s.expiresAt(scope.Period)
+		}
+		updates = append(updates, update)
+	}
+
+	if err := s.redisClient.PipedZUpdate(ctx, updates); err != nil {
+		if txn := newrelic.FromContext(ctx); txn != nil {
+			txn.NoticeError(err)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// RebuildRankings repopulates the ranking sorted set of the given boards from the durable
+// leaderboard table. Rankings are only swapped in while fence's term holds the worker lock.
+func (s *LeaderboardService) RebuildRankings(ctx context.Context, scopes []Scope, fence leaderboardRepo.Fence) error {
+	for _, scope := range scopes {
+		if err := s.rebuildRanking(ctx, scope, fence); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rebuildRanking repopulates the ranking keys of one board.
+// Members are written to staging keys which are swapped in for the live keys once complete,
+// so readers never observe a partially built ranking. Scores recorded on the live keys in the
+// meantime are journaled and replayed onto the rebuilt ones as they are swapped in. The swap is
+// refused once fence's term has lost the worker lock, as the rebuild may then be stale.
+func (s *LeaderboardService) rebuildRanking(ctx context.Context, scope Scope, fence leaderboardRepo.Fence) error {
+	live := keysFor(scope)
+	staging := live.staging(fence)
+	if _, err := s.redisClient.Unlink(ctx, staging.all()); err != nil {
+		return err
+	}
+
+	if err := s.redisClient.StartJournal(ctx, live.journal, constants.RankingJournalTTL); err != nil {
+		return err
+	}
+
+	total, err := s.stageRanking(ctx, scope, staging)
+	if err != nil {
+		if _, unlinkErr := s.redisClient.Unlink(ctx, []string{live.journal}); unlinkErr != nil {
+			log.Printf("[WARN] ranking journal not dropped | scope=%s | err=%v", scope, unlinkErr)
+		}
+		return err
+	}
+
+	replayed, err := s.redisClient.SwapRanking(ctx, oredis.ZSwap{
+		Key:                 live.ranking,
+		DistinctKey:         live.scores,
+		ChangedAtKey:        live.reached,
+		RebuiltKey:          staging.ranking,
+		RebuiltDistinctKey:  staging.scores,
+		RebuiltChangedAtKey: staging.reached,
+		JournalKey:          live.journal,
+		LockKey:             constants.LeaderboardWorkerLockKey,
+		LockOwner:           fence.Holder,
+		LockToken:           fence.Token,
+	})
+	if errors.Is(err, oredis.ErrLockLost) {
+		if _, unlinkErr := s.redisClient.Unlink(ctx, staging.all()); unlinkErr != nil {
+			log.Printf("[WARN] ranking staging keys not dropped | scope=%s | err=%v", scope, unlinkErr)
+		}
+		return leaderboardRepo.ErrFenced
+	}
+	if err != nil {
+		return err
+	}
+
+	if replayed < 0 {
+		// Scores recorded during the rebuild may be lost until a reconciliation notices the drift
+		log.Printf("[WARN] ranking journal lapsed during rebuild | scope=%s", scope)
+	}
+
+	if expiresAt := s.expiresAt(scope.Period); !expiresAt.IsZero() {
+		for _, key := range live.all() {
+			if err := s.redisClient.ExpireAt(ctx, key, expiresAt); err != nil {
+				return err
+			}
+		}
+	}
+
+	log.Printf("[INFO] ranking sorted set rebuilt | scope=%s | members=%d | replayed=%d", scope, total, replayed)
+	return nil
+}
+
+// stageRanking writes one board's standings from the durable leaderboard table to the staging
+// keys, returning how many there were
+func (s *LeaderboardService) stageRanking(ctx context.Context, scope Scope, staging rankingKeys) (int, error) {
+	lastID, total := 0, 0
+	for {
+		rows, cusErr := s.repository.GetAll(ctx, scopeFilter(scope), func(db *gorm.DB) *gorm.DB {
+			return db.Where("id > ?", lastID).Order("id ASC").Limit(constants.RankingRebuildBatchSize)
+		})
+		if cusErr.Exists() {
+			return 0, cusErr
+		}
+
+		if len(rows) == 0 {
+			return total, nil
+		}
+
+		members := make([]oredis.ZMember, 0, len(rows))
+		scores := make([]oredis.ZMember, 0, len(rows))
+		reached := make(map[string]interface{}, len(rows))
+		for _, row := range rows {
+			member := strconv.Itoa(row.UserID)
+			members = append(members, oredis.ZMember{Member: member, Score: float64(row.TotalScore)})
+			scores = append(scores, oredis.ZMember{
+				Member: scoreMember(float64(row.TotalScore)),
+				Score:  float64(row.TotalScore),
+			})
+			reached[member] = reachedAtMicros(row.ReachedAt)
+		}
+
+		if err := s.redisClient.ZAdd(ctx, staging.ranking, members); err != nil {
+			return 0, err
+		}
+
+		if err := s.redisClient.ZAdd(ctx, staging.scores, scores); err != nil {
+			return 0, err
+		}
+
+		if err := s.redisClient.HSet(ctx, staging.reached, reached); err != nil {
+			return 0, err
+		}
+
+		lastID = rows[len(rows)-1].ID
+		total += len(rows)
+	}
+}
+
+// ReconcileRankings rebuilds any of the given boards' ranking sorted set that has drifted from Postgres.
+// The sorted set may legitimately hold more members than the table, and newer scores for
+// the members both hold (scores submitted since the last recalculation), but holding fewer
+// members, or a score the table reached later or differs on, means updates were lost. Boards
+// whose aggregation cannot be maintained in real time are always rebuilt. Rankings are only
+// swapped in while fence's term holds the worker lock.
+func (s *LeaderboardService) ReconcileRankings(ctx context.Context, scopes []Scope, fence leaderboardRepo.Fence) error {
+	for _, scope := range scopes {
+		if _, ok := realtimeUpdate(scope.Board); !ok {
+			if err := s.rebuildRanking(ctx, scope, fence); err != nil {
+				return err
+			}
+			continue
+		}
+
+		drift, err := s.rankingDrift(ctx, scope)
+		if err != nil {
+			return err
+		}
+
+		if drift == "" {
+			continue
+		}
+
+		log.Printf("[WARN] ranking sorted set drift detected | scope=%s | %s", scope, drift)
+		if err := s.rebuildRanking(ctx, scope, fence); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rankingDrift describes how a board's ranking sorted set has fallen behind the durable
+// leaderboard, empty when it has not. Member counts are compared in full, scores on a sample of
+// the top standings and those that changed last.
+func (s *LeaderboardService) rankingDrift(ctx context.Context, scope Scope) (string, error) {
+	keys := keysFor(scope)
+
+	ranked, err := s.repository.CountRanked(ctx, scope.Board.Name, scope.Period.Window, scope.Period.Start)
+	if err != nil {
+		return "", err
+	}
+
+	cached, err := s.redisClient.ZCard(ctx, keys.ranking)
+	if err != nil {
+		return "", err
+	}
+
+	if cached < ranked {
+		return fmt.Sprintf("cached=%d | ranked=%d", cached, ranked), nil
+	}
+
+	sample, err := s.repository.SampleRanked(
+		ctx,
+		scope.Board.Name,
+		scope.Period.Window,
+		scope.Period.Start,
+		constants.RankingDriftSampleSize,
+	)
+	if err != nil || len(sample) == 0 {
+		return "", err
+	}
+
+	members := make([]string, 0, len(sample))
+	for _, row := range sample {
+		members = append(members, strconv.Itoa(row.UserID))
+	}
+
+	scores, err := s.redisClient.PipedZScore(ctx, keys.ranking, members)
+	if err != nil {
+		return "", err
+	}
+
+	reached, err := s.redisClient.HMGet(ctx, keys.reached, members)
+	if err != nil {
+		return "", err
+	}
+
+	for i, row := range sample {
+		member := members[i]
+
+		score, ok := scores[member]
+		if !ok {
+			return fmt.Sprintf("user_id=%d | missing", row.UserID), nil
+		}
+
+		// A later reached-at time is a score submitted since, an earlier one a score lost
+		cachedAt, rankedAt := reachedAtMicros(reachedAtTime(reached[member])), reachedAtMicros(row.ReachedAt)
+		if cachedAt < rankedAt || (cachedAt == rankedAt && int(score) != row.TotalScore) {
+			return fmt.Sprintf("user_id=%d | cached_score=%d | ranked_score=%d", row.UserID, int(score), row.TotalScore), nil
+		}
+	}
+
+	return "", nil
+}
+
+// rankingEntries turns ranking members into leaderboard entries carrying when each reached
+// their score, ranks are left for the caller to fill in
+func (s *LeaderboardService) rankingEntries(
+	ctx context.Context,
+	scope Scope,
+	members []oredis.ZMember,
+) (models.LeaderboardSlice, error) {
+	ids := make([]string, 0, len(members))
+	for _, m := range members {
+		ids = append(ids, m.Member)
+	}
+
+	reached, err := s.redisClient.HMGet(ctx, keysFor(scope).reached, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(models.LeaderboardSlice, 0, len(members))
+	for _, m := range members {
+		userID, err := strconv.Atoi(m.Member)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ranking member %q: %w", m.Member, err)
+		}
+
+		entries = append(entries, &models.Leaderboard{
+			UserID:      userID,
+			Board:       scope.Board.Name,
+			GameMode:    scope.Board.GameMode,
+			TimeWindow:  scope.Period.Window,
+			PeriodStart: scope.Period.Start.UTC(),
+			TotalScore:  int(m.Score),
+			ReachedAt:   reachedAtTime(reached[m.Member]),
+		})
+	}
+
+	return entries, nil
+}
+
+// errTieGroupTooLarge is returned when more users share a score than a ranking read loads, the
+// read falls back to the stored leaderboard which orders ties itself
+var errTieGroupTooLarge = errors.New("tie group exceeds the ranking read limit")
+
+// tiedEntries returns every entry of the ranking holding exactly score, or errTieGroupTooLarge
+// when there are more than constants.RankingTieGroupLimit of them
+func (s *LeaderboardService) tiedEntries(ctx context.Context, scope Scope, score float64) (models.LeaderboardSlice, error) {
+	tied, err := s.redisClient.ZRangeByScore(
+		ctx,
+		keysFor(scope).ranking,
+		scoreMember(score),
+		scoreMember(score),
+		constants.RankingTieGroupLimit+1,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tied) > constants.RankingTieGroupLimit {
+		return nil, errTieGroupTooLarge
+	}
+
+	members := make([]oredis.ZMember, 0, len(tied))
+	for _, member := range tied {
+		members = append(members, oredis.ZMember{Member: member, Score: score})
+	}
+
+	return s.rankingEntries(ctx, scope, members)
+}
+
+// rankedWindow reads the entries between two ranking positions, widened at both ends to whole
+// groups of tied scores. Members with equal scores come back from Redis in member order, so
+// groups are fetched whole and sorted by the board's tie-breaker, then ranked from the number
+// of users (or distinct scores, for dense ranks) above the first group. A boundary group
+// larger than constants.RankingTieGroupLimit fails with errTieGroupTooLarge.
+func (s *LeaderboardService) rankedWindow(
+	ctx context.Context,
+	scope Scope,
+	start int64,
+	stop int64,
+) (models.LeaderboardSlice, error) {
+	keys := keysFor(scope)
+
+	members, err := s.redisClient.ZRevRangeWithScores(ctx, keys.ranking, start, stop)
+	if err != nil || len(members) == 0 {
+		return nil, err
+	}
+
+	first, last := members[0].Score, members[len(members)-1].Score
+	inner := make([]oredis.ZMember, 0, len(members))
+	for _, m := range members {
+		if m.Score != first && m.Score != last {
+			inner = append(inner, m)
+		}
+	}
+
+	entries, err := s.tiedEntries(ctx, scope, first)
+	if err != nil {
+		return nil, err
+	}
+
+	middle, err := s.rankingEntries(ctx, scope, inner)
+	if err != nil {
+		return nil, err
+	}
+	entries = append(entries, middle...)
+
+	if last != first {
+		tied, err := s.tiedEntries(ctx, scope, last)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, tied...)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return ranksBefore(scope.Board, entries[i], entries[j])
+	})
+
+	// Position of the first entry among all ranked users
+	offset, err := s.countAbove(ctx, keys.ranking, first)
+	if err != nil {
+		return nil, err
+	}
+
+	firstRank := offset + 1
+	if scope.Board.RankType == constants.RankDense {
+		distinctAbove, err := s.countAbove(ctx, keys.scores, first)
+		if err != nil {
+			return nil, err
+		}
+		firstRank = distinctAbove + 1
+	}
+
+	for i, entry := range entries {
+		if i == 0 {
+			entry.Rank = firstRank
+			continue
+		}
+
+		prev := entries[i-1]
+		switch {
+		case scope.Board.RankType == constants.RankOrdinal:
+			entry.Rank = offset + i + 1
+		case entry.TotalScore == prev.TotalScore:
+			entry.Rank = prev.Rank
+		case scope.Board.RankType == constants.RankDense:
+			entry.Rank = prev.Rank + 1
+		default:
+			entry.Rank = offset + i + 1
+		}
+	}
+
+	return entries, nil
+}
+
+// countAbove counts the members of a sorted set scoring strictly higher than score
+func (s *LeaderboardService) countAbove(ctx context.Context, key string, score float64) (int, error) {
+	above, err := s.redisClient.ZCount(ctx, key, "("+scoreMember(score), "+inf")
+	return int(above), err
+}
+
+// getTopFromRankings reads the top entries from the sorted set, found is false when the set is
+// empty or a tie group is too large to load
+func (s *LeaderboardService) getTopFromRankings(
+	ctx context.Context,
+	scope Scope,
+	limit int,
+) (leaders models.LeaderboardSlice, found bool, err error) {
+	leaders, err = s.rankedWindow(ctx, scope, 0, int64(limit-1))
+	if errors.Is(err, errTieGroupTooLarge) {
+		return nil, false, nil
+	}
+	if err != nil || len(leaders) == 0 {
+		return nil, false, err
+	}
+
+	if len(leaders) > limit {
+		leaders = leaders[:limit]
+	}
+
+	return leaders, true, nil
+}
+
+// getNeighboursFromRankings reads the entries up to radius places either side of a user from
+// the sorted set, found is false when the user is not ranked there or a tie group is too large
+// to load
+func (s *LeaderboardService) getNeighboursFromRankings(
+	ctx context.Context,
+	scope Scope,
+	userID string,
+	radius int,
+) (neighbours models.LeaderboardSlice, found bool, err error) {
+	keys := keysFor(scope)
+
+	score, found, err := s.redisClient.ZScore(ctx, keys.ranking, userID)
+	if err != nil || !found {
+		return nil, false, err
+	}
+
+	above, err := s.countAbove(ctx, keys.ranking, score)
+	if err != nil {
+		return nil, false, err
+	}
+
+	tied, err := s.redisClient.ZCount(ctx, keys.ranking, scoreMember(score), scoreMember(score))
+	if err != nil || tied > constants.RankingTieGroupLimit {
+		return nil, false, err
+	}
+
+	// The user sits somewhere in their tie group, so the group plus radius either side covers the window
+	start := max(above-radius, 0)
+	stop := above + int(tied) - 1 + radius
+
+	entries, err := s.rankedWindow(ctx, scope, int64(start), int64(stop))
+	if errors.Is(err, errTieGroupTooLarge) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	for i, entry := range entries {
+		if strconv.Itoa(entry.UserID) == userID {
+			return entries[max(i-radius, 0):min(i+radius+1, len(entries))], true, nil
+		}
+	}
+
+	// The user was removed between reads
+	return nil, false, nil
+}
+
+// getUserFromRankings reads a user's score and rank from the sorted set. Competition ranks
+// count the users scoring higher, dense ranks the distinct higher scores, and ordinal ranks
+// add the tied users the tie-breaker puts ahead. found is false when the user is not ranked
+// there or their tie group is too large to load.
+func (s *LeaderboardService) getUserFromRankings(
+	ctx context.Context,
+	scope Scope,
+	userID string,
+) (leader models.Leaderboard, found bool, err error) {
+	keys := keysFor(scope)
+
+	score, found, err := s.redisClient.ZScore(ctx, keys.ranking, userID)
+	if err != nil || !found {
+		return models.Leaderboard{}, false, err
+	}
+
+	countKey := keys.ranking
+	if scope.Board.RankType == constants.RankDense {
+		countKey = keys.scores
+	}
+
+	above, err := s.countAbove(ctx, countKey, score)
+	if err != nil {
+		return models.Leaderboard{}, false, err
+	}
+
+	entries, err := s.rankingEntries(ctx, scope, []oredis.ZMember{{Member: userID, Score: score}})
+	if err != nil {
+		return models.Leaderboard{}, false, err
+	}
+
+	leader = *entries[0]
+	leader.Rank = above + 1
+
+	if scope.Board.RankType == constants.RankOrdinal {
+		tied, err := s.tiedEntries(ctx, scope, score)
+		if errors.Is(err, errTieGroupTooLarge) {
+			return models.Leaderboard{}, false, nil
+		}
+		if err != nil {
+			return models.Leaderboard{}, false, err
+		}
+
+		for _, other := range tied {
+			if ranksBefore(scope.Board, other, &leader) {
+				leader.Rank++
+			}
+		}
+	}
+
+	return leader, true, nil
+}
@@ -2,45 +2,84 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"net/http"
 	"sync"
 	"time"
 
+	"gaming-leaderboard/constants"
 	leaderboardRepo "gaming-leaderboard/internal/leaderboard/repository"
+	"gaming-leaderboard/internal/models"
+	"gaming-leaderboard/pkg/apperror"
 )
 
-// LeaderboardWorker handles batch rank recalculation
+// WorkerConfig controls when the worker recalculates after scores are submitted
+type WorkerConfig struct {
+	// PollInterval is how often the worker checks for pending submissions
+	PollInterval time.Duration
+	// Debounce is how long submissions must pause before a recalculation starts
+	Debounce time.Duration
+	// MaxLatency caps how long a submission waits for a recalculation while others keep arriving
+	MaxLatency time.Duration
+	// LockTTL is how long the worker lock outlives its holder, bounding the failover delay
+	LockTTL time.Duration
+}
+
+// LeaderboardWorker handles batch rank recalculation. Every replica starts one, but only the
+// holder of the worker lock recalculates; the others stand by to take over.
 type LeaderboardWorker struct {
 	repository         *leaderboardRepo.LeaderboardRepository
 	leaderboardService *LeaderboardService
+	leadership         *leadership
 	mu                 sync.Mutex
-	batchInterval      time.Duration
+	config             WorkerConfig
+	bootstrappedToken  int64
+	lastRun            time.Time
+	// lastReconcile is when each board was last recalculated in full, by board name
+	lastReconcile map[string]time.Time
+	// lastTeamReconcile is when the team boards were last ranked over every current period
+	lastTeamReconcile time.Time
+}
+
+// RecalculationSummary describes one completed recalculation
+type RecalculationSummary struct {
+	Periods int `json:"periods"`
+	// Full is set when every board was recalculated in full, Reconciled counts those that were
+	Full       bool  `json:"full"`
+	Reconciled int   `json:"reconciled_boards"`
+	Duration   int64 `json:"duration_ms"`
 }
 
-// *optimise approach*//
-// we add something any flag value so if anyone submit new score will mark this flag as true
-// then it will trigger the recalculation in the next batch interval
-// once done recalculation we will reset the flag to false
+// Submissions mark the leaderboard pending (see MarkPending). The worker polls that signal and
+// only recalculates once submissions pause for the debounce, or have waited the max latency.
 func NewLeaderboardWorker(
 	repo *leaderboardRepo.LeaderboardRepository,
 	leaderboardService *LeaderboardService,
-	batchInterval time.Duration,
+	config WorkerConfig,
 ) *LeaderboardWorker {
 	return &LeaderboardWorker{
 		repository:         repo,
 		leaderboardService: leaderboardService,
-		batchInterval:      batchInterval,
+		leadership:         newLeadership(leaderboardService.redisClient, repo, config.LockTTL),
+		config:             config,
+		lastReconcile:      make(map[string]time.Time),
 	}
 }
 
 // Start begins the background worker
 func (w *LeaderboardWorker) Start(ctx context.Context) {
-	ticker := time.NewTicker(w.batchInterval)
+	ticker := time.NewTicker(w.config.PollInterval)
+
+	go w.leadership.campaign(ctx)
 
 	go func() {
 		defer ticker.Stop()
 
-		log.Printf("[INFO] LeaderboardWorker started | batch_interval=%v", w.batchInterval)
+		log.Printf(
+			"[INFO] LeaderboardWorker started | instance=%s | poll_interval=%v | debounce=%v | max_latency=%v",
+			w.leadership.instance, w.config.PollInterval, w.config.Debounce, w.config.MaxLatency,
+		)
 
 		for {
 			select {
@@ -54,25 +93,474 @@ func (w *LeaderboardWorker) Start(ctx context.Context) {
 	}()
 }
 
-// processBatch recalculates leaderboard if there are pending updates
+// processBatch recalculates leaderboard if this instance leads and there are pending updates
+// that are due. A newly won term first bootstraps, since the previous leader's state is unknown.
 func (w *LeaderboardWorker) processBatch(ctx context.Context) {
+	t, ok := w.leadership.current()
+	if !ok {
+		return
+	}
+
+	// Work stops as soon as the lock is lost
+	ctx = t.ctx
+
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
-	log.Printf("[INFO] Processing leaderboard recalculation")
-	startTime := time.Now()
+	if w.bootstrappedToken != t.fence.Token {
+		w.bootstrap(ctx, t.fence)
+		return
+	}
 
-	// Recalculate all ranks
-	if err := w.repository.RecalculateAllRanksWithIsolation(ctx); err != nil {
-		log.Printf("[ERROR] Leaderboard recalculation failed | err=%v", err)
+	w.closeEndedSeason(ctx, t.fence)
+
+	now := time.Now()
+	pending, err := w.pending(ctx)
+	if err != nil {
+		log.Printf("[WARN] Pending leaderboard updates unavailable | err=%v", err)
+		return
+	}
+
+	if !pending.due(now, w.config) {
 		return
 	}
 
+	if _, err := w.run(ctx, now, t.fence, false); err != nil {
+		log.Printf("[ERROR] Leaderboard recalculation failed | err=%v", err)
+	}
+}
+
+// leaderFence returns the fence of this instance's term, or a conflict naming the instance
+// that leads instead
+func (w *LeaderboardWorker) leaderFence(ctx context.Context) (leaderboardRepo.Fence, apperror.Error) {
+	if t, ok := w.leadership.current(); ok {
+		return t.fence, apperror.Error{}
+	}
+
+	status, err := w.leadership.status(ctx)
+	if err != nil || status.Holder == "" {
+		return leaderboardRepo.Fence{}, apperror.New(
+			fmt.Errorf("leaderboard worker has no leader yet, please try again shortly"),
+			http.StatusServiceUnavailable,
+		)
+	}
+
+	return leaderboardRepo.Fence{}, apperror.New(
+		fmt.Errorf("leaderboard worker runs on instance %s", status.Holder),
+		http.StatusConflict,
+	)
+}
+
+// Leadership reports which instance runs the leaderboard worker
+func (w *LeaderboardWorker) Leadership(ctx context.Context) (LeadershipStatus, apperror.Error) {
+	status, err := w.leadership.status(ctx)
+	if err != nil {
+		return LeadershipStatus{}, apperror.New(err, http.StatusInternalServerError)
+	}
+
+	return status, apperror.Error{}
+}
+
+// RecalculateNow recalculates immediately whether or not submissions are pending, in full when
+// full is set and otherwise however the next scheduled run would. Only the leading instance
+// recalculates, the others answer with a conflict naming it.
+func (w *LeaderboardWorker) RecalculateNow(ctx context.Context, full bool) (RecalculationSummary, apperror.Error) {
+	fence, cusErr := w.leaderFence(ctx)
+	if cusErr.Exists() {
+		return RecalculationSummary{}, cusErr
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	summary, err := w.run(ctx, time.Now(), fence, full)
+	if err != nil {
+		return RecalculationSummary{}, apperror.New(err, http.StatusInternalServerError)
+	}
+
+	return summary, apperror.Error{}
+}
+
+// run claims the pending submissions and recalculates every current period. The claim is
+// restored when nothing could be recalculated so the next tick retries. Callers must hold w.mu.
+func (w *LeaderboardWorker) run(
+	ctx context.Context,
+	startTime time.Time,
+	fence leaderboardRepo.Fence,
+	full bool,
+) (RecalculationSummary, error) {
+	log.Printf("[INFO] Processing leaderboard recalculation")
+
+	// Boards defined or changed on any instance are ranked from this run on
+	if err := w.leaderboardService.loadBoards(ctx); err != nil {
+		return RecalculationSummary{}, fmt.Errorf("leaderboard definitions unavailable: %w", err)
+	}
+
+	claimed, err := w.claimPending(ctx)
+	if err != nil {
+		return RecalculationSummary{}, err
+	}
+
+	// Recalculate the current period of every window, plus any period that
+	// ended since the last run so its final minutes are not lost
+	periods, err := w.periodsToRecalculate(ctx, startTime)
+	if err != nil {
+		w.restorePending(ctx, claimed)
+		return RecalculationSummary{}, fmt.Errorf("leaderboard periods unavailable: %w", err)
+	}
+
+	// Only new sessions are folded in between each board's periodic full reconciliations
+	full = full || claimed.full
+	reconcile := w.dueForReconcile(startTime, full)
+
+	// Team boards are always ranked from every session, so they follow the reconcile cadence
+	// rather than every run, except over periods that just ended
+	teams := full || w.dueForTeamReconcile(startTime)
+
+	recalculated, teamRanked := w.recalculate(ctx, startTime, periods, fence, reconcile, teams)
+	if len(recalculated) == 0 {
+		w.restorePending(ctx, claimed)
+		return RecalculationSummary{}, fmt.Errorf("recalculation failed for every period")
+	}
+
+	w.lastRun = startTime
+	reconciled := w.markReconciled(startTime, reconcile, len(recalculated) == len(periods))
+	if teams && len(teamRanked) == len(periods) {
+		w.lastTeamReconcile = startTime
+	}
+
 	duration := time.Since(startTime)
-	log.Printf("[INFO] Leaderboard recalculation completed | duration=%v", duration)
+	log.Printf(
+		"[INFO] Leaderboard recalculation completed | periods=%d | reconciled_boards=%d/%d | duration=%v",
+		len(recalculated), reconciled, len(reconcile), duration,
+	)
+
+	w.purgeExpiredPeriods(ctx, startTime, periods, fence)
+
+	scopes := w.leaderboardService.periodScopes(recalculated)
 
 	// Invalidate cache after successful recalculation
-	if err := w.leaderboardService.InvalidateTopCache(ctx); err != nil {
+	if err := w.leaderboardService.InvalidateTopCache(ctx, scopes); err != nil {
 		log.Printf("[WARN] Cache invalidation failed | err=%v", err)
 	}
+	if err := w.leaderboardService.InvalidateTeamTopCache(ctx, w.leaderboardService.teamPeriodScopes(teamRanked)); err != nil {
+		log.Printf("[WARN] Team cache invalidation failed | err=%v", err)
+	}
+
+	if full {
+		// Recorded sessions changed, which the real-time ranking cannot follow incrementally
+		if err := w.leaderboardService.RebuildRankings(ctx, scopes, fence); err != nil {
+			log.Printf("[ERROR] Ranking rebuild failed | err=%v", err)
+		}
+	} else if err := w.leaderboardService.ReconcileRankings(ctx, scopes, fence); err != nil {
+		// Repair the real-time ranking if it lost members, or refresh it for boards ranked only here
+		log.Printf("[WARN] Ranking reconciliation failed | err=%v", err)
+	}
+
+	return RecalculationSummary{
+		Periods:    len(recalculated),
+		Full:       reconciled == len(reconcile),
+		Reconciled: reconciled,
+		Duration:   duration.Milliseconds(),
+	}, nil
+}
+
+// dueForReconcile tells for every board whether this run recalculates it in full: all of them
+// when full is set, else those this instance has not reconciled yet and those whose refresh
+// interval has passed since it last did
+func (w *LeaderboardWorker) dueForReconcile(now time.Time, full bool) map[string]bool {
+	boards := w.leaderboardService.Boards()
+
+	due := make(map[string]bool, len(boards))
+	for _, board := range boards {
+		last, ok := w.lastReconcile[board.Name]
+		due[board.Name] = full || !ok || now.Sub(last) >= w.refreshInterval(board)
+	}
+
+	return due
+}
+
+// refreshInterval is how often a board is recalculated in full, the reconcile interval unless
+// the board sets its own
+func (w *LeaderboardWorker) refreshInterval(board models.Board) time.Duration {
+	if board.RefreshSeconds > 0 {
+		return time.Duration(board.RefreshSeconds) * time.Second
+	}
+
+	return w.leaderboardService.config.ReconcileInterval
+}
+
+// dueForTeamReconcile tells whether the team boards are due to be ranked over every current
+// period, which they are once the reconcile interval has passed since they last were
+func (w *LeaderboardWorker) dueForTeamReconcile(now time.Time) bool {
+	return w.lastTeamReconcile.IsZero() || now.Sub(w.lastTeamReconcile) >= w.leaderboardService.config.ReconcileInterval
+}
+
+// markReconciled records when the boards reconciled by a run were, provided every period
+// succeeded, and returns how many there were. Boards no longer defined are forgotten.
+func (w *LeaderboardWorker) markReconciled(now time.Time, reconcile map[string]bool, succeeded bool) int {
+	for name := range w.lastReconcile {
+		if _, ok := reconcile[name]; !ok {
+			delete(w.lastReconcile, name)
+		}
+	}
+
+	reconciled := 0
+	for name, due := range reconcile {
+		if !due {
+			continue
+		}
+
+		reconciled++
+		if succeeded {
+			w.lastReconcile[name] = now
+		}
+	}
+
+	return reconciled
+}
+
+// bootstrap brings the durable leaderboard up to date and rebuilds the ranking sorted set from
+// it. Callers must hold w.mu.
+func (w *LeaderboardWorker) bootstrap(ctx context.Context, fence leaderboardRepo.Fence) {
+	if err := w.leaderboardService.loadBoards(ctx); err != nil {
+		log.Printf("[ERROR] Leaderboard bootstrap definitions unavailable | err=%v", err)
+		return
+	}
+
+	now := time.Now()
+	periods, err := w.periodsToRecalculate(ctx, now)
+	if err != nil {
+		log.Printf("[ERROR] Leaderboard bootstrap periods unavailable | err=%v", err)
+		return
+	}
+
+	reconcile := w.dueForReconcile(now, true)
+	recalculated, teamRanked := w.recalculate(ctx, now, periods, fence, reconcile, true)
+	if len(recalculated) == 0 {
+		// Retried on the next tick
+		return
+	}
+
+	w.bootstrappedToken = fence.Token
+	w.lastRun = now
+	w.markReconciled(now, reconcile, len(recalculated) == len(periods))
+	if len(teamRanked) == len(periods) {
+		w.lastTeamReconcile = now
+	}
+
+	if err := w.leaderboardService.RebuildRankings(ctx, w.leaderboardService.periodScopes(recalculated), fence); err != nil {
+		log.Printf("[ERROR] Ranking rebuild failed | err=%v", err)
+	}
+}
+
+// periodsToRecalculate lists the current period of every window, and the previous
+// period of a window whose boundary was crossed since the last run
+func (w *LeaderboardWorker) periodsToRecalculate(ctx context.Context, now time.Time) ([]Period, error) {
+	current, err := w.leaderboardService.currentPeriods(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	periods := make([]Period, 0, len(current))
+	for _, period := range current {
+		periods = append(periods, period)
+
+		// All-time periods only end when a season closes, which recalculates them itself
+		if !period.Bounded() || w.lastRun.IsZero() || period.Contains(w.lastRun) {
+			continue
+		}
+		periods = append(periods, PeriodAt(period.Window, w.lastRun, w.leaderboardService.config.Location))
+	}
+
+	return periods, nil
+}
+
+// recalculate ranks every board over each given period, the boards reconcile names in full and
+// the others from only the sessions recorded since the last run, returning the periods that
+// succeeded. Team boards are ranked over every period when teams is set, and otherwise only over
+// the periods that have ended; the periods they were ranked over are returned in teamRanked.
+func (w *LeaderboardWorker) recalculate(
+	ctx context.Context,
+	now time.Time,
+	periods []Period,
+	fence leaderboardRepo.Fence,
+	reconcile map[string]bool,
+	teams bool,
+) (recalculated []Period, teamRanked []Period) {
+	recalculated = make([]Period, 0, len(periods))
+	teamRanked = make([]Period, 0, len(periods))
+	for _, period := range periods {
+		if err := w.repository.RecalculateIncrementally(
+			ctx,
+			w.leaderboardService.boardsOver(period.Window),
+			reconcile,
+			period.Window,
+			period.Start,
+			period.End,
+			w.leaderboardService.historyDay(now),
+			fence,
+		); err != nil {
+			log.Printf("[ERROR] Leaderboard recalculation failed | window=%s | period=%s | err=%v", period.Window, period.Label(), err)
+			continue
+		}
+		recalculated = append(recalculated, period)
+
+		if !teams && period.Contains(now) {
+			continue
+		}
+
+		// Team boards are few and always ranked in full, a failure leaves them for the next run
+		if err := w.leaderboardService.teamLeaderboardRepository.RecalculateTeamBoards(
+			ctx,
+			w.leaderboardService.TeamBoards(),
+			period.Window,
+			period.Start,
+			period.End,
+			fence,
+		); err != nil {
+			log.Printf("[ERROR] Team leaderboard recalculation failed | window=%s | period=%s | err=%v", period.Window, period.Label(), err)
+			continue
+		}
+		teamRanked = append(teamRanked, period)
+	}
+
+	return recalculated, teamRanked
+}
+
+// purgeExpiredPeriods drops standings of periods that ended longer than their retention ago,
+// of all-time boards counted from a season boundary that has since moved, and of boards
+// that are no longer defined, along with rank history past its retention. Standings are only
+// purged while fence's term is the newest to have written.
+func (w *LeaderboardWorker) purgeExpiredPeriods(
+	ctx context.Context,
+	now time.Time,
+	current []Period,
+	fence leaderboardRepo.Fence,
+) {
+	config := w.leaderboardService.config
+
+	if err := w.repository.PurgeRemovedBoards(ctx, w.leaderboardService.boardNames(), fence); err != nil {
+		log.Printf("[WARN] Removed board purge failed | err=%v", err)
+	}
+
+	teamBoards := make([]string, 0)
+	for _, board := range w.leaderboardService.TeamBoards() {
+		teamBoards = append(teamBoards, board.Name)
+	}
+	if err := w.leaderboardService.teamLeaderboardRepository.PurgeRemovedBoards(ctx, teamBoards, fence); err != nil {
+		log.Printf("[WARN] Removed team board purge failed | err=%v", err)
+	}
+
+	if config.HistoryRetention > 0 {
+		before := w.leaderboardService.historyDay(now.Add(-config.HistoryRetention))
+		if err := w.leaderboardService.rankHistoryRepository.PurgeBefore(ctx, before); err != nil {
+			log.Printf("[WARN] Rank history purge failed | err=%v", err)
+		}
+	}
+
+	for _, period := range current {
+		if period.Window != constants.WindowAllTime {
+			continue
+		}
+
+		if err := w.repository.PurgeExpiredPeriods(ctx, period.Window, period.Start, fence); err != nil {
+			log.Printf("[WARN] Expired period purge failed | window=%s | err=%v", period.Window, err)
+		}
+		if err := w.leaderboardService.teamLeaderboardRepository.PurgeExpiredPeriods(ctx, period.Window, period.Start, fence); err != nil {
+			log.Printf("[WARN] Expired team period purge failed | window=%s | err=%v", period.Window, err)
+		}
+	}
+
+	for _, window := range w.leaderboardService.Windows() {
+		if window == constants.WindowAllTime {
+			continue
+		}
+
+		// The period containing the cutoff ended after it, so everything before it has expired
+		oldest := PeriodAt(window, now.Add(-config.Retention[window]), config.Location)
+		if err := w.repository.PurgeExpiredPeriods(ctx, window, oldest.Start, fence); err != nil {
+			log.Printf("[WARN] Expired period purge failed | window=%s | err=%v", window, err)
+		}
+		if err := w.leaderboardService.teamLeaderboardRepository.PurgeExpiredPeriods(ctx, window, oldest.Start, fence); err != nil {
+			log.Printf("[WARN] Expired team period purge failed | window=%s | err=%v", window, err)
+		}
+	}
+}
+
+// CloseSeason closes the running season on demand, freezing its final standings
+func (w *LeaderboardWorker) CloseSeason(ctx context.Context, seasonID int) (models.Season, apperror.Error) {
+	fence, cusErr := w.leaderFence(ctx)
+	if cusErr.Exists() {
+		return models.Season{}, cusErr
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	season, cusErr := w.leaderboardService.GetSeason(ctx, seasonID)
+	if cusErr.Exists() {
+		return models.Season{}, cusErr
+	}
+
+	if season.Status != constants.SeasonStatusActive {
+		return models.Season{}, apperror.New(
+			fmt.Errorf("season %d is already %s", seasonID, season.Status),
+			http.StatusConflict,
+		)
+	}
+
+	return w.closeSeason(ctx, season, time.Now().UTC(), fence)
+}
+
+// closeEndedSeason closes the running season once its scheduled end has passed
+func (w *LeaderboardWorker) closeEndedSeason(ctx context.Context, fence leaderboardRepo.Fence) {
+	season, found, cusErr := w.leaderboardService.activeSeason(ctx)
+	if cusErr.Exists() {
+		log.Printf("[WARN] Active season lookup failed | err=%v", cusErr)
+		return
+	}
+
+	if !found || season.EndAt == nil || season.EndAt.After(time.Now()) {
+		return
+	}
+
+	if _, cusErr := w.closeSeason(ctx, season, *season.EndAt, fence); cusErr.Exists() {
+		log.Printf("[ERROR] Scheduled season close failed | season_id=%d | err=%v", season.ID, cusErr)
+	}
+}
+
+// closeSeason ranks the season's sessions played before closedAt one final time, then archives
+// the result and moves the live all-time boards to a fresh period. Callers must hold w.mu.
+func (w *LeaderboardWorker) closeSeason(
+	ctx context.Context,
+	season models.Season,
+	closedAt time.Time,
+	fence leaderboardRepo.Fence,
+) (models.Season, apperror.Error) {
+	period := w.leaderboardService.allTimePeriod(season.StartAt)
+
+	if err := w.repository.RecalculateAllRanksWithIsolation(
+		ctx,
+		w.leaderboardService.boardsOver(period.Window),
+		period.Window,
+		period.Start,
+		closedAt,
+		w.leaderboardService.historyDay(time.Now()),
+		fence,
+	); err != nil {
+		return models.Season{}, apperror.New(err, http.StatusInternalServerError)
+	}
+
+	if err := w.leaderboardService.seasonsRepository.CloseWithStandings(ctx, season, closedAt, fence); err != nil {
+		return models.Season{}, apperror.New(err, http.StatusInternalServerError)
+	}
+
+	w.leaderboardService.resetSeasonBoundary(ctx, season.StartAt)
+
+	season.Status = constants.SeasonStatusClosed
+	season.EndAt = &closedAt
+
+	log.Printf("[INFO] season closed | season_id=%d | closed_at=%v", season.ID, closedAt)
+	return season, apperror.Error{}
 }
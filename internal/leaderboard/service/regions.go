@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/models"
+	"gaming-leaderboard/pkg/apperror"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+// GetRegionalTopLeaderboards retrieves the top entries of a board among the users who played
+// from one region, with their profiles. A session's region is its user's country when it was
+// submitted, and users are ranked in a region by their sessions from there alone, so a user who
+// played from several regions is listed in each and one who moves keeps their past standings
+// where they were earned. Entries carry their global rank as of the last recalculation and their
+// rank in the region. The lists are read from the sessions rather than the recalculated
+// standings, and cached only briefly since recalculations do not invalidate them region by
+// region.
+func (s *LeaderboardService) GetRegionalTopLeaderboards(
+	ctx context.Context,
+	scope Scope,
+	region string,
+) (models.LeaderboardSlice, apperror.Error) {
+	txn := newrelic.FromContext(ctx)
+
+	cacheKey := fmt.Sprintf(constants.RegionTopKeyFormat, scope.versioned(), region, scope.Board.SizeCap)
+
+	// Cache lookup
+	var cachedLeaders models.LeaderboardSlice
+	found, err := s.redisClient.Get(ctx, cacheKey, &cachedLeaders)
+	if err == nil && found {
+		if txn != nil {
+			txn.AddAttribute("cache_hit", true)
+		}
+		s.withProfiles(ctx, cachedLeaders)
+		return shownScores(scope.Board, cachedLeaders), apperror.Error{}
+	}
+
+	if err != nil {
+		log.Printf("[WARN] regional leaderboard cache get failed | err=%v", err)
+		if txn != nil {
+			txn.NoticeError(err)
+		}
+	}
+
+	leaders, err := s.repository.RegionTop(
+		ctx,
+		scope.Board,
+		scope.Period.Window,
+		scope.Period.Start,
+		scope.Period.End,
+		region,
+		scope.Board.SizeCap,
+	)
+	if err != nil {
+		if txn != nil {
+			txn.NoticeError(err)
+		}
+		return nil, apperror.New(fmt.Errorf("unable to get regional leaderboard"), http.StatusInternalServerError)
+	}
+
+	// The region's best users are all listed, so their regional ranks follow from the list alone
+	for i, rank := range ranksAmong(scope.Board, leaders) {
+		leaders[i].Region = region
+		leaders[i].RegionalRank = &rank
+	}
+
+	// Cached without profiles like the global top lists, they are filled in on every read
+	if _, err := s.redisClient.Set(ctx, cacheKey, leaders, cacheTTL(scope.Board, constants.OneMinute)); err != nil {
+		log.Printf("[WARN] regional leaderboard cache set failed | err=%v", err)
+		if txn != nil {
+			txn.NoticeError(err)
+		}
+	}
+
+	s.withProfiles(ctx, leaders)
+
+	return shownScores(scope.Board, leaders), apperror.Error{}
+}
+
+// withRegionalRank adds the region an entry's user is ranked in, that of their latest counted
+// session on the board period, and the rank their sessions from there hold in it, as the
+// regional top lists number them. Entries of users with no session from a known region are
+// left as they are, as are those whose regional rank cannot be read.
+func (s *LeaderboardService) withRegionalRank(ctx context.Context, scope Scope, entry *models.Leaderboard) {
+	region, rank, found, err := s.repository.RegionalRank(
+		ctx,
+		scope.Board,
+		scope.Period.Window,
+		scope.Period.Start,
+		scope.Period.End,
+		entry.UserID,
+	)
+	if err != nil {
+		log.Printf("[WARN] regional rank read failed | user_id=%d | err=%v", entry.UserID, err)
+		if txn := newrelic.FromContext(ctx); txn != nil {
+			txn.NoticeError(err)
+		}
+		return
+	}
+
+	if !found {
+		return
+	}
+
+	entry.Region = region
+	entry.RegionalRank = &rank
+}
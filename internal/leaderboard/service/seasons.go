@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/controller/request"
+	leaderboardRepository "gaming-leaderboard/internal/leaderboard/repository"
+	"gaming-leaderboard/internal/models"
+	"gaming-leaderboard/internal/repository"
+	"gaming-leaderboard/pkg/apperror"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"gorm.io/gorm"
+)
+
+// seasonBoundary returns when the live all-time board starts counting. Every submission and
+// read needs it, so it is cached briefly rather than queried each time.
+func (s *LeaderboardService) seasonBoundary(ctx context.Context) (time.Time, error) {
+	var boundary time.Time
+	found, err := s.redisClient.Get(ctx, constants.SeasonBoundaryKey, &boundary)
+	if err == nil && found {
+		return boundary, nil
+	}
+
+	boundary, err = s.seasonsRepository.GetBoundary(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// Cache set (non-blocking)
+	if _, err := s.redisClient.Set(ctx, constants.SeasonBoundaryKey, boundary, constants.OneMinute); err != nil {
+		log.Printf("[WARN] season boundary cache set failed | err=%v", err)
+	}
+
+	return boundary, nil
+}
+
+// resetSeasonBoundary drops the cached boundary and the live all-time boards counted from the previous one
+func (s *LeaderboardService) resetSeasonBoundary(ctx context.Context, previous time.Time) {
+	keys := []string{constants.SeasonBoundaryKey}
+	for _, scope := range s.periodScopes([]Period{s.allTimePeriod(previous)}) {
+		keys = append(keys, keysFor(scope).all()...)
+		keys = append(keys, fmt.Sprintf(constants.LeaderboardTopKeyFormat, scope.versioned(), scope.Board.SizeCap))
+	}
+
+	if _, err := s.redisClient.Unlink(ctx, keys); err != nil {
+		log.Printf("[WARN] season boundary reset failed | err=%v", err)
+		if txn := newrelic.FromContext(ctx); txn != nil {
+			txn.NoticeError(err)
+		}
+	}
+}
+
+// activeSeason returns the running season, found is false when none is running
+func (s *LeaderboardService) activeSeason(ctx context.Context) (season models.Season, found bool, cusErr apperror.Error) {
+	seasons, cusErr := s.seasonsRepository.GetAll(ctx, map[string]interface{}{
+		constants.Status: constants.SeasonStatusActive,
+	})
+	if cusErr.Exists() || len(seasons) == 0 {
+		return models.Season{}, false, cusErr
+	}
+
+	return *seasons[0], true, apperror.Error{}
+}
+
+// OpenSeason starts a new season, the live all-time boards restart from zero
+func (s *LeaderboardService) OpenSeason(
+	ctx context.Context,
+	req request.OpenSeasonRequest,
+) (models.Season, apperror.Error) {
+	now := time.Now().UTC()
+
+	if req.EndAt != nil && !req.EndAt.After(now) {
+		return models.Season{}, apperror.New(fmt.Errorf("end_at must be in the future"), http.StatusBadRequest)
+	}
+
+	active, found, cusErr := s.activeSeason(ctx)
+	if cusErr.Exists() {
+		return models.Season{}, cusErr
+	}
+
+	if found {
+		return models.Season{}, apperror.New(
+			fmt.Errorf("season %d is still active, close it first", active.ID),
+			http.StatusConflict,
+		)
+	}
+
+	previous, err := s.seasonsRepository.GetBoundary(ctx)
+	if err != nil {
+		return models.Season{}, apperror.New(err, http.StatusInternalServerError)
+	}
+
+	season := &models.Season{
+		Name:    req.Name,
+		StartAt: now,
+		Status:  constants.SeasonStatusActive,
+	}
+	if req.EndAt != nil {
+		endAt := req.EndAt.UTC()
+		season.EndAt = &endAt
+	}
+
+	if cusErr := s.seasonsRepository.Create(ctx, season); cusErr.Exists() {
+		return models.Season{}, cusErr
+	}
+
+	s.resetSeasonBoundary(ctx, previous)
+
+	log.Printf("[INFO] season opened | season_id=%d | name=%s", season.ID, season.Name)
+	return *season, apperror.Error{}
+}
+
+// GetSeason retrieves a season by id
+func (s *LeaderboardService) GetSeason(ctx context.Context, seasonID int) (models.Season, apperror.Error) {
+	season, cusErr := s.seasonsRepository.Get(ctx, map[string]interface{}{"id": seasonID})
+	if cusErr.Exists() {
+		return models.Season{}, apperror.New(fmt.Errorf("season %d not found", seasonID), http.StatusNotFound)
+	}
+
+	return season, apperror.Error{}
+}
+
+// GetSeasonStandings retrieves a page of a closed season's final rankings
+func (s *LeaderboardService) GetSeasonStandings(
+	ctx context.Context,
+	seasonID int,
+	query request.SeasonLeaderboardQuery,
+) (models.SeasonStandingSlice, int64, apperror.Error) {
+	board, cusErr := s.resolveBoard(query.Board, query.Mode)
+	if cusErr.Exists() {
+		return nil, 0, cusErr
+	}
+
+	season, cusErr := s.GetSeason(ctx, seasonID)
+	if cusErr.Exists() {
+		return nil, 0, cusErr
+	}
+
+	if season.Status != constants.SeasonStatusClosed {
+		return nil, 0, apperror.New(
+			fmt.Errorf("season %d has no final standings until it closes", seasonID),
+			http.StatusConflict,
+		)
+	}
+
+	filter := map[string]interface{}{
+		constants.SeasonID: seasonID,
+		constants.Board:    board.Name,
+	}
+
+	standings, total, cusErr := s.seasonStandingsRepository.GetAllWithPagination(
+		ctx,
+		filter,
+		func(db *gorm.DB) *gorm.DB {
+			return db.Order("rank ASC, " + leaderboardRepository.TieBreakOrder(board))
+		},
+		repository.Paginate(query.Page, query.Limit),
+	)
+	if cusErr.Exists() {
+		return nil, 0, cusErr
+	}
+
+	for _, standing := range standings {
+		standing.TotalScore = rankedScore(board, standing.TotalScore)
+	}
+
+	return standings, total, apperror.Error{}
+}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/controller/request"
+	"gaming-leaderboard/internal/models"
+	"gaming-leaderboard/pkg/apperror"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"gorm.io/gorm"
+)
+
+// ValidateTeamBoards checks team board definitions before the service starts ranking them,
+// filling in sum splits and competition ranking where left unset. Team boards share their
+// names' namespace with boards.
+func ValidateTeamBoards(teamBoards []models.TeamBoard, boards []models.Board) error {
+	names := make(map[string]struct{}, len(boards)+len(teamBoards))
+	for _, board := range boards {
+		names[board.Name] = struct{}{}
+	}
+
+	for i := range teamBoards {
+		board := &teamBoards[i]
+
+		if !boardNamePattern.MatchString(board.Name) {
+			return fmt.Errorf("team board name %q must match %s", board.Name, boardNamePattern)
+		}
+
+		if _, ok := names[board.Name]; ok {
+			return fmt.Errorf("team board %q is declared twice or shares a board's name", board.Name)
+		}
+		names[board.Name] = struct{}{}
+
+		switch board.Aggregation {
+		case constants.AggregationSum, constants.AggregationBest, constants.AggregationLatest:
+		case constants.AggregationAverage, constants.AggregationBestN:
+			if board.Sessions < 1 {
+				return fmt.Errorf("team board %q needs a positive session count for %s aggregation", board.Name, board.Aggregation)
+			}
+		default:
+			return fmt.Errorf("team board %q has unknown aggregation %q", board.Name, board.Aggregation)
+		}
+
+		switch board.Split {
+		case "":
+			board.Split = constants.SplitSum
+		case constants.SplitSum, constants.SplitAverage:
+		case constants.SplitTop:
+			if board.Members < 1 {
+				return fmt.Errorf("team board %q needs a positive member count for %s split", board.Name, board.Split)
+			}
+		default:
+			return fmt.Errorf("team board %q has unknown split %q", board.Name, board.Split)
+		}
+
+		switch board.RankType {
+		case "":
+			board.RankType = constants.RankCompetition
+		case constants.RankCompetition, constants.RankDense, constants.RankOrdinal:
+		default:
+			return fmt.Errorf("team board %q has unknown rank type %q", board.Name, board.RankType)
+		}
+
+		switch {
+		case board.SizeCap == 0:
+			board.SizeCap = constants.TopLeaderboardLimit
+		case board.SizeCap < 0 || board.SizeCap > constants.MaxBoardSizeCap:
+			return fmt.Errorf("team board %q needs a size cap between 1 and %d", board.Name, constants.MaxBoardSizeCap)
+		}
+	}
+
+	return nil
+}
+
+// TeamBoards lists every team board that is ranked as last loaded from the leaderboard
+// definitions, the first one is the default
+func (s *LeaderboardService) TeamBoards() []models.TeamBoard {
+	s.boardsMu.RLock()
+	defer s.boardsMu.RUnlock()
+
+	return s.teamBoards
+}
+
+// TeamScope is one team board over one window period
+type TeamScope struct {
+	Board  models.TeamBoard
+	Period Period
+}
+
+func (s TeamScope) String() string {
+	return fmt.Sprintf("%s:%s:%s", s.Board.Name, s.Period.Window, s.Period.Label())
+}
+
+// versioned identifies the team ranking in the keys of lists cached from it, as Scope.versioned
+func (s TeamScope) versioned() string {
+	return fmt.Sprintf("%s:v%d", s, s.Board.Version)
+}
+
+func teamScopeFilter(scope TeamScope) map[string]interface{} {
+	return map[string]interface{}{
+		constants.Board:       scope.Board.Name,
+		constants.TimeWindow:  scope.Period.Window,
+		constants.PeriodStart: scope.Period.Start.UTC(),
+	}
+}
+
+// ResolveTeamScope validates the requested team board, defaulting to the current all-time
+// default team board
+func (s *LeaderboardService) ResolveTeamScope(ctx context.Context, query request.TeamLeaderboardQuery) (TeamScope, apperror.Error) {
+	teamBoards := s.TeamBoards()
+	if len(teamBoards) == 0 {
+		return TeamScope{}, apperror.New(fmt.Errorf("no team board is defined"), http.StatusNotFound)
+	}
+
+	board := teamBoards[0]
+	if query.Board != "" {
+		found := false
+		for _, teamBoard := range teamBoards {
+			if teamBoard.Name == query.Board {
+				board, found = teamBoard, true
+				break
+			}
+		}
+
+		if !found {
+			return TeamScope{}, apperror.New(fmt.Errorf("team board %s not found", query.Board), http.StatusNotFound)
+		}
+	}
+
+	period, cusErr := s.resolvePeriod(ctx, query.Window, query.Period)
+	if cusErr.Exists() {
+		return TeamScope{}, cusErr
+	}
+
+	return TeamScope{Board: board, Period: period}, apperror.Error{}
+}
+
+// GetTopTeams retrieves the top teams of a team board period from the cached Postgres
+// standings, with each team's name
+func (s *LeaderboardService) GetTopTeams(ctx context.Context, scope TeamScope) ([]*models.TeamStanding, apperror.Error) {
+	txn := newrelic.FromContext(ctx)
+
+	cacheKey := fmt.Sprintf(constants.TeamTopKeyFormat, scope.versioned(), scope.Board.SizeCap)
+
+	// Cache lookup
+	var cachedTeams []*models.TeamStanding
+	found, err := s.redisClient.Get(ctx, cacheKey, &cachedTeams)
+	if err == nil && found {
+		if txn != nil {
+			txn.AddAttribute("cache_hit", true)
+		}
+		return cachedTeams, apperror.Error{}
+	}
+
+	if err != nil {
+		log.Printf("[WARN] team leaderboard cache get failed | err=%v", err)
+		if txn != nil {
+			txn.NoticeError(err)
+		}
+	}
+
+	teams, cusErr := s.teamLeaderboardRepository.GetAll(ctx, teamScopeFilter(scope), func(db *gorm.DB) *gorm.DB {
+		return db.Preload("Team").Order("rank ASC, team_id ASC").Limit(scope.Board.SizeCap)
+	})
+	if cusErr.Exists() {
+		if txn != nil {
+			txn.NoticeError(cusErr)
+		}
+		return nil, cusErr
+	}
+
+	// Cache set (non-blocking)
+	if _, err := s.redisClient.Set(ctx, cacheKey, teams, constants.OneHour); err != nil {
+		log.Printf("[WARN] team leaderboard cache set failed | err=%v", err)
+		if txn != nil {
+			txn.NoticeError(err)
+		}
+	}
+
+	return teams, apperror.Error{}
+}
+
+// GetTeamRank retrieves a team's standing on a team board period
+func (s *LeaderboardService) GetTeamRank(ctx context.Context, teamID int, scope TeamScope) (models.TeamStanding, apperror.Error) {
+	filter := teamScopeFilter(scope)
+	filter[constants.TeamID] = teamID
+
+	standings, cusErr := s.teamLeaderboardRepository.GetAll(ctx, filter, func(db *gorm.DB) *gorm.DB {
+		return db.Preload("Team").Limit(1)
+	})
+	if cusErr.Exists() {
+		if txn := newrelic.FromContext(ctx); txn != nil {
+			txn.NoticeError(cusErr)
+		}
+		return models.TeamStanding{}, cusErr
+	}
+
+	if len(standings) == 0 {
+		return models.TeamStanding{}, apperror.New(
+			fmt.Errorf("team %d is not ranked on %s", teamID, scope),
+			http.StatusNotFound,
+		)
+	}
+
+	return *standings[0], apperror.Error{}
+}
+
+// teamPeriodScopes pairs every team board with each of the given periods
+func (s *LeaderboardService) teamPeriodScopes(periods []Period) []TeamScope {
+	teamBoards := s.TeamBoards()
+	scopes := make([]TeamScope, 0, len(periods)*len(teamBoards))
+	for _, period := range periods {
+		for _, board := range teamBoards {
+			scopes = append(scopes, TeamScope{Board: board, Period: period})
+		}
+	}
+
+	return scopes
+}
+
+// InvalidateTeamTopCache drops the cached top list of the given team boards
+func (s *LeaderboardService) InvalidateTeamTopCache(ctx context.Context, scopes []TeamScope) error {
+	keys := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		keys = append(keys, fmt.Sprintf(constants.TeamTopKeyFormat, scope.versioned(), scope.Board.SizeCap))
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	if _, err := s.redisClient.Unlink(ctx, keys); err != nil {
+		if txn := newrelic.FromContext(ctx); txn != nil {
+			txn.NoticeError(err)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/models"
+	"gaming-leaderboard/pkg/apperror"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+// totalRanked returns how many users are ranked on the board, from the real-time ranking
+// unless it is empty. realtime reports which source answered.
+func (s *LeaderboardService) totalRanked(ctx context.Context, scope Scope) (total int64, realtime bool, err error) {
+	total, err = s.redisClient.ZCard(ctx, keysFor(scope).ranking)
+	if err == nil && total > 0 {
+		return total, true, nil
+	}
+
+	if err != nil {
+		log.Printf("[WARN] ranking size read failed | scope=%s | err=%v", scope, err)
+	}
+
+	total, err = s.repository.CountRanked(ctx, scope.Board.Name, scope.Period.Window, scope.Period.Start)
+	return total, false, err
+}
+
+// standing returns how many users are ranked on the board and how many of them score above
+// score, both from the same source
+func (s *LeaderboardService) standing(ctx context.Context, scope Scope, score int) (total, above int64, err error) {
+	total, realtime, err := s.totalRanked(ctx, scope)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if realtime {
+		above, err = s.redisClient.ZCount(ctx, keysFor(scope).ranking, "("+scoreMember(float64(score)), "+inf")
+		return total, above, err
+	}
+
+	above, err = s.repository.CountAbove(ctx, scope.Board.Name, scope.Period.Window, scope.Period.Start, score)
+	return total, above, err
+}
+
+// scoreAtPosition returns the score held by the user in the given 1-indexed position when the
+// board is ordered by score, from the real-time ranking unless it is empty
+func (s *LeaderboardService) scoreAtPosition(ctx context.Context, scope Scope, position int64) (int, bool, error) {
+	members, err := s.redisClient.ZRevRangeWithScores(ctx, keysFor(scope).ranking, position-1, position-1)
+	if err == nil && len(members) > 0 {
+		return int(members[0].Score), true, nil
+	}
+
+	if err != nil {
+		log.Printf("[WARN] ranking position read failed | scope=%s | err=%v", scope, err)
+	}
+
+	return s.repository.ScoreAtPosition(ctx, scope.Board.Name, scope.Period.Window, scope.Period.Start, position)
+}
+
+// topPercent is the smallest top percentage of the board a user with above higher scoring users falls into
+func topPercent(total, above int64) float64 {
+	return 100 * float64(above+1) / float64(total)
+}
+
+// tierFor places a score in the highest tier whose threshold it meets, else the last tier
+func tierFor(board models.Board, score int, topPercent float64) string {
+	if len(board.Tiers) == 0 {
+		return ""
+	}
+
+	for _, tier := range board.Tiers[:len(board.Tiers)-1] {
+		switch board.TierBasis {
+		case constants.TierBasisPercentile:
+			if topPercent <= tier.TopPercent {
+				return tier.Name
+			}
+		case constants.TierBasisScore:
+			if score >= tier.MinScore {
+				return tier.Name
+			}
+		}
+	}
+
+	return board.Tiers[len(board.Tiers)-1].Name
+}
+
+// GetUserStanding retrieves a user's rank along with their percentile and tier on the board.
+// The rank is still returned when the board totals cannot be read.
+func (s *LeaderboardService) GetUserStanding(
+	ctx context.Context,
+	userID string,
+	scope Scope,
+) (models.UserRank, apperror.Error) {
+	leader, cusErr := s.rankedUser(ctx, userID, scope)
+	if cusErr.Exists() {
+		return models.UserRank{}, cusErr
+	}
+
+	standing := models.UserRank{Leaderboard: leader}
+	standing.TotalScore = rankedScore(scope.Board, leader.TotalScore)
+
+	total, above, err := s.standing(ctx, scope, leader.TotalScore)
+	if err != nil || total == 0 {
+		log.Printf("[WARN] user standing read failed | user_id=%s | err=%v", userID, err)
+		if txn := newrelic.FromContext(ctx); txn != nil && err != nil {
+			txn.NoticeError(err)
+		}
+		return standing, apperror.Error{}
+	}
+
+	// The real-time score may lead the durable one, never count the user among those above
+	above = min(above, total-1)
+
+	standing.TotalRanked = total
+	standing.Percentile = math.Round(100*100*float64(total-above)/float64(total)) / 100
+	standing.Tier = tierFor(scope.Board, leader.TotalScore, topPercent(total, above))
+
+	return standing, apperror.Error{}
+}
+
+// GetBoardTiers reports the score currently needed to enter each of the board's tiers
+func (s *LeaderboardService) GetBoardTiers(ctx context.Context, scope Scope) (models.BoardTiers, apperror.Error) {
+	board := scope.Board
+
+	report := models.BoardTiers{
+		Board:     board.Name,
+		TierBasis: board.TierBasis,
+		Tiers:     make([]models.TierCutoff, 0, len(board.Tiers)),
+	}
+
+	total, _, err := s.totalRanked(ctx, scope)
+	if err != nil {
+		if txn := newrelic.FromContext(ctx); txn != nil {
+			txn.NoticeError(err)
+		}
+		return models.BoardTiers{}, apperror.New(err, http.StatusInternalServerError)
+	}
+	report.TotalRanked = total
+
+	for i, tier := range board.Tiers {
+		cutoff := models.TierCutoff{Tier: tier}
+
+		switch {
+		case i == len(board.Tiers)-1:
+			// The last tier takes everyone else
+		case board.TierBasis == constants.TierBasisScore:
+			minScore := tier.MinScore
+			cutoff.CutoffScore = &minScore
+		default:
+			// The lowest score inside the top percent, a small epsilon absorbs float error
+			position := int64(math.Floor(tier.TopPercent*float64(total)/100 + 1e-9))
+			if position < 1 {
+				break
+			}
+
+			score, found, err := s.scoreAtPosition(ctx, scope, position)
+			if err != nil {
+				return models.BoardTiers{}, apperror.New(err, http.StatusInternalServerError)
+			}
+			if found {
+				score = rankedScore(board, score)
+				cutoff.CutoffScore = &score
+			}
+		}
+
+		report.Tiers = append(report.Tiers, cutoff)
+	}
+
+	return report, apperror.Error{}
+}
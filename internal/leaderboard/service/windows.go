@@ -0,0 +1,76 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/models"
+)
+
+// Period is one occurrence of a time window, e.g. the week starting 2026-10-12.
+// Start and End are boundaries in the configured timezone, End is zero for the all-time window.
+type Period struct {
+	Window string
+	Start  time.Time
+	End    time.Time
+}
+
+// PeriodAt returns the period of window that contains t, with boundaries computed in loc
+func PeriodAt(window string, t time.Time, loc *time.Location) Period {
+	local := t.In(loc)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+
+	switch window {
+	case constants.WindowDaily:
+		return Period{Window: window, Start: day, End: day.AddDate(0, 0, 1)}
+	case constants.WindowWeekly:
+		// Weeks start on Monday
+		start := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+		return Period{Window: window, Start: start, End: start.AddDate(0, 0, 7)}
+	case constants.WindowMonthly:
+		start := time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, loc)
+		return Period{Window: window, Start: start, End: start.AddDate(0, 1, 0)}
+	default:
+		return Period{Window: constants.WindowAllTime, Start: time.Unix(0, 0).In(loc)}
+	}
+}
+
+// Bounded reports whether the period has an end, i.e. is not all-time
+func (p Period) Bounded() bool {
+	return !p.End.IsZero()
+}
+
+// Label identifies the period inside cache keys
+func (p Period) Label() string {
+	if !p.Bounded() {
+		// All-time periods restart at every season boundary
+		if p.Start.Unix() == 0 {
+			return "all"
+		}
+		return fmt.Sprintf("since-%d", p.Start.Unix())
+	}
+
+	return p.Start.Format(time.DateOnly)
+}
+
+// Contains reports whether t falls inside the period
+func (p Period) Contains(t time.Time) bool {
+	return !t.Before(p.Start) && (!p.Bounded() || t.Before(p.End))
+}
+
+// Scope identifies a single ranking: a board ranked over one period
+type Scope struct {
+	Board  models.Board
+	Period Period
+}
+
+func (s Scope) String() string {
+	return fmt.Sprintf("%s:%s:%s", s.Board.Name, s.Period.Window, s.Period.Label())
+}
+
+// versioned identifies the ranking in the keys of lists cached from it, which change with the
+// board's definition so nothing cached before the board was changed or recreated is served
+func (s Scope) versioned() string {
+	return fmt.Sprintf("%s:v%d", s, s.Board.Version)
+}
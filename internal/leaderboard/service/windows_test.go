@@ -0,0 +1,116 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/models"
+)
+
+func TestPeriodAt(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		window string
+		at     time.Time
+		loc    *time.Location
+		start  time.Time
+		end    time.Time
+	}{
+		{
+			name:   "daily",
+			window: constants.WindowDaily,
+			at:     time.Date(2026, 10, 17, 23, 59, 0, 0, time.UTC),
+			loc:    time.UTC,
+			start:  time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC),
+			end:    time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "daily in the configured timezone",
+			window: constants.WindowDaily,
+			at:     time.Date(2026, 10, 17, 23, 30, 0, 0, time.UTC),
+			loc:    berlin,
+			start:  time.Date(2026, 10, 18, 0, 0, 0, 0, berlin),
+			end:    time.Date(2026, 10, 19, 0, 0, 0, 0, berlin),
+		},
+		{
+			name:   "weekly starts on monday",
+			window: constants.WindowWeekly,
+			at:     time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC),
+			loc:    time.UTC,
+			start:  time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC),
+			end:    time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "weekly on a sunday belongs to the week before",
+			window: constants.WindowWeekly,
+			at:     time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC),
+			loc:    time.UTC,
+			start:  time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC),
+			end:    time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "weekly across the end of daylight saving",
+			window: constants.WindowWeekly,
+			at:     time.Date(2026, 10, 28, 12, 0, 0, 0, berlin),
+			loc:    berlin,
+			start:  time.Date(2026, 10, 26, 0, 0, 0, 0, berlin),
+			end:    time.Date(2026, 11, 2, 0, 0, 0, 0, berlin),
+		},
+		{
+			name:   "monthly",
+			window: constants.WindowMonthly,
+			at:     time.Date(2026, 12, 31, 23, 0, 0, 0, time.UTC),
+			loc:    time.UTC,
+			start:  time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC),
+			end:    time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "all-time starts at the epoch and never ends",
+			window: constants.WindowAllTime,
+			at:     time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC),
+			loc:    time.UTC,
+			start:  time.Unix(0, 0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			period := PeriodAt(tt.window, tt.at, tt.loc)
+
+			if period.Window != tt.window {
+				t.Errorf("window = %q, want %q", period.Window, tt.window)
+			}
+			if !period.Start.Equal(tt.start) {
+				t.Errorf("start = %v, want %v", period.Start, tt.start)
+			}
+			if !period.End.Equal(tt.end) {
+				t.Errorf("end = %v, want %v", period.End, tt.end)
+			}
+			if !period.Contains(tt.at) {
+				t.Errorf("period %v..%v does not contain %v", period.Start, period.End, tt.at)
+			}
+		})
+	}
+}
+
+func TestScopeVersioned(t *testing.T) {
+	period := PeriodAt(constants.WindowDaily, time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC), time.UTC)
+	board := models.Board{Name: "global", Version: 1}
+	changed := board
+	changed.Version = 2
+
+	before, after := Scope{Board: board, Period: period}, Scope{Board: changed, Period: period}
+
+	if before.String() != after.String() {
+		t.Errorf("ranking %q moved to %q when the definition changed", before, after)
+	}
+	if before.versioned() == after.versioned() {
+		t.Errorf("cached lists of both definitions are keyed %q", before.versioned())
+	}
+}
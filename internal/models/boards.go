@@ -0,0 +1,76 @@
+package models
+
+// Board declares one ranked leaderboard: which sessions it counts and how they are folded
+// into a player's score
+type Board struct {
+	Name     string `gorm:"unique;not null;column:name" mapstructure:"name" json:"name"`
+	GameMode string `gorm:"not null;column:game_mode" mapstructure:"gameMode" json:"game_mode"`
+	// Windows are the time windows the board is ranked over, every window when empty
+	Windows     []string `gorm:"serializer:json;column:windows" mapstructure:"windows" json:"windows,omitempty"`
+	Aggregation string   `gorm:"not null;column:aggregation" mapstructure:"aggregation" json:"aggregation"`
+	// Sessions is K for the average over the last K sessions, N for the sum of the best N
+	Sessions int `gorm:"not null;column:sessions" mapstructure:"sessions" json:"sessions,omitempty"`
+	// RankType is competition (1224), dense (1223) or ordinal (1234) ranking of tied scores
+	RankType string `gorm:"not null;column:rank_type" mapstructure:"rankType" json:"rank_type"`
+	// TieBreaker orders players with equal scores, by who reached the score first or by user id
+	TieBreaker string `gorm:"not null;column:tie_breaker" mapstructure:"tieBreaker" json:"tie_breaker"`
+	// SortDirection is desc when the highest score leads the board, asc when the lowest does
+	SortDirection string `gorm:"not null;column:sort_direction" mapstructure:"sortDirection" json:"sort_direction"`
+	// SizeCap is how many entries the board's top lists hold
+	SizeCap int `gorm:"not null;column:size_cap" mapstructure:"sizeCap" json:"size_cap"`
+	// RefreshSeconds is how often the worker recalculates the board in full rather than folding
+	// in only new sessions, its reconcile interval when zero
+	RefreshSeconds int `gorm:"not null;column:refresh_seconds" mapstructure:"refreshSeconds" json:"refresh_seconds,omitempty"`
+	// CacheSeconds is how long the board's top lists, ranks and neighbours read from Postgres
+	// stay cached, each their own default when zero
+	CacheSeconds int `gorm:"not null;column:cache_seconds" mapstructure:"cacheSeconds" json:"cache_seconds,omitempty"`
+	// TierBasis is whether Tiers are placed by percentile or by score
+	TierBasis string `gorm:"not null;column:tier_basis" mapstructure:"tierBasis" json:"tier_basis,omitempty"`
+	// Tiers are ordered from the highest, the last one takes everyone not placed higher
+	Tiers []Tier `gorm:"serializer:json;column:tiers" mapstructure:"tiers" json:"tiers,omitempty"`
+	// Version changes whenever the board's definition does, so lists cached under it go stale
+	Version int64 `gorm:"-" mapstructure:"-" json:"-"`
+}
+
+// Tier is one division of a board, entered by ranking within the top TopPercent of players
+// or by scoring at least MinScore, depending on the board's tier basis
+type Tier struct {
+	Name       string  `mapstructure:"name" json:"name"`
+	TopPercent float64 `mapstructure:"topPercent" json:"top_percent,omitempty"`
+	MinScore   int     `mapstructure:"minScore" json:"min_score,omitempty"`
+}
+
+// TierCutoff is the score currently needed to enter a tier. It is nil when nobody qualifies
+// for a percentile tier yet, and for the last tier, which takes everyone else.
+type TierCutoff struct {
+	Tier
+	CutoffScore *int `json:"cutoff_score"`
+}
+
+// BoardTiers reports a board's tiers as they currently stand
+type BoardTiers struct {
+	Board       string       `json:"board"`
+	TierBasis   string       `json:"tier_basis"`
+	TotalRanked int64        `json:"total_ranked"`
+	Tiers       []TierCutoff `json:"tiers"`
+}
+
+// TeamBoard declares one ranked team leaderboard over team-mode sessions. Each member's
+// sessions for the team are first folded into a member score by the aggregation, then the
+// split rule combines the member scores into the team's.
+type TeamBoard struct {
+	Name        string `mapstructure:"name" json:"name"`
+	Aggregation string `mapstructure:"aggregation" json:"aggregation"`
+	// Sessions is K for the average over the last K sessions, N for the sum of the best N
+	Sessions int `mapstructure:"sessions" json:"sessions,omitempty"`
+	// Split is sum (every member's score adds up), average (the mean member score) or top (the
+	// sum of the best Members member scores)
+	Split   string `mapstructure:"split" json:"split"`
+	Members int    `mapstructure:"members" json:"members,omitempty"`
+	// RankType is competition (1224), dense (1223) or ordinal (1234) ranking of tied scores
+	RankType string `mapstructure:"rankType" json:"rank_type"`
+	// SizeCap is how many teams the team board's top list holds
+	SizeCap int `mapstructure:"sizeCap" json:"size_cap"`
+	// Version changes whenever the team board's definition does, as a board's Version
+	Version int64 `mapstructure:"-" json:"-"`
+}
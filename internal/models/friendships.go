@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Friendship puts FriendID in UserID's friend set. Mutual friendships are held as a row each
+// way, a follow only as the follower's row.
+type Friendship struct {
+	UserID    int       `gorm:"primaryKey;autoIncrement:false;column:user_id" json:"user_id"`
+	FriendID  int       `gorm:"primaryKey;autoIncrement:false;column:friend_id" json:"friend_id"`
+	Kind      string    `gorm:"not null;column:kind" json:"kind"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+
+	Friend User `gorm:"foreignKey:FriendID;references:ID" json:"friend"`
+}
+
+func (Friendship) TableName() string {
+	return "friendships"
+}
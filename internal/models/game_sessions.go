@@ -8,6 +8,25 @@ type GameSession struct {
 	Score     int       `gorm:"not null;column:score" json:"score"`
 	GameMode  string    `gorm:"not null;column:game_mode" json:"game_mode"`
 	Timestamp time.Time `gorm:"column:timestamp;autoCreateTime" json:"timestamp"`
+	// TeamID is the team a team-mode session was played for, the user's team when submitted
+	TeamID *int `gorm:"column:team_id" json:"team_id,omitempty"`
+	// Region is the user's country when submitted, if they had one. Regional boards rank the
+	// session in this region whatever country the user moves to later.
+	Region *string `gorm:"column:region" json:"region,omitempty"`
+	// ClientSessionID is the client's idempotency key, a user's retries carrying it are recorded once
+	ClientSessionID *string `gorm:"column:client_session_id" json:"client_session_id,omitempty"`
+	// PlaySessionID is the play session opened by POST /sessions/start, each records one score
+	PlaySessionID *string    `gorm:"column:play_session_id" json:"play_session_id,omitempty"`
+	StartedAt     *time.Time `gorm:"column:started_at" json:"started_at,omitempty"`
+	DurationMs    *int64     `gorm:"column:duration_ms" json:"duration_ms,omitempty"`
+	// Status is accepted, or quarantined by anti-cheat until a review accepts or rejects it
+	Status     string     `gorm:"not null;column:status;default:accepted" json:"status"`
+	FlagReason *string    `gorm:"column:flag_reason" json:"flag_reason,omitempty"`
+	ReviewedAt *time.Time `gorm:"column:reviewed_at" json:"reviewed_at,omitempty"`
+	// VoidedAt is set while a moderator has voided the session, a voided session never counts
+	VoidedAt   *time.Time `gorm:"column:voided_at" json:"voided_at,omitempty"`
+	VoidReason *string    `gorm:"column:void_reason" json:"void_reason,omitempty"`
+	VoidedBy   *string    `gorm:"column:voided_by" json:"voided_by,omitempty"`
 
 	User User `gorm:"foreignKey:UserID;references:ID" json:"-"`
 }
@@ -15,3 +34,31 @@ type GameSession struct {
 func (GameSession) TableName() string {
 	return "game_sessions"
 }
+
+// Keyed reports whether the session carries a key that makes repeats of it collide
+func (g *GameSession) Keyed() bool {
+	return g.ClientSessionID != nil || g.PlaySessionID != nil
+}
+
+// ScoreStats summarises a set of session scores
+type ScoreStats struct {
+	Count  int64   `gorm:"column:count"`
+	Mean   float64 `gorm:"column:mean"`
+	StdDev float64 `gorm:"column:std_dev"`
+}
+
+// RejectedSubmission is the outcome of a submission refused under a client session id, retries
+// under the same id are refused the same way
+type RejectedSubmission struct {
+	UserID          int       `gorm:"primaryKey;column:user_id"`
+	ClientSessionID string    `gorm:"primaryKey;column:client_session_id"`
+	Score           int       `gorm:"not null;column:score"`
+	GameMode        string    `gorm:"not null;column:game_mode"`
+	StatusCode      int       `gorm:"not null;column:status_code"`
+	Error           string    `gorm:"not null;column:error"`
+	CreatedAt       time.Time `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (RejectedSubmission) TableName() string {
+	return "rejected_submissions"
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Game is one tenant of the service. Its players, sessions and leaderboards live in their own
+// Postgres schema and under their own Redis key prefix, and its API key picks it on requests.
+type Game struct {
+	ID   int    `gorm:"primaryKey;column:id" json:"id"`
+	Slug string `gorm:"unique;not null;column:slug" json:"slug"`
+	Name string `gorm:"not null;column:name" json:"name"`
+	// SchemaName is the Postgres schema holding the game's tables
+	SchemaName string `gorm:"unique;not null;column:schema_name" json:"schema_name"`
+	// APIKeyHash is the SHA-256 of the game's API key, the key itself is never stored
+	APIKeyHash string `gorm:"unique;not null;column:api_key_hash" json:"-"`
+	// Boards seed the game's leaderboard definitions while it has none, the configured boards
+	// seed them when empty
+	Boards    []Board   `gorm:"serializer:json;column:boards" json:"boards,omitempty"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (Game) TableName() string {
+	return "public.games"
+}
@@ -1,10 +1,27 @@
 package models
 
+import "time"
+
 type Leaderboard struct {
-	ID         int `gorm:"primaryKey;column:id" json:"id"`
-	UserID     int `gorm:"not null;column:user_id" json:"user_id"`
-	TotalScore int `gorm:"not null;column:total_score" json:"total_score"`
-	Rank       int `gorm:"column:rank" json:"rank"`
+	ID          int       `gorm:"primaryKey;column:id" json:"id"`
+	UserID      int       `gorm:"not null;column:user_id" json:"user_id"`
+	Board       string    `gorm:"not null;column:board" json:"board"`
+	GameMode    string    `gorm:"not null;column:game_mode" json:"game_mode"`
+	TimeWindow  string    `gorm:"not null;column:time_window" json:"window"`
+	PeriodStart time.Time `gorm:"not null;column:period_start" json:"period_start"`
+	TotalScore  int       `gorm:"not null;column:total_score" json:"total_score"`
+	Rank        int       `gorm:"column:rank" json:"rank"`
+	ReachedAt   time.Time `gorm:"column:reached_at" json:"reached_at"`
+	// PreviousRank is the rank held at the end of the last recorded day, nil when none was recorded
+	PreviousRank *int `gorm:"-" json:"previous_rank"`
+	// RankDelta is how many places the user climbed since PreviousRank, negative when they fell
+	RankDelta *int `gorm:"-" json:"rank_delta"`
+	// Region is where the user is ranked regionally, the country they submitted their latest
+	// counted session on the board period from, and RegionalRank their rank among the users who
+	// played from there, counting only sessions from there. Both are left out when none of the
+	// user's sessions recorded a country.
+	Region       string `gorm:"-" json:"region,omitempty"`
+	RegionalRank *int   `gorm:"-" json:"regional_rank,omitempty"`
 
 	User User `gorm:"foreignKey:UserID;references:ID" json:"user"`
 }
@@ -14,3 +31,12 @@ func (Leaderboard) TableName() string {
 }
 
 type LeaderboardSlice []*Leaderboard
+
+// UserRank is a user's standing on a board along with where it places them among every ranked user
+type UserRank struct {
+	Leaderboard
+	TotalRanked int64 `json:"total_ranked"`
+	// Percentile is the share of ranked users scoring no higher than this user
+	Percentile float64 `json:"percentile"`
+	Tier       string  `json:"tier,omitempty"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// LeaderboardDefinition is a board or team board as stored for a game and managed through the
+// admin API. Every definition is ranked, so boards are launched and changed without a deploy.
+// Boards and team boards share the definitions' names.
+type LeaderboardDefinition struct {
+	ID int `gorm:"primaryKey;column:id" json:"id"`
+	// Kind is board for a board ranking players, team for a team board ranking teams
+	Kind  string `gorm:"not null;column:kind" json:"kind"`
+	Board `gorm:"embedded"`
+	// Split and Members are how a team board combines its members' scores, unset on boards
+	Split     string    `gorm:"not null;column:split" json:"split,omitempty"`
+	Members   int       `gorm:"not null;column:members" json:"members,omitempty"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (LeaderboardDefinition) TableName() string {
+	return "leaderboard_definitions"
+}
+
+// TeamBoard is the team board a team definition declares
+func (d LeaderboardDefinition) TeamBoard() TeamBoard {
+	return TeamBoard{
+		Name:        d.Name,
+		Aggregation: d.Aggregation,
+		Sessions:    d.Sessions,
+		Split:       d.Split,
+		Members:     d.Members,
+		RankType:    d.RankType,
+		SizeCap:     d.SizeCap,
+		Version:     d.Version(),
+	}
+}
+
+// Version is the version of the board or team board as last updated
+func (d LeaderboardDefinition) Version() int64 {
+	return d.UpdatedAt.UnixMicro()
+}
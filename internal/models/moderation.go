@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// LeaderboardBan keeps a user off every board. A shadow-banned user can still submit and see
+// their own standing, a banned user's submissions are refused.
+type LeaderboardBan struct {
+	UserID    int       `gorm:"primaryKey;autoIncrement:false;column:user_id" json:"user_id"`
+	Kind      string    `gorm:"not null;column:kind" json:"kind"`
+	Reason    string    `gorm:"not null;column:reason" json:"reason"`
+	Actor     string    `gorm:"not null;column:actor" json:"actor"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (LeaderboardBan) TableName() string {
+	return "leaderboard_bans"
+}
+
+// ModerationAction is one audited moderator change to a session or user
+type ModerationAction struct {
+	ID         int       `gorm:"primaryKey;column:id" json:"id"`
+	Action     string    `gorm:"not null;column:action" json:"action"`
+	TargetType string    `gorm:"not null;column:target_type" json:"target_type"`
+	TargetID   int       `gorm:"not null;column:target_id" json:"target_id"`
+	Reason     string    `gorm:"not null;column:reason" json:"reason"`
+	Actor      string    `gorm:"not null;column:actor" json:"actor"`
+	CreatedAt  time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (ModerationAction) TableName() string {
+	return "moderation_actions"
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// RankSnapshot is a user's standing on a board period as of the end of a day
+type RankSnapshot struct {
+	ID          int       `gorm:"primaryKey;column:id" json:"id"`
+	UserID      int       `gorm:"not null;column:user_id" json:"user_id"`
+	Board       string    `gorm:"not null;column:board" json:"board"`
+	TimeWindow  string    `gorm:"not null;column:time_window" json:"window"`
+	PeriodStart time.Time `gorm:"not null;column:period_start" json:"period_start"`
+	RecordedOn  time.Time `gorm:"not null;column:recorded_on" json:"recorded_on"`
+	TotalScore  int       `gorm:"not null;column:total_score" json:"total_score"`
+	Rank        int       `gorm:"column:rank" json:"rank"`
+}
+
+func (RankSnapshot) TableName() string {
+	return "rank_history"
+}
+
+type RankSnapshotSlice []*RankSnapshot
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+type Season struct {
+	ID      int        `gorm:"primaryKey;column:id" json:"id"`
+	Name    string     `gorm:"not null;column:name" json:"name"`
+	StartAt time.Time  `gorm:"not null;column:start_at" json:"start_at"`
+	EndAt   *time.Time `gorm:"column:end_at" json:"end_at"`
+	Status  string     `gorm:"not null;column:status" json:"status"`
+}
+
+func (Season) TableName() string {
+	return "seasons"
+}
+
+type SeasonStanding struct {
+	ID         int       `gorm:"primaryKey;column:id" json:"id"`
+	SeasonID   int       `gorm:"not null;column:season_id" json:"season_id"`
+	UserID     int       `gorm:"not null;column:user_id" json:"user_id"`
+	Board      string    `gorm:"not null;column:board" json:"board"`
+	GameMode   string    `gorm:"not null;column:game_mode" json:"game_mode"`
+	TotalScore int       `gorm:"not null;column:total_score" json:"total_score"`
+	Rank       int       `gorm:"column:rank" json:"rank"`
+	ReachedAt  time.Time `gorm:"column:reached_at" json:"reached_at"`
+}
+
+func (SeasonStanding) TableName() string {
+	return "season_standings"
+}
+
+type SeasonStandingSlice []*SeasonStanding
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+type Team struct {
+	ID        int       `gorm:"primaryKey;column:id" json:"id"`
+	Name      string    `gorm:"unique;not null;column:name" json:"name"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+
+	Members []TeamMember `gorm:"foreignKey:TeamID;references:ID" json:"members,omitempty"`
+}
+
+func (Team) TableName() string {
+	return "teams"
+}
+
+// TeamMember places a user in a team, a user belongs to at most one team at a time
+type TeamMember struct {
+	UserID   int       `gorm:"primaryKey;autoIncrement:false;column:user_id" json:"user_id"`
+	TeamID   int       `gorm:"not null;column:team_id" json:"team_id"`
+	JoinedAt time.Time `gorm:"column:joined_at" json:"joined_at"`
+
+	User User `gorm:"foreignKey:UserID;references:ID" json:"user"`
+}
+
+func (TeamMember) TableName() string {
+	return "team_members"
+}
+
+// TeamStanding is a team's place on one team board period
+type TeamStanding struct {
+	ID          int       `gorm:"primaryKey;column:id" json:"id"`
+	TeamID      int       `gorm:"not null;column:team_id" json:"team_id"`
+	Board       string    `gorm:"not null;column:board" json:"board"`
+	TimeWindow  string    `gorm:"not null;column:time_window" json:"window"`
+	PeriodStart time.Time `gorm:"not null;column:period_start" json:"period_start"`
+	TotalScore  int       `gorm:"not null;column:total_score" json:"total_score"`
+	Rank        int       `gorm:"column:rank" json:"rank"`
+	ReachedAt   time.Time `gorm:"column:reached_at" json:"reached_at"`
+	// Members is how many members' scores made up the team's
+	Members int `gorm:"not null;column:members" json:"members"`
+
+	Team Team `gorm:"foreignKey:TeamID;references:ID" json:"team"`
+}
+
+func (TeamStanding) TableName() string {
+	return "team_leaderboard"
+}
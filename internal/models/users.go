@@ -6,4 +6,9 @@ type User struct {
 	ID       int       `gorm:"primaryKey;column:id" json:"id"`
 	Username string    `gorm:"unique;not null;column:username" json:"username"`
 	JoinDate time.Time `gorm:"column:join_date;autoCreateTime" json:"join_date"`
+	// DisplayName, AvatarURL and Country are the profile shown next to the user on boards,
+	// empty when not set. Country is an ISO 3166-1 alpha-2 code.
+	DisplayName string `gorm:"not null;column:display_name" json:"display_name,omitempty"`
+	AvatarURL   string `gorm:"not null;column:avatar_url" json:"avatar_url,omitempty"`
+	Country     string `gorm:"not null;column:country" json:"country,omitempty"`
 }
@@ -70,7 +70,8 @@ func (r *Repository[T]) GetAllWithPagination(
 
 	db := r.Db.GetSlaveDB(ctx).Model(new(T)).Where(filter).Scopes(scopes...)
 
-	if errTx := db.Count(&count); errTx.Error != nil {
+	// Count across every page, ignoring any pagination applied by the scopes
+	if errTx := db.Session(&gorm.Session{}).Limit(-1).Offset(-1).Count(&count); errTx.Error != nil {
 		log.Println(logTag, "Error while counting records:", errTx.Error)
 		return nil, 0, apperror.New(errTx.Error, http.StatusBadRequest)
 	}
@@ -79,7 +80,7 @@ func (r *Repository[T]) GetAllWithPagination(
 		return []*T{}, 0, apperror.Error{}
 	}
 
-	if errTx := db.Find(&results); errTx.Error != nil {
+	if errTx := db.Session(&gorm.Session{}).Find(&results); errTx.Error != nil {
 		log.Println(logTag, "Error while fetching records:", errTx.Error)
 		return nil, 0, apperror.New(errTx.Error, http.StatusBadRequest)
 	}
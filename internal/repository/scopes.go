@@ -0,0 +1,14 @@
+package repository
+
+import "gorm.io/gorm"
+
+// Paginate limits a query to a single 1-indexed page
+func Paginate(page, limit int) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if page < 1 {
+			page = 1
+		}
+
+		return db.Offset((page - 1) * limit).Limit(limit)
+	}
+}
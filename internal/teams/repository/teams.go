@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"log"
+
+	"gaming-leaderboard/internal/models"
+	"gaming-leaderboard/internal/repository"
+	"gaming-leaderboard/pkg/db/postgres"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TeamsRepository struct {
+	repository.Interface[models.Team]
+	db *postgres.DbCluster
+}
+
+func NewTeamsRepository(db *postgres.DbCluster) *TeamsRepository {
+	return &TeamsRepository{
+		Interface: &repository.Repository[models.Team]{Db: db},
+		db:        db,
+	}
+}
+
+// CreateUnique inserts team unless its name is taken, in which case created is false
+func (r *TeamsRepository) CreateUnique(ctx context.Context, team *models.Team) (created bool, err error) {
+	tx := r.db.GetMasterDB(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "name"}}, DoNothing: true}).
+		Omit("Members").
+		Create(team)
+	if tx.Error != nil {
+		log.Printf("[ERROR] CreateUnique: team name=%s | err=%v", team.Name, tx.Error)
+		return false, tx.Error
+	}
+
+	return tx.RowsAffected == 1, nil
+}
+
+// GetWithMembers returns a team with its members and their profiles, found is false when there
+// is no such team
+func (r *TeamsRepository) GetWithMembers(ctx context.Context, teamID int) (team models.Team, found bool, err error) {
+	if err := r.db.GetSlaveDB(ctx).
+		Preload("Members", func(db *gorm.DB) *gorm.DB { return db.Order("joined_at ASC, user_id ASC") }).
+		Preload("Members.User").
+		Where("id = ?", teamID).
+		Limit(1).
+		Find(&team).Error; err != nil {
+		log.Printf("[ERROR] GetWithMembers: team_id=%d | err=%v", teamID, err)
+		return models.Team{}, false, err
+	}
+
+	return team, team.ID != 0, nil
+}
+
+// AddMember places a user in a team. teamFound and userFound are false when either does not
+// exist, and currentTeam is the team the user is already in, in which case nothing changes.
+func (r *TeamsRepository) AddMember(
+	ctx context.Context,
+	member *models.TeamMember,
+) (teamFound bool, userFound bool, currentTeam int, err error) {
+	err = r.db.GetMasterDB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Raw(
+			"SELECT EXISTS (SELECT 1 FROM teams WHERE id = ?), EXISTS (SELECT 1 FROM users WHERE id = ?)",
+			member.TeamID, member.UserID,
+		).Row().Scan(&teamFound, &userFound); err != nil {
+			return err
+		}
+		if !teamFound || !userFound {
+			return nil
+		}
+
+		inserted := tx.Clauses(clause.OnConflict{DoNothing: true}).Omit("User").Create(member)
+		if inserted.Error != nil {
+			return inserted.Error
+		}
+		if inserted.RowsAffected == 1 {
+			return nil
+		}
+
+		return tx.Model(&models.TeamMember{}).
+			Where("user_id = ?", member.UserID).
+			Pluck("team_id", &currentTeam).Error
+	})
+	if err != nil {
+		log.Printf("[ERROR] AddMember: team_id=%d | user_id=%d | err=%v", member.TeamID, member.UserID, err)
+		return false, false, 0, err
+	}
+
+	return teamFound, userFound, currentTeam, nil
+}
+
+// RemoveMember takes a user out of a team, found is false when the user was not in it
+func (r *TeamsRepository) RemoveMember(ctx context.Context, teamID int, userID int) (found bool, err error) {
+	tx := r.db.GetMasterDB(ctx).
+		Where("team_id = ? AND user_id = ?", teamID, userID).
+		Delete(&models.TeamMember{})
+	if tx.Error != nil {
+		log.Printf("[ERROR] RemoveMember: team_id=%d | user_id=%d | err=%v", teamID, userID, tx.Error)
+		return false, tx.Error
+	}
+
+	return tx.RowsAffected == 1, nil
+}
+
+// MemberTeams returns the team each of userIDs is in, users in no team are left out. It reads
+// from the master so a member who just joined plays for the team.
+func (r *TeamsRepository) MemberTeams(ctx context.Context, userIDs []int) (map[int]int, error) {
+	teams := make(map[int]int)
+	if len(userIDs) == 0 {
+		return teams, nil
+	}
+
+	var members []models.TeamMember
+	if err := r.db.GetMasterDB(ctx).
+		Select("user_id", "team_id").
+		Where("user_id IN ?", userIDs).
+		Find(&members).Error; err != nil {
+		log.Printf("[ERROR] MemberTeams: users=%d | err=%v", len(userIDs), err)
+		return nil, err
+	}
+
+	for _, member := range members {
+		teams[member.UserID] = member.TeamID
+	}
+
+	return teams, nil
+}
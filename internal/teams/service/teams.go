@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"gaming-leaderboard/internal/controller/request"
+	"gaming-leaderboard/internal/models"
+	"gaming-leaderboard/internal/teams/repository"
+	"gaming-leaderboard/pkg/apperror"
+)
+
+type TeamsService struct {
+	repository *repository.TeamsRepository
+}
+
+func NewTeamsService(repo *repository.TeamsRepository) *TeamsService {
+	return &TeamsService{
+		repository: repo,
+	}
+}
+
+// CreateTeam registers a team under a name no other team has
+func (s *TeamsService) CreateTeam(ctx context.Context, req request.CreateTeamRequest) (models.Team, apperror.Error) {
+	team := models.Team{
+		Name:      strings.TrimSpace(req.Name),
+		CreatedAt: time.Now().UTC(),
+	}
+	if team.Name == "" {
+		return models.Team{}, apperror.New(fmt.Errorf("team name must not be blank"), http.StatusBadRequest)
+	}
+
+	created, err := s.repository.CreateUnique(ctx, &team)
+	if err != nil {
+		return models.Team{}, apperror.New(fmt.Errorf("unable to create team"), http.StatusInternalServerError)
+	}
+
+	if !created {
+		return models.Team{}, apperror.New(fmt.Errorf("team name %q is taken", team.Name), http.StatusConflict)
+	}
+
+	log.Printf("[INFO] team created | team_id=%d", team.ID)
+	return team, apperror.Error{}
+}
+
+// GetTeam returns one team with its members
+func (s *TeamsService) GetTeam(ctx context.Context, teamID int) (models.Team, apperror.Error) {
+	team, found, err := s.repository.GetWithMembers(ctx, teamID)
+	if err != nil {
+		return models.Team{}, apperror.New(fmt.Errorf("unable to get team"), http.StatusInternalServerError)
+	}
+
+	if !found {
+		return models.Team{}, apperror.New(fmt.Errorf("team %d not found", teamID), http.StatusNotFound)
+	}
+
+	return team, apperror.Error{}
+}
+
+// AddMember places a user in a team. A user plays for one team at a time, so one already in a
+// team has to leave it first.
+func (s *TeamsService) AddMember(
+	ctx context.Context,
+	teamID int,
+	req request.AddTeamMemberRequest,
+) (models.TeamMember, apperror.Error) {
+	member := models.TeamMember{
+		UserID:   req.UserID,
+		TeamID:   teamID,
+		JoinedAt: time.Now().UTC(),
+	}
+
+	teamFound, userFound, currentTeam, err := s.repository.AddMember(ctx, &member)
+	if err != nil {
+		return models.TeamMember{}, apperror.New(fmt.Errorf("unable to add team member"), http.StatusInternalServerError)
+	}
+
+	if !teamFound {
+		return models.TeamMember{}, apperror.New(fmt.Errorf("team %d not found", teamID), http.StatusNotFound)
+	}
+
+	if !userFound {
+		return models.TeamMember{}, apperror.New(fmt.Errorf("user %d not found", req.UserID), http.StatusNotFound)
+	}
+
+	if currentTeam != 0 {
+		return models.TeamMember{}, apperror.New(
+			fmt.Errorf("user %d is already in team %d", req.UserID, currentTeam),
+			http.StatusConflict,
+		)
+	}
+
+	log.Printf("[INFO] team member added | team_id=%d | user_id=%d", teamID, req.UserID)
+	return member, apperror.Error{}
+}
+
+// RemoveMember takes a user out of a team. Sessions they already played keep counting for it.
+func (s *TeamsService) RemoveMember(ctx context.Context, teamID int, userID int) apperror.Error {
+	found, err := s.repository.RemoveMember(ctx, teamID, userID)
+	if err != nil {
+		return apperror.New(fmt.Errorf("unable to remove team member"), http.StatusInternalServerError)
+	}
+
+	if !found {
+		return apperror.New(fmt.Errorf("user %d is not in team %d", userID, teamID), http.StatusNotFound)
+	}
+
+	log.Printf("[INFO] team member removed | team_id=%d | user_id=%d", teamID, userID)
+	return apperror.Error{}
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"log"
+
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/models"
+	"gaming-leaderboard/internal/repository"
+	"gaming-leaderboard/pkg/db/postgres"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FriendshipsRepository struct {
+	repository.Interface[models.Friendship]
+	db *postgres.DbCluster
+}
+
+func NewFriendshipsRepository(db *postgres.DbCluster) *FriendshipsRepository {
+	return &FriendshipsRepository{
+		Interface: &repository.Repository[models.Friendship]{Db: db},
+		db:        db,
+	}
+}
+
+// Befriend adds friendship, along with the row back for a mutual one. An existing follow is
+// upgraded to a mutual friendship but a mutual friendship is never downgraded to a follow, so
+// the returned friendship holds the kind in place afterwards. usersFound is false when either
+// user does not exist, in which case nothing changes.
+func (r *FriendshipsRepository) Befriend(
+	ctx context.Context,
+	friendship models.Friendship,
+) (saved models.Friendship, usersFound bool, err error) {
+	rows := []models.Friendship{friendship}
+	if friendship.Kind == constants.FriendshipMutual {
+		rows = append(rows, models.Friendship{
+			UserID:    friendship.FriendID,
+			FriendID:  friendship.UserID,
+			Kind:      friendship.Kind,
+			CreatedAt: friendship.CreatedAt,
+		})
+	}
+
+	err = r.db.GetMasterDB(ctx).Transaction(func(tx *gorm.DB) error {
+		var users int64
+		if err := tx.Model(&models.User{}).
+			Where("id IN ?", []int{friendship.UserID, friendship.FriendID}).
+			Count(&users).Error; err != nil {
+			return err
+		}
+		if usersFound = users == 2; !usersFound {
+			return nil
+		}
+
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "friend_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"kind": gorm.Expr("EXCLUDED.kind")}),
+			Where: clause.Where{Exprs: []clause.Expression{
+				clause.Neq{Column: clause.Column{Table: "friendships", Name: "kind"}, Value: constants.FriendshipMutual},
+			}},
+		}).Omit("Friend").Create(&rows).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("user_id = ? AND friend_id = ?", friendship.UserID, friendship.FriendID).
+			Take(&saved).Error
+	})
+	if err != nil {
+		log.Printf("[ERROR] Befriend: user_id=%d | friend_id=%d | err=%v", friendship.UserID, friendship.FriendID, err)
+		return models.Friendship{}, false, err
+	}
+
+	return saved, usersFound, nil
+}
+
+// Unfriend takes friendID out of userID's friend set, a mutual friendship ending both ways.
+// found is false when friendID was not in the set.
+func (r *FriendshipsRepository) Unfriend(ctx context.Context, userID int, friendID int) (found bool, err error) {
+	err = r.db.GetMasterDB(ctx).Transaction(func(tx *gorm.DB) error {
+		var removed models.Friendship
+		deleted := tx.Clauses(clause.Returning{}).
+			Where("user_id = ? AND friend_id = ?", userID, friendID).
+			Delete(&removed)
+		if deleted.Error != nil {
+			return deleted.Error
+		}
+
+		found = deleted.RowsAffected == 1
+		if !found || removed.Kind != constants.FriendshipMutual {
+			return nil
+		}
+
+		return tx.Where("user_id = ? AND friend_id = ?", friendID, userID).
+			Delete(&models.Friendship{}).Error
+	})
+	if err != nil {
+		log.Printf("[ERROR] Unfriend: user_id=%d | friend_id=%d | err=%v", userID, friendID, err)
+		return false, err
+	}
+
+	return found, nil
+}
+
+// FriendIDs lists the users in userID's friend set
+func (r *FriendshipsRepository) FriendIDs(ctx context.Context, userID int) ([]int, error) {
+	var ids []int
+	if err := r.db.GetSlaveDB(ctx).
+		Model(&models.Friendship{}).
+		Where("user_id = ?", userID).
+		Pluck("friend_id", &ids).Error; err != nil {
+		log.Printf("[ERROR] FriendIDs: user_id=%d | err=%v", userID, err)
+		return nil, err
+	}
+
+	return ids, nil
+}
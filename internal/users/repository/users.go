@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"gaming-leaderboard/internal/models"
+	"gaming-leaderboard/internal/repository"
+	"gaming-leaderboard/pkg/db/postgres"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UsersRepository struct {
+	repository.Interface[models.User]
+	db *postgres.DbCluster
+}
+
+func NewUsersRepository(db *postgres.DbCluster) *UsersRepository {
+	return &UsersRepository{
+		Interface: &repository.Repository[models.User]{Db: db},
+		db:        db,
+	}
+}
+
+// CreateUnique inserts user unless its username is taken, in which case created is false
+func (r *UsersRepository) CreateUnique(ctx context.Context, user *models.User) (created bool, err error) {
+	tx := r.db.GetMasterDB(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "username"}}, DoNothing: true}).
+		Create(user)
+	if tx.Error != nil {
+		log.Printf("[ERROR] CreateUnique: username=%s | err=%v", user.Username, tx.Error)
+		return false, tx.Error
+	}
+
+	return tx.RowsAffected == 1, nil
+}
+
+// Update applies updates to a user. found is false when there is no such user, and taken true
+// when updates renames the user to a username another user has, in which case nothing changes.
+func (r *UsersRepository) Update(
+	ctx context.Context,
+	userID int,
+	updates map[string]interface{},
+) (user models.User, found bool, taken bool, err error) {
+	db := r.db.GetMasterDB(ctx)
+
+	tx := db.Model(&user).
+		Clauses(clause.Returning{}).
+		Where("id = ?", userID).
+		Updates(updates)
+	if tx.Error != nil {
+		// A unique violation, unless the lookup finds the username free again
+		if username, ok := updates["username"]; ok {
+			var holder models.User
+			if err := db.Where("username = ? AND id <> ?", username, userID).Limit(1).Find(&holder).Error; err == nil && holder.ID != 0 {
+				return models.User{}, true, true, nil
+			}
+		}
+
+		log.Printf("[ERROR] Update: user_id=%d | err=%v", userID, tx.Error)
+		return models.User{}, false, false, tx.Error
+	}
+
+	return user, tx.RowsAffected == 1, false, nil
+}
+
+// UsernamePrefix narrows a query to the users whose username starts with prefix, ignoring case
+func UsernamePrefix(prefix string) func(db *gorm.DB) *gorm.DB {
+	pattern := likeEscaper.Replace(prefix) + "%"
+
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("username ILIKE ?", pattern)
+	}
+}
+
+// likeEscaper escapes the LIKE wildcards in user input
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// Existing returns which of userIDs belong to a user. It reads from the master so users that
+// were just created are found.
+func (r *UsersRepository) Existing(ctx context.Context, userIDs []int) (map[int]bool, error) {
+	existing := make(map[int]bool)
+	if len(userIDs) == 0 {
+		return existing, nil
+	}
+
+	var ids []int
+	if err := r.db.GetMasterDB(ctx).
+		Model(&models.User{}).
+		Where("id IN ?", userIDs).
+		Pluck("id", &ids).Error; err != nil {
+		log.Printf("[ERROR] Existing: users=%d | err=%v", len(userIDs), err)
+		return nil, err
+	}
+
+	for _, id := range ids {
+		existing[id] = true
+	}
+
+	return existing, nil
+}
+
+// Countries returns the country of each of userIDs that exists, empty for a user with none
+func (r *UsersRepository) Countries(ctx context.Context, userIDs []int) (map[int]string, error) {
+	countries := make(map[int]string)
+	if len(userIDs) == 0 {
+		return countries, nil
+	}
+
+	var users []models.User
+	if err := r.db.GetMasterDB(ctx).
+		Select("id", "country").
+		Where("id IN ?", userIDs).
+		Find(&users).Error; err != nil {
+		log.Printf("[ERROR] Countries: users=%d | err=%v", len(userIDs), err)
+		return nil, err
+	}
+
+	for _, user := range users {
+		countries[user.ID] = user.Country
+	}
+
+	return countries, nil
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/controller/request"
+	"gaming-leaderboard/internal/models"
+	baseRepository "gaming-leaderboard/internal/repository"
+	"gaming-leaderboard/pkg/apperror"
+
+	"gorm.io/gorm"
+)
+
+// AddFriend puts a user in another's friend set, both ways for a mutual friendship. Adding a
+// follow to an existing mutual friendship leaves it mutual.
+func (s *UsersService) AddFriend(
+	ctx context.Context,
+	userID int,
+	req request.AddFriendRequest,
+) (models.Friendship, apperror.Error) {
+	if req.FriendID == userID {
+		return models.Friendship{}, apperror.New(fmt.Errorf("a user cannot befriend themselves"), http.StatusBadRequest)
+	}
+
+	kind := req.Kind
+	if kind == "" {
+		kind = constants.FriendshipMutual
+	}
+
+	friendship, found, err := s.friendshipsRepository.Befriend(ctx, models.Friendship{
+		UserID:    userID,
+		FriendID:  req.FriendID,
+		Kind:      kind,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return models.Friendship{}, apperror.New(fmt.Errorf("unable to add friend"), http.StatusInternalServerError)
+	}
+
+	if !found {
+		return models.Friendship{}, apperror.New(
+			fmt.Errorf("user %d or user %d not found", userID, req.FriendID),
+			http.StatusNotFound,
+		)
+	}
+
+	log.Printf("[INFO] friend added | user_id=%d | friend_id=%d | kind=%s", userID, req.FriendID, friendship.Kind)
+	return friendship, apperror.Error{}
+}
+
+// RemoveFriend takes a user out of another's friend set, ending a mutual friendship both ways
+func (s *UsersService) RemoveFriend(ctx context.Context, userID int, friendID int) apperror.Error {
+	found, err := s.friendshipsRepository.Unfriend(ctx, userID, friendID)
+	if err != nil {
+		return apperror.New(fmt.Errorf("unable to remove friend"), http.StatusInternalServerError)
+	}
+
+	if !found {
+		return apperror.New(fmt.Errorf("user %d is not a friend of user %d", friendID, userID), http.StatusNotFound)
+	}
+
+	log.Printf("[INFO] friend removed | user_id=%d | friend_id=%d", userID, friendID)
+	return apperror.Error{}
+}
+
+// ListFriends pages through a user's friend set, with each friend's profile, most recent first
+func (s *UsersService) ListFriends(
+	ctx context.Context,
+	userID int,
+	page int,
+	limit int,
+) ([]*models.Friendship, int64, apperror.Error) {
+	friends, total, cusErr := s.friendshipsRepository.GetAllWithPagination(
+		ctx,
+		map[string]interface{}{constants.UserID: userID},
+		baseRepository.Paginate(page, limit),
+		func(db *gorm.DB) *gorm.DB { return db.Preload("Friend").Order("created_at DESC, friend_id ASC") },
+	)
+	if cusErr.Exists() {
+		return nil, 0, apperror.New(fmt.Errorf("unable to list friends"), http.StatusInternalServerError)
+	}
+
+	return friends, total, apperror.Error{}
+}
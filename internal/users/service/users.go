@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"gaming-leaderboard/constants"
+	"gaming-leaderboard/internal/controller/request"
+	"gaming-leaderboard/internal/models"
+	baseRepository "gaming-leaderboard/internal/repository"
+	"gaming-leaderboard/internal/users/repository"
+	"gaming-leaderboard/pkg/apperror"
+	oredis "gaming-leaderboard/pkg/redis"
+
+	"gorm.io/gorm"
+)
+
+type UsersService struct {
+	repository            *repository.UsersRepository
+	friendshipsRepository *repository.FriendshipsRepository
+	redisClient           oredis.Cache
+}
+
+func NewUsersService(
+	repo *repository.UsersRepository,
+	friendshipsRepository *repository.FriendshipsRepository,
+	redisClient oredis.Cache,
+) *UsersService {
+	return &UsersService{
+		repository:            repo,
+		friendshipsRepository: friendshipsRepository,
+		redisClient:           redisClient,
+	}
+}
+
+// CreateUser registers a user under a username no other user has
+func (s *UsersService) CreateUser(ctx context.Context, req request.CreateUserRequest) (models.User, apperror.Error) {
+	user := models.User{
+		Username:    strings.TrimSpace(req.Username),
+		JoinDate:    time.Now().UTC(),
+		DisplayName: strings.TrimSpace(req.DisplayName),
+		AvatarURL:   req.AvatarURL,
+		Country:     req.Country,
+	}
+	if user.Username == "" {
+		return models.User{}, apperror.New(fmt.Errorf("username must not be blank"), http.StatusBadRequest)
+	}
+
+	created, err := s.repository.CreateUnique(ctx, &user)
+	if err != nil {
+		return models.User{}, apperror.New(fmt.Errorf("unable to create user"), http.StatusInternalServerError)
+	}
+
+	if !created {
+		return models.User{}, apperror.New(fmt.Errorf("username %q is taken", user.Username), http.StatusConflict)
+	}
+
+	log.Printf("[INFO] user created | user_id=%d", user.ID)
+	return user, apperror.Error{}
+}
+
+// GetUser returns one user
+func (s *UsersService) GetUser(ctx context.Context, userID int) (models.User, apperror.Error) {
+	users, cusErr := s.repository.GetAll(ctx, map[string]interface{}{"id": userID})
+	if cusErr.Exists() {
+		return models.User{}, apperror.New(fmt.Errorf("unable to get user"), http.StatusInternalServerError)
+	}
+
+	if len(users) == 0 {
+		return models.User{}, apperror.New(fmt.Errorf("user %d not found", userID), http.StatusNotFound)
+	}
+
+	return *users[0], apperror.Error{}
+}
+
+// UpdateUser changes a user's username, to one no other user has, and profile. The profile
+// cached for leaderboard reads is dropped so boards show the change.
+func (s *UsersService) UpdateUser(
+	ctx context.Context,
+	userID int,
+	req request.UpdateUserRequest,
+) (models.User, apperror.Error) {
+	updates := make(map[string]interface{})
+	if req.Username != nil {
+		username := strings.TrimSpace(*req.Username)
+		if username == "" {
+			return models.User{}, apperror.New(fmt.Errorf("username must not be blank"), http.StatusBadRequest)
+		}
+		updates["username"] = username
+	}
+	if req.DisplayName != nil {
+		updates["display_name"] = strings.TrimSpace(*req.DisplayName)
+	}
+	if req.AvatarURL != nil {
+		updates["avatar_url"] = *req.AvatarURL
+	}
+	if req.Country != nil {
+		updates["country"] = *req.Country
+	}
+
+	if len(updates) == 0 {
+		return s.GetUser(ctx, userID)
+	}
+
+	user, found, taken, err := s.repository.Update(ctx, userID, updates)
+	if err != nil {
+		return models.User{}, apperror.New(fmt.Errorf("unable to update user"), http.StatusInternalServerError)
+	}
+
+	if taken {
+		return models.User{}, apperror.New(fmt.Errorf("username %q is taken", updates["username"]), http.StatusConflict)
+	}
+
+	if !found {
+		return models.User{}, apperror.New(fmt.Errorf("user %d not found", userID), http.StatusNotFound)
+	}
+
+	if _, err := s.redisClient.Unlink(ctx, []string{fmt.Sprintf(constants.UserProfileKeyFormat, userID)}); err != nil {
+		log.Printf("[WARN] user profile cache invalidation failed | user_id=%d | err=%v", userID, err)
+	}
+
+	return user, apperror.Error{}
+}
+
+// SearchUsers pages through the users whose username starts with prefix, ignoring case, in
+// username order
+func (s *UsersService) SearchUsers(
+	ctx context.Context,
+	prefix string,
+	page int,
+	limit int,
+) ([]*models.User, int64, apperror.Error) {
+	users, total, cusErr := s.repository.GetAllWithPagination(
+		ctx,
+		map[string]interface{}{},
+		repository.UsernamePrefix(prefix),
+		baseRepository.Paginate(page, limit),
+		func(db *gorm.DB) *gorm.DB { return db.Order("username ASC, id ASC") },
+	)
+	if cusErr.Exists() {
+		return nil, 0, apperror.New(fmt.Errorf("unable to search users"), http.StatusInternalServerError)
+	}
+
+	return users, total, apperror.Error{}
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"strings"
+
+	"gaming-leaderboard/constants"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+// adminToken is the X-Admin-Token one admin presents, and the name their actions are recorded
+// under
+type adminToken struct {
+	name  string
+	token []byte
+}
+
+// AdminAuth restricts routes to callers presenting one of the configured admin tokens, and
+// records the name of the admin it belongs to under constants.AdminActor. Without any token
+// every caller is refused, and the placeholder token from the examples is not accepted as one.
+// The single admin.token of earlier releases is still accepted as the token of an admin named
+// constants.LegacyAdminName.
+func AdminAuth() gin.HandlerFunc {
+	tokens := loadAdminTokens(viper.GetString("admin.tokens"), viper.GetString("admin.token"))
+
+	return func(c *gin.Context) {
+		presented := []byte(c.GetHeader(constants.AdminTokenHeader))
+
+		// Every token is compared so the time taken does not tell which one came close
+		actor := ""
+		for _, admin := range tokens {
+			if subtle.ConstantTimeCompare(presented, admin.token) == 1 {
+				actor = admin.name
+			}
+		}
+
+		if actor == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "invalid admin token",
+			})
+			return
+		}
+
+		c.Set(constants.AdminActor, actor)
+		c.Next()
+	}
+}
+
+// loadAdminTokens parses the comma separated name:token pairs of admin.tokens, adding the
+// deprecated admin.token as the token of constants.LegacyAdminName when it is set
+func loadAdminTokens(raw string, legacy string) []adminToken {
+	pairs := make([][2]string, 0)
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		name, token, ok := strings.Cut(pair, ":")
+		name, token = strings.TrimSpace(name), strings.TrimSpace(token)
+		if !ok || name == "" || token == "" {
+			log.Panicf("Invalid admin token entry, expected name:token")
+		}
+
+		pairs = append(pairs, [2]string{name, token})
+	}
+
+	if legacy = strings.TrimSpace(legacy); legacy != "" {
+		log.Printf("[WARN] admin.token (ADMIN_TOKEN) is deprecated, set admin.tokens (ADMIN_TOKENS) to %s:<token> instead", constants.LegacyAdminName)
+		pairs = append(pairs, [2]string{constants.LegacyAdminName, legacy})
+	}
+
+	tokens := make([]adminToken, 0, len(pairs))
+	names := make(map[string]bool)
+	for _, pair := range pairs {
+		name, token := pair[0], pair[1]
+
+		if token == constants.PlaceholderSecret {
+			log.Panicf("Admin token of %s must not be the placeholder %q", name, constants.PlaceholderSecret)
+		}
+
+		if names[name] {
+			log.Panicf("Admin %s has more than one token", name)
+		}
+		names[name] = true
+
+		tokens = append(tokens, adminToken{name: name, token: []byte(token)})
+	}
+
+	return tokens
+}
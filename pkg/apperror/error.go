@@ -44,6 +44,11 @@ func (e Error) Unwrap() error {
 	return e.err
 }
 
+// StatusCode is the HTTP status the error is answered with
+func (e Error) StatusCode() int {
+	return e.statusCode
+}
+
 func (e Error) AbortWithError(ctx *gin.Context) {
 	status := e.statusCode
 	if status < 100 || status >= 600 {
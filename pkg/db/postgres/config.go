@@ -28,4 +28,6 @@ type DBConfig struct {
 	DebugMode              bool
 	PrepareStmt            bool
 	SkipDefaultTransaction bool
+	// SearchPath is the schema unqualified table names resolve to, the server's default when empty
+	SearchPath string
 }
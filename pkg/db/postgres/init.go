@@ -23,6 +23,36 @@ func InitializeDBInstance(ctx context.Context, master DBConfig, slaves *[]DBConf
 	return db
 }
 
+// ForSchema opens a cluster on the same servers as db whose connections resolve tables in
+// schema, creating the schema and its tables when they do not exist yet. Each of its pools
+// keeps at most maxOpen connections, maxIdle of them idle.
+func (db *DbCluster) ForSchema(
+	ctx context.Context,
+	schema string,
+	maxOpen int,
+	maxIdle int,
+	nrApp *newrelic.Application,
+) (*DbCluster, error) {
+	if err := db.getMaster(ctx).Exec(fmt.Sprintf(`CREATE SCHEMA IF NOT EXISTS "%s"`, schema)).Error; err != nil {
+		log.Printf("[ERROR] ForSchema: schema=%s | err=%v", schema, err)
+		return nil, err
+	}
+
+	master := db.master.config
+	master.SearchPath = schema
+	master.MaxOpenConnections, master.MaxIdleConnections = maxOpen, maxIdle
+
+	slaves := make([]DBConfig, 0, len(db.slaves))
+	for _, slave := range db.slaves {
+		config := slave.config
+		config.SearchPath = schema
+		config.MaxOpenConnections, config.MaxIdleConnections = maxOpen, maxIdle
+		slaves = append(slaves, config)
+	}
+
+	return InitializeDBInstance(ctx, master, &slaves, nrApp), nil
+}
+
 func getDbInstance(ctx context.Context, master DBConfig, slaves *[]DBConfig) (instance *DbCluster) {
 	slavesCount := len(*slaves)
 	instance = &DbCluster{
@@ -47,6 +77,9 @@ func initDbConnection(ctx context.Context, config DBConfig) *Connection {
 	dsn := fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable", config.Host, config.Port, config.Username, config.Password, config.Dbname,
 	)
+	if config.SearchPath != "" {
+		dsn += " search_path=" + config.SearchPath
+	}
 
 	gormDB, err := gorm.Open(postgres.Dialector{
 		Config: &postgres.Config{
@@ -167,6 +200,10 @@ func EnsureSchema(ctx context.Context, db *sql.DB) error {
 			username VARCHAR(255) UNIQUE NOT NULL,
 			join_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		);`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS display_name VARCHAR(64) NOT NULL DEFAULT '';`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_url VARCHAR(512) NOT NULL DEFAULT '';`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS country VARCHAR(2) NOT NULL DEFAULT '';`,
+		`CREATE INDEX IF NOT EXISTS idx_users_country ON users(country) WHERE country <> '';`,
 
 		// game_sessions table
 		`CREATE TABLE IF NOT EXISTS game_sessions (
@@ -176,6 +213,59 @@ func EnsureSchema(ctx context.Context, db *sql.DB) error {
 			game_mode VARCHAR(50) NOT NULL,
 			timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		);`,
+		`ALTER TABLE game_sessions ADD COLUMN IF NOT EXISTS client_session_id VARCHAR(255);`,
+		`ALTER TABLE game_sessions ADD COLUMN IF NOT EXISTS play_session_id VARCHAR(64);`,
+		`ALTER TABLE game_sessions ADD COLUMN IF NOT EXISTS started_at TIMESTAMP;`,
+		`ALTER TABLE game_sessions ADD COLUMN IF NOT EXISTS duration_ms BIGINT;`,
+		`ALTER TABLE game_sessions ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'accepted';`,
+		`ALTER TABLE game_sessions ADD COLUMN IF NOT EXISTS flag_reason TEXT;`,
+		`ALTER TABLE game_sessions ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMP;`,
+		`ALTER TABLE game_sessions ADD COLUMN IF NOT EXISTS voided_at TIMESTAMP;`,
+		`ALTER TABLE game_sessions ADD COLUMN IF NOT EXISTS void_reason TEXT;`,
+		`ALTER TABLE game_sessions ADD COLUMN IF NOT EXISTS voided_by VARCHAR(255);`,
+		`ALTER TABLE game_sessions ADD COLUMN IF NOT EXISTS region VARCHAR(2);`,
+		// the transaction that recorded each session, incremental recalculations fold in the
+		// sessions their last snapshot could not see whatever order ids committed in
+		`ALTER TABLE game_sessions ADD COLUMN IF NOT EXISTS xact_id xid8 NOT NULL DEFAULT pg_current_xact_id();`,
+
+		// rejected_submissions table, the outcome of each submission refused under a client session
+		// id, replayed to its retries. Users are not referenced, an unknown user is one reason to refuse.
+		`CREATE TABLE IF NOT EXISTS rejected_submissions (
+			user_id INT NOT NULL,
+			client_session_id VARCHAR(255) NOT NULL,
+			score INT NOT NULL,
+			game_mode VARCHAR(50) NOT NULL,
+			status_code INT NOT NULL,
+			error TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, client_session_id)
+		);`,
+
+		// friendships table, a row per user whose friend set holds friend_id
+		`CREATE TABLE IF NOT EXISTS friendships (
+			user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			friend_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			kind VARCHAR(20) NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, friend_id),
+			CHECK (user_id <> friend_id)
+		);`,
+
+		// teams table
+		`CREATE TABLE IF NOT EXISTS teams (
+			id SERIAL PRIMARY KEY,
+			name VARCHAR(64) UNIQUE NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);`,
+
+		// team_members table, a user belongs to at most one team at a time
+		`CREATE TABLE IF NOT EXISTS team_members (
+			user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+			team_id INT NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+			joined_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_team_members_team ON team_members(team_id);`,
+		`ALTER TABLE game_sessions ADD COLUMN IF NOT EXISTS team_id INT REFERENCES teams(id) ON DELETE SET NULL;`,
 
 		// leaderboard table
 		`CREATE TABLE IF NOT EXISTS leaderboard (
@@ -185,15 +275,53 @@ func EnsureSchema(ctx context.Context, db *sql.DB) error {
 			rank INT
 		);`,
 
-		// Add unique constraint on user_id (critical for ON CONFLICT to work)
+		// per game mode boards, rows from before the split are sums across every mode
+		`ALTER TABLE leaderboard ADD COLUMN IF NOT EXISTS game_mode VARCHAR(50) NOT NULL DEFAULT 'overall';`,
+
+		// time windowed boards, rows from before windows existed are all-time standings
+		`ALTER TABLE leaderboard ADD COLUMN IF NOT EXISTS time_window VARCHAR(20) NOT NULL DEFAULT 'all_time';`,
+		`ALTER TABLE leaderboard ADD COLUMN IF NOT EXISTS period_start TIMESTAMP NOT NULL DEFAULT '1970-01-01 00:00:00';`,
+
+		// configurable boards, rows from before boards existed belong to the default board of their mode
+		`ALTER TABLE leaderboard ADD COLUMN IF NOT EXISTS board VARCHAR(64) NOT NULL DEFAULT '';`,
+		`UPDATE leaderboard SET board = game_mode WHERE board = '';`,
+
+		// when each user reached their current score, breaks ties between equal scores
+		`ALTER TABLE leaderboard ADD COLUMN IF NOT EXISTS reached_at TIMESTAMP;`,
+
+		// a user is ranked once per board and window period (critical for ON CONFLICT to work)
 		`DO $$ 
 		BEGIN
+			IF EXISTS (
+				SELECT 1 FROM pg_constraint 
+				WHERE connamespace = current_schema()::regnamespace AND conname = 'leaderboard_user_id_unique'
+			) THEN
+				ALTER TABLE leaderboard 
+				DROP CONSTRAINT leaderboard_user_id_unique;
+			END IF;
+
+			IF EXISTS (
+				SELECT 1 FROM pg_constraint 
+				WHERE connamespace = current_schema()::regnamespace AND conname = 'leaderboard_user_id_game_mode_unique'
+			) THEN
+				ALTER TABLE leaderboard 
+				DROP CONSTRAINT leaderboard_user_id_game_mode_unique;
+			END IF;
+
+			IF EXISTS (
+				SELECT 1 FROM pg_constraint 
+				WHERE connamespace = current_schema()::regnamespace AND conname = 'leaderboard_user_board_period_unique'
+			) THEN
+				ALTER TABLE leaderboard 
+				DROP CONSTRAINT leaderboard_user_board_period_unique;
+			END IF;
+
 			IF NOT EXISTS (
 				SELECT 1 FROM pg_constraint 
-				WHERE conname = 'leaderboard_user_id_unique'
+				WHERE connamespace = current_schema()::regnamespace AND conname = 'leaderboard_board_period_user_unique'
 			) THEN
 				ALTER TABLE leaderboard 
-				ADD CONSTRAINT leaderboard_user_id_unique UNIQUE (user_id);
+				ADD CONSTRAINT leaderboard_board_period_user_unique UNIQUE (board, time_window, period_start, user_id);
 			END IF;
 		END $$;`,
 
@@ -201,14 +329,189 @@ func EnsureSchema(ctx context.Context, db *sql.DB) error {
 		`CREATE INDEX IF NOT EXISTS idx_leaderboard_user_id ON leaderboard(user_id);`,
 		`CREATE INDEX IF NOT EXISTS idx_leaderboard_rank ON leaderboard(rank);`,
 		`CREATE INDEX IF NOT EXISTS idx_leaderboard_total_score ON leaderboard(total_score DESC);`,
+		`DROP INDEX IF EXISTS idx_leaderboard_game_mode_rank;`,
+		`DROP INDEX IF EXISTS idx_leaderboard_board_period_rank;`,
+		`CREATE INDEX IF NOT EXISTS idx_leaderboard_board_period_ranking ON leaderboard(board, time_window, period_start, rank);`,
+		`CREATE INDEX IF NOT EXISTS idx_leaderboard_board_period_score ON leaderboard(board, time_window, period_start, total_score DESC);`,
+
+		// leaderboard_watermarks table, the snapshot whose sessions each board period has absorbed
+		`CREATE TABLE IF NOT EXISTS leaderboard_watermarks (
+			board VARCHAR(64) NOT NULL,
+			time_window VARCHAR(20) NOT NULL,
+			period_start TIMESTAMP NOT NULL,
+			last_snapshot pg_snapshot NOT NULL,
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (board, time_window, period_start)
+		);`,
+
+		// leaderboard_definitions table, the boards the game ranks, managed through the admin API
+		`CREATE TABLE IF NOT EXISTS leaderboard_definitions (
+			id SERIAL PRIMARY KEY,
+			name VARCHAR(64) UNIQUE NOT NULL,
+			game_mode VARCHAR(50) NOT NULL,
+			windows JSONB,
+			aggregation VARCHAR(20) NOT NULL,
+			sessions INT NOT NULL DEFAULT 0,
+			rank_type VARCHAR(20) NOT NULL,
+			tie_breaker VARCHAR(20) NOT NULL,
+			sort_direction VARCHAR(4) NOT NULL,
+			size_cap INT NOT NULL,
+			refresh_seconds INT NOT NULL DEFAULT 0,
+			tier_basis VARCHAR(20) NOT NULL DEFAULT '',
+			tiers JSONB,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`ALTER TABLE leaderboard_definitions ADD COLUMN IF NOT EXISTS cache_seconds INT NOT NULL DEFAULT 0;`,
+
+		// team boards are stored alongside the boards, sharing their names
+		`ALTER TABLE leaderboard_definitions ADD COLUMN IF NOT EXISTS kind VARCHAR(10) NOT NULL DEFAULT 'board';`,
+		`ALTER TABLE leaderboard_definitions ADD COLUMN IF NOT EXISTS split VARCHAR(20) NOT NULL DEFAULT '';`,
+		`ALTER TABLE leaderboard_definitions ADD COLUMN IF NOT EXISTS members INT NOT NULL DEFAULT 0;`,
+
+		// leaderboard_definition_seeds table, the kinds of definition seeded from the configuration,
+		// so each is seeded once and boards retired through the admin API stay retired. Games that
+		// defined boards before the seeds were recorded have had theirs seeded.
+		`CREATE TABLE IF NOT EXISTS leaderboard_definition_seeds (
+			kind VARCHAR(10) PRIMARY KEY,
+			seeded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`INSERT INTO leaderboard_definition_seeds (kind)
+			SELECT 'board' WHERE EXISTS (SELECT 1 FROM leaderboard_definitions)
+			ON CONFLICT (kind) DO NOTHING;`,
+
+		// worker_fences table, the newest fencing token that has written on behalf of each worker
+		`CREATE TABLE IF NOT EXISTS worker_fences (
+			name VARCHAR(64) PRIMARY KEY,
+			token BIGINT NOT NULL,
+			holder VARCHAR(255) NOT NULL,
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);`,
+
+		// seasons table
+		`CREATE TABLE IF NOT EXISTS seasons (
+			id SERIAL PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			start_at TIMESTAMP NOT NULL,
+			end_at TIMESTAMP,
+			status VARCHAR(20) NOT NULL
+		);`,
+
+		// at most one season runs at a time
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_seasons_single_active ON seasons(status) WHERE status = 'active';`,
+
+		// season_standings table, final rankings frozen when a season closes
+		`CREATE TABLE IF NOT EXISTS season_standings (
+			id SERIAL PRIMARY KEY,
+			season_id INT NOT NULL REFERENCES seasons(id) ON DELETE CASCADE,
+			user_id INT REFERENCES users(id) ON DELETE CASCADE,
+			game_mode VARCHAR(50) NOT NULL,
+			total_score INT NOT NULL,
+			rank INT
+		);`,
+		`ALTER TABLE season_standings ADD COLUMN IF NOT EXISTS board VARCHAR(64) NOT NULL DEFAULT '';`,
+		`UPDATE season_standings SET board = game_mode WHERE board = '';`,
+		`ALTER TABLE season_standings ADD COLUMN IF NOT EXISTS reached_at TIMESTAMP;`,
+		`DO $$ 
+		BEGIN
+			IF EXISTS (
+				SELECT 1 FROM pg_constraint 
+				WHERE connamespace = current_schema()::regnamespace AND conname = 'season_standings_season_id_game_mode_user_id_key'
+			) THEN
+				ALTER TABLE season_standings 
+				DROP CONSTRAINT season_standings_season_id_game_mode_user_id_key;
+			END IF;
+
+			IF NOT EXISTS (
+				SELECT 1 FROM pg_constraint 
+				WHERE connamespace = current_schema()::regnamespace AND conname = 'season_standings_season_board_user_unique'
+			) THEN
+				ALTER TABLE season_standings 
+				ADD CONSTRAINT season_standings_season_board_user_unique UNIQUE (season_id, board, user_id);
+			END IF;
+		END $$;`,
+		`DROP INDEX IF EXISTS idx_season_standings_season_rank;`,
+		`CREATE INDEX IF NOT EXISTS idx_season_standings_board_rank ON season_standings(season_id, board, rank);`,
+
+		// rank_history table, one snapshot of each user's standing per board period and day
+		`CREATE TABLE IF NOT EXISTS rank_history (
+			id SERIAL PRIMARY KEY,
+			user_id INT REFERENCES users(id) ON DELETE CASCADE,
+			board VARCHAR(64) NOT NULL,
+			time_window VARCHAR(20) NOT NULL,
+			period_start TIMESTAMP NOT NULL,
+			recorded_on DATE NOT NULL,
+			total_score INT NOT NULL,
+			rank INT,
+			CONSTRAINT rank_history_period_user_day_unique UNIQUE (board, time_window, period_start, user_id, recorded_on)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_rank_history_user_recorded ON rank_history(user_id, board, time_window, recorded_on);`,
+		`CREATE INDEX IF NOT EXISTS idx_rank_history_recorded ON rank_history(recorded_on);`,
+
+		// team_leaderboard table, team boards ranked from their members' team-mode sessions
+		`CREATE TABLE IF NOT EXISTS team_leaderboard (
+			id SERIAL PRIMARY KEY,
+			team_id INT NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+			board VARCHAR(64) NOT NULL,
+			time_window VARCHAR(20) NOT NULL,
+			period_start TIMESTAMP NOT NULL,
+			total_score INT NOT NULL,
+			rank INT,
+			reached_at TIMESTAMP,
+			members INT NOT NULL DEFAULT 0,
+			CONSTRAINT team_leaderboard_period_team_unique UNIQUE (board, time_window, period_start, team_id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_team_leaderboard_period_rank ON team_leaderboard(board, time_window, period_start, rank);`,
+
+		// leaderboard_bans table, users banned or shadow-banned from every board until lifted
+		`CREATE TABLE IF NOT EXISTS leaderboard_bans (
+			user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+			kind VARCHAR(20) NOT NULL,
+			reason TEXT NOT NULL,
+			actor VARCHAR(255) NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);`,
+
+		// moderation_actions table, the audit log of every void, restore, ban and unban
+		`CREATE TABLE IF NOT EXISTS moderation_actions (
+			id SERIAL PRIMARY KEY,
+			action VARCHAR(32) NOT NULL,
+			target_type VARCHAR(20) NOT NULL,
+			target_id INT NOT NULL,
+			reason TEXT NOT NULL,
+			actor VARCHAR(255) NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_moderation_actions_target ON moderation_actions(target_type, target_id, id DESC);`,
 
 		// indexes for game_sessions
 		`CREATE INDEX IF NOT EXISTS idx_game_sessions_user_id ON game_sessions(user_id);`,
 		`CREATE INDEX IF NOT EXISTS idx_game_sessions_timestamp ON game_sessions(timestamp DESC);`,
 		`CREATE INDEX IF NOT EXISTS idx_game_sessions_score ON game_sessions(score DESC);`,
+		`CREATE INDEX IF NOT EXISTS idx_game_sessions_xact_id ON game_sessions(xact_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_game_sessions_region ON game_sessions(region, timestamp) WHERE region IS NOT NULL;`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS game_sessions_user_client_session_unique
+			ON game_sessions(user_id, client_session_id) WHERE client_session_id IS NOT NULL;`,
+		`CREATE INDEX IF NOT EXISTS idx_game_sessions_quarantined ON game_sessions(id) WHERE status = 'quarantined';`,
+		`CREATE INDEX IF NOT EXISTS idx_game_sessions_user_mode ON game_sessions(user_id, game_mode, id DESC);`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS game_sessions_play_session_unique
+			ON game_sessions(play_session_id) WHERE play_session_id IS NOT NULL;`,
 
 		// indexes for users
 		`CREATE INDEX IF NOT EXISTS idx_users_username ON users(username);`,
+
+		// games table, the tenants sharing the service, each kept in its own schema. It always
+		// lives in public, whichever schema the connection's search path names.
+		`CREATE TABLE IF NOT EXISTS public.games (
+			id SERIAL PRIMARY KEY,
+			slug VARCHAR(32) UNIQUE NOT NULL,
+			name VARCHAR(255) NOT NULL,
+			schema_name VARCHAR(63) UNIQUE NOT NULL,
+			api_key_hash CHAR(64) UNIQUE NOT NULL,
+			boards JSONB,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);`,
 	}
 
 	for i, q := range queries {
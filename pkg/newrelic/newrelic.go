@@ -21,3 +21,21 @@ func NoticeErrorWithTransaction(txn *newrelic.Transaction, err error) {
 
 	txn.NoticeError(err)
 }
+
+// RecordCustomMetric records a custom metric, a no-op when New Relic is not initialized
+func RecordCustomMetric(name string, value float64) {
+	if NRApp == nil {
+		return
+	}
+
+	NRApp.RecordCustomMetric(name, value)
+}
+
+// RecordCustomEvent records a custom event, a no-op when New Relic is not initialized
+func RecordCustomEvent(eventType string, params map[string]interface{}) {
+	if NRApp == nil {
+		return
+	}
+
+	NRApp.RecordCustomEvent(eventType, params)
+}
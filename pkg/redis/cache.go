@@ -216,3 +216,24 @@ func (r *Redis) PipedMSet(ctx context.Context, kvArr []KVIn, expiry time.Duratio
 	_, err := pipe.Exec(ctx)
 	return err
 }
+
+// incrExpire increments the counter in KEYS[1], starting its ARGV[1] millisecond expiry on the
+// first increment
+var incrExpire = redis.NewScript(`
+	local count = redis.call('INCR', KEYS[1])
+	if count == 1 then
+		redis.call('PEXPIRE', KEYS[1], ARGV[1])
+	end
+	return count
+`)
+
+// IncrExpire increments a counter, expiring it ttl after its first increment
+func (r *Redis) IncrExpire(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	count, err := incrExpire.Run(ctx, r.Client, []string{key}, ttl.Milliseconds()).Int64()
+	if err != nil {
+		log.Printf("[Cache] Failed to increment key %s: %v\n", key, err)
+		return 0, err
+	}
+
+	return count, nil
+}
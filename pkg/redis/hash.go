@@ -0,0 +1,70 @@
+package redis
+
+import (
+	"context"
+	"log"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func (r *Redis) HSet(ctx context.Context, key string, values map[string]interface{}) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	if err := r.Client.HSet(ctx, key, values).Err(); err != nil {
+		log.Printf("[Cache] Failed to HSET %d fields of key %s: %v\n", len(values), key, err)
+		return err
+	}
+
+	return nil
+}
+
+// HMGet returns the fields of a hash that exist, missing fields are left out
+func (r *Redis) HMGet(ctx context.Context, key string, fields []string) (map[string]string, error) {
+	values := make(map[string]string, len(fields))
+	if len(fields) == 0 {
+		return values, nil
+	}
+
+	vals, err := r.Client.HMGet(ctx, key, fields...).Result()
+	if err != nil {
+		log.Printf("[Cache] Failed to HMGET %d fields of key %s: %v\n", len(fields), key, err)
+		return nil, err
+	}
+
+	for i, val := range vals {
+		if str, ok := val.(string); ok {
+			values[fields[i]] = str
+		}
+	}
+
+	return values, nil
+}
+
+// HSetNX sets a hash field only when it is not set yet, reporting whether it was
+func (r *Redis) HSetNX(ctx context.Context, key string, field string, value interface{}) (bool, error) {
+	set, err := r.Client.HSetNX(ctx, key, field, value).Result()
+	if err != nil {
+		log.Printf("[Cache] Failed to HSETNX field %s of key %s: %v\n", field, key, err)
+		return false, err
+	}
+
+	return set, nil
+}
+
+// HPopAll reads every field of a hash and deletes it in one transaction
+func (r *Redis) HPopAll(ctx context.Context, key string) (map[string]string, error) {
+	var values *redis.MapStringStringCmd
+	_, err := r.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		values = pipe.HGetAll(ctx, key)
+		pipe.Del(ctx, key)
+		return nil
+	})
+	if err != nil {
+		log.Printf("[Cache] Failed to pop hash %s: %v\n", key, err)
+		return nil, err
+	}
+
+	return values.Val(), nil
+}
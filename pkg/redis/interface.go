@@ -13,6 +13,87 @@ type Cache interface {
 	PipedMSet(ctx context.Context, kvArr []KVIn, d time.Duration) error
 	PipedMGet(ctx context.Context, kvArr []*KVOut) (err error)
 	Unlink(ctx context.Context, keys []string) (int64, error)
+	IncrExpire(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	ZIncrBy(ctx context.Context, key string, increment float64, member string) (float64, error)
+	ZAdd(ctx context.Context, key string, members []ZMember) error
+	ZRevRangeWithScores(ctx context.Context, key string, start, stop int64) ([]ZMember, error)
+	ZScore(ctx context.Context, key string, member string) (score float64, found bool, err error)
+	PipedZScore(ctx context.Context, key string, members []string) (map[string]float64, error)
+	ZCount(ctx context.Context, key string, min, max string) (int64, error)
+	ZCard(ctx context.Context, key string) (int64, error)
+	ZRangeByScore(ctx context.Context, key string, min, max string, count int64) ([]string, error)
+	PipedZUpdate(ctx context.Context, updates []ZUpdate) error
+	HSet(ctx context.Context, key string, values map[string]interface{}) error
+	HMGet(ctx context.Context, key string, fields []string) (map[string]string, error)
+	HSetNX(ctx context.Context, key string, field string, value interface{}) (bool, error)
+	HPopAll(ctx context.Context, key string) (map[string]string, error)
+	StartJournal(ctx context.Context, key string, ttl time.Duration) error
+	SwapRanking(ctx context.Context, swap ZSwap) (replayed int64, err error)
+	ExpireAt(ctx context.Context, key string, at time.Time) error
+	AcquireLock(ctx context.Context, key, fencingKey, owner string, ttl time.Duration, floor int64) (int64, error)
+	ReleaseLock(ctx context.Context, key, owner string, token int64) (bool, error)
+	LockHolder(ctx context.Context, key string) (lock Lock, found bool, err error)
+}
+
+type ZMember struct {
+	Member string
+	Score  float64
+}
+
+// ZUpdateMode is how a ZUpdate's score is combined with the member's current score
+type ZUpdateMode int
+
+const (
+	ZUpdateIncr   ZUpdateMode = iota // add to the current score
+	ZUpdateMax                       // keep the higher of the two scores
+	ZUpdateSet                       // replace the current score
+	ZUpdateRemove                    // take the member out of the ranking, the score is ignored
+)
+
+func (m ZUpdateMode) String() string {
+	switch m {
+	case ZUpdateMax:
+		return "max"
+	case ZUpdateSet:
+		return "set"
+	case ZUpdateRemove:
+		return "rem"
+	default:
+		return "incr"
+	}
+}
+
+// ZUpdate changes one member's score in a ranking sorted set, keeping its two companion keys
+// in step: a sorted set of the distinct scores held (for dense ranking) and a hash of when
+// each member's score last changed (for tie-breaking)
+type ZUpdate struct {
+	Key          string
+	DistinctKey  string
+	ChangedAtKey string
+	Member       string
+	Score        float64
+	Mode         ZUpdateMode
+	ChangedAt    int64     // stored in ChangedAtKey, unix microseconds for the rankings
+	ExpireAt     time.Time // zero keeps the keys without expiry
+	// JournalKey is the journal of the ranking's rebuild, the update is appended to it while
+	// one is open (see StartJournal)
+	JournalKey string
+}
+
+// ZSwap names a live ranking's keys, the rebuilt keys replacing them and the journal of the
+// updates made to the live ranking while it was rebuilt. The swap is only made while LockOwner
+// holds the lock in LockKey under LockToken (see AcquireLock).
+type ZSwap struct {
+	Key                 string
+	DistinctKey         string
+	ChangedAtKey        string
+	RebuiltKey          string
+	RebuiltDistinctKey  string
+	RebuiltChangedAtKey string
+	JournalKey          string
+	LockKey             string
+	LockOwner           string
+	LockToken           int64
 }
 
 type KVIn struct {
@@ -0,0 +1,99 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrLockLost is returned by writes made only while a lock is held, when it no longer is
+var ErrLockLost = errors.New("lock is no longer held")
+
+// Lock is the current holder of a lock taken with AcquireLock. Token is the fencing token of
+// the holder's term: it grows every time the lock changes hands, so work stamped with an
+// older token can be rejected once a newer holder exists.
+type Lock struct {
+	Owner string
+	Token int64
+}
+
+// acquireLock takes the lock in KEYS[1] for ARGV[1], or extends it when ARGV[1] already holds
+// it, and returns the holder's fencing token, 0 when someone else holds the lock. Tokens come
+// from the counter in KEYS[2], which never expires, but a new term's token is raised above
+// ARGV[3] so a counter that was flushed or evicted carries on past the terms already issued.
+var acquireLock = redis.NewScript(`
+	local owner = redis.call('HGET', KEYS[1], 'owner')
+	if owner == ARGV[1] then
+		redis.call('PEXPIRE', KEYS[1], ARGV[2])
+		return tonumber(redis.call('HGET', KEYS[1], 'token'))
+	end
+	if owner then
+		return 0
+	end
+
+	local token = redis.call('INCR', KEYS[2])
+	if token <= tonumber(ARGV[3]) then
+		token = tonumber(ARGV[3]) + 1
+		redis.call('SET', KEYS[2], token)
+	end
+	redis.call('HSET', KEYS[1], 'owner', ARGV[1], 'token', token)
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return token
+`)
+
+// releaseLock deletes the lock in KEYS[1] only while ARGV[1] still holds it under token ARGV[2]
+var releaseLock = redis.NewScript(`
+	if redis.call('HGET', KEYS[1], 'owner') == ARGV[1] and redis.call('HGET', KEYS[1], 'token') == ARGV[2] then
+		return redis.call('DEL', KEYS[1])
+	end
+	return 0
+`)
+
+// AcquireLock takes or extends key for owner until ttl from now, fencingKey counts the terms.
+// A new term's token is always above floor, the newest token known to have been issued. The
+// returned token is 0 when another owner holds the lock.
+func (r *Redis) AcquireLock(ctx context.Context, key, fencingKey, owner string, ttl time.Duration, floor int64) (int64, error) {
+	token, err := acquireLock.Run(ctx, r.Client, []string{key, fencingKey}, owner, ttl.Milliseconds(), floor).Int64()
+	if err != nil {
+		log.Printf("[Cache] Failed to acquire lock %s for %s: %v\n", key, owner, err)
+		return 0, err
+	}
+
+	return token, nil
+}
+
+// ReleaseLock gives up key if owner still holds it under token
+func (r *Redis) ReleaseLock(ctx context.Context, key, owner string, token int64) (bool, error) {
+	released, err := releaseLock.Run(ctx, r.Client, []string{key}, owner, token).Int64()
+	if err != nil {
+		log.Printf("[Cache] Failed to release lock %s for %s: %v\n", key, owner, err)
+		return false, err
+	}
+
+	return released == 1, nil
+}
+
+// LockHolder returns who holds key, found is false while nobody does
+func (r *Redis) LockHolder(ctx context.Context, key string) (lock Lock, found bool, err error) {
+	values, err := r.Client.HMGet(ctx, key, "owner", "token").Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		log.Printf("[Cache] Failed to read lock %s: %v\n", key, err)
+		return Lock{}, false, err
+	}
+
+	owner, ok := values[0].(string)
+	if !ok {
+		return Lock{}, false, nil
+	}
+
+	lock.Owner = owner
+	if token, ok := values[1].(string); ok {
+		lock.Token, _ = strconv.ParseInt(token, 10, 64)
+	}
+
+	return lock, true, nil
+}
@@ -0,0 +1,183 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// testRedis connects to the Redis the TEST_REDIS_* variables name, and returns a prefix for the
+// test's keys, which are deleted once the test ends. The test is skipped when TEST_REDIS_HOST
+// is not set.
+func testRedis(t *testing.T) (*Redis, string) {
+	t.Helper()
+
+	host := os.Getenv("TEST_REDIS_HOST")
+	if host == "" {
+		t.Skip("TEST_REDIS_HOST is not set, skipping the Redis test")
+	}
+
+	port := os.Getenv("TEST_REDIS_PORT")
+	if port == "" {
+		port = "6379"
+	}
+
+	client := redis.NewClient(&redis.Options{Addr: host + ":" + port})
+	prefix := fmt.Sprintf("test:%d:", time.Now().UnixNano())
+
+	t.Cleanup(func() {
+		ctx := context.Background()
+		keys, err := client.Keys(ctx, prefix+"*").Result()
+		if err == nil && len(keys) > 0 {
+			err = client.Del(ctx, keys...).Err()
+		}
+		if err != nil {
+			t.Errorf("delete test keys: %v", err)
+		}
+		client.Close()
+	})
+
+	return &Redis{Client: client, serializer: NewMsgpackSerializer()}, prefix
+}
+
+// lockStep is one call made against a lock, in order, and what it should return
+type lockStep struct {
+	owner string
+	// release gives the lock up under token instead of acquiring it
+	release bool
+	token   int64
+	// floor is the newest token known to have been issued, passed when acquiring
+	floor int64
+	// resetCounter deletes the fencing counter first, as a flush or eviction would
+	resetCounter bool
+	want         int64
+}
+
+func TestLock(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []lockStep
+	}{
+		{
+			name: "only one owner holds the lock",
+			steps: []lockStep{
+				{owner: "a", want: 1},
+				{owner: "b", want: 0},
+			},
+		},
+		{
+			name: "the holder extends its lock under the same token",
+			steps: []lockStep{
+				{owner: "a", want: 1},
+				{owner: "a", want: 1},
+				{owner: "b", want: 0},
+			},
+		},
+		{
+			name: "a released lock is taken under a newer token",
+			steps: []lockStep{
+				{owner: "a", want: 1},
+				{owner: "a", release: true, token: 1, want: 1},
+				{owner: "b", want: 2},
+			},
+		},
+		{
+			name: "release under a stale token is ignored",
+			steps: []lockStep{
+				{owner: "a", want: 1},
+				{owner: "a", release: true, token: 1, want: 1},
+				{owner: "a", want: 2},
+				{owner: "a", release: true, token: 1, want: 0},
+				{owner: "b", want: 0},
+			},
+		},
+		{
+			name: "release by another owner is ignored",
+			steps: []lockStep{
+				{owner: "a", want: 1},
+				{owner: "b", release: true, token: 1, want: 0},
+				{owner: "b", want: 0},
+			},
+		},
+		{
+			name: "a new term is issued above the floor",
+			steps: []lockStep{
+				{owner: "a", floor: 41, want: 42},
+			},
+		},
+		{
+			name: "a reset counter carries on past the floor",
+			steps: []lockStep{
+				{owner: "a", want: 1},
+				{owner: "a", release: true, token: 1, want: 1},
+				{owner: "b", floor: 1, resetCounter: true, want: 2},
+				{owner: "b", release: true, token: 2, want: 1},
+				{owner: "a", floor: 2, want: 3},
+			},
+		},
+	}
+
+	cache, prefix := testRedis(t)
+	ctx := context.Background()
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := fmt.Sprintf("%slock:%d", prefix, i)
+			fencingKey := key + ":fencing"
+
+			for n, step := range tt.steps {
+				if step.resetCounter {
+					if err := cache.Client.Del(ctx, fencingKey).Err(); err != nil {
+						t.Fatalf("step %d: reset counter: %v", n, err)
+					}
+				}
+
+				var got int64
+				if step.release {
+					released, err := cache.ReleaseLock(ctx, key, step.owner, step.token)
+					if err != nil {
+						t.Fatalf("step %d: release: %v", n, err)
+					}
+					if released {
+						got = 1
+					}
+				} else {
+					token, err := cache.AcquireLock(ctx, key, fencingKey, step.owner, time.Minute, step.floor)
+					if err != nil {
+						t.Fatalf("step %d: acquire: %v", n, err)
+					}
+					got = token
+				}
+
+				if got != step.want {
+					t.Fatalf("step %d: %s got %d, want %d", n, step.owner, got, step.want)
+				}
+			}
+		})
+	}
+}
+
+func TestLockExpires(t *testing.T) {
+	cache, prefix := testRedis(t)
+	ctx := context.Background()
+	key := prefix + "lock"
+
+	if token, err := cache.AcquireLock(ctx, key, key+":fencing", "a", 50*time.Millisecond, 0); err != nil || token != 1 {
+		t.Fatalf("acquire = %d, %v, want 1", token, err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	if token, err := cache.AcquireLock(ctx, key, key+":fencing", "b", time.Minute, 0); err != nil || token != 2 {
+		t.Fatalf("acquire after expiry = %d, %v, want 2", token, err)
+	}
+
+	lock, found, err := cache.LockHolder(ctx, key)
+	if err != nil || !found || lock.Owner != "b" || lock.Token != 2 {
+		t.Errorf("holder = %+v, %v, %v, want b under token 2", lock, found, err)
+	}
+}
@@ -0,0 +1,168 @@
+package redis
+
+import (
+	"context"
+	"time"
+)
+
+// Prefixed is a Cache keeping every key it is given under a prefix, so that several tenants
+// can share one Redis without reading or overwriting each other's keys
+type Prefixed struct {
+	cache  Cache
+	prefix string
+}
+
+func NewPrefixed(cache Cache, prefix string) *Prefixed {
+	return &Prefixed{cache: cache, prefix: prefix}
+}
+
+func (p *Prefixed) key(key string) string {
+	return p.prefix + key
+}
+
+func (p *Prefixed) keys(keys []string) []string {
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = p.key(key)
+	}
+	return prefixed
+}
+
+func (p *Prefixed) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	return p.cache.Set(ctx, p.key(key), value, ttl)
+}
+
+func (p *Prefixed) Get(ctx context.Context, key string, out interface{}) (bool, error) {
+	return p.cache.Get(ctx, p.key(key), out)
+}
+
+func (p *Prefixed) MSet(ctx context.Context, keyValue map[string]any) error {
+	prefixed := make(map[string]any, len(keyValue))
+	for key, value := range keyValue {
+		prefixed[p.key(key)] = value
+	}
+	return p.cache.MSet(ctx, prefixed)
+}
+
+func (p *Prefixed) PipedMSet(ctx context.Context, kvArr []KVIn, d time.Duration) error {
+	prefixed := make([]KVIn, len(kvArr))
+	for i, kv := range kvArr {
+		prefixed[i] = KVIn{Key: p.key(kv.Key), Val: kv.Val}
+	}
+	return p.cache.PipedMSet(ctx, prefixed, d)
+}
+
+// PipedMGet fills the caller's KVOuts in place, so their keys are prefixed only for the
+// duration of the call
+func (p *Prefixed) PipedMGet(ctx context.Context, kvArr []*KVOut) error {
+	for _, kv := range kvArr {
+		kv.Key = p.key(kv.Key)
+	}
+	defer func() {
+		for _, kv := range kvArr {
+			kv.Key = kv.Key[len(p.prefix):]
+		}
+	}()
+
+	return p.cache.PipedMGet(ctx, kvArr)
+}
+
+func (p *Prefixed) Unlink(ctx context.Context, keys []string) (int64, error) {
+	return p.cache.Unlink(ctx, p.keys(keys))
+}
+
+func (p *Prefixed) IncrExpire(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	return p.cache.IncrExpire(ctx, p.key(key), ttl)
+}
+
+func (p *Prefixed) ZIncrBy(ctx context.Context, key string, increment float64, member string) (float64, error) {
+	return p.cache.ZIncrBy(ctx, p.key(key), increment, member)
+}
+
+func (p *Prefixed) ZAdd(ctx context.Context, key string, members []ZMember) error {
+	return p.cache.ZAdd(ctx, p.key(key), members)
+}
+
+func (p *Prefixed) ZRevRangeWithScores(ctx context.Context, key string, start, stop int64) ([]ZMember, error) {
+	return p.cache.ZRevRangeWithScores(ctx, p.key(key), start, stop)
+}
+
+func (p *Prefixed) ZScore(ctx context.Context, key string, member string) (float64, bool, error) {
+	return p.cache.ZScore(ctx, p.key(key), member)
+}
+
+func (p *Prefixed) PipedZScore(ctx context.Context, key string, members []string) (map[string]float64, error) {
+	return p.cache.PipedZScore(ctx, p.key(key), members)
+}
+
+func (p *Prefixed) ZCount(ctx context.Context, key string, min, max string) (int64, error) {
+	return p.cache.ZCount(ctx, p.key(key), min, max)
+}
+
+func (p *Prefixed) ZCard(ctx context.Context, key string) (int64, error) {
+	return p.cache.ZCard(ctx, p.key(key))
+}
+
+func (p *Prefixed) ZRangeByScore(ctx context.Context, key string, min, max string, count int64) ([]string, error) {
+	return p.cache.ZRangeByScore(ctx, p.key(key), min, max, count)
+}
+
+func (p *Prefixed) PipedZUpdate(ctx context.Context, updates []ZUpdate) error {
+	prefixed := make([]ZUpdate, len(updates))
+	for i, u := range updates {
+		u.Key = p.key(u.Key)
+		u.DistinctKey = p.key(u.DistinctKey)
+		u.ChangedAtKey = p.key(u.ChangedAtKey)
+		u.JournalKey = p.key(u.JournalKey)
+		prefixed[i] = u
+	}
+	return p.cache.PipedZUpdate(ctx, prefixed)
+}
+
+func (p *Prefixed) HSet(ctx context.Context, key string, values map[string]interface{}) error {
+	return p.cache.HSet(ctx, p.key(key), values)
+}
+
+func (p *Prefixed) HMGet(ctx context.Context, key string, fields []string) (map[string]string, error) {
+	return p.cache.HMGet(ctx, p.key(key), fields)
+}
+
+func (p *Prefixed) HSetNX(ctx context.Context, key string, field string, value interface{}) (bool, error) {
+	return p.cache.HSetNX(ctx, p.key(key), field, value)
+}
+
+func (p *Prefixed) HPopAll(ctx context.Context, key string) (map[string]string, error) {
+	return p.cache.HPopAll(ctx, p.key(key))
+}
+
+func (p *Prefixed) StartJournal(ctx context.Context, key string, ttl time.Duration) error {
+	return p.cache.StartJournal(ctx, p.key(key), ttl)
+}
+
+func (p *Prefixed) SwapRanking(ctx context.Context, swap ZSwap) (int64, error) {
+	swap.Key = p.key(swap.Key)
+	swap.DistinctKey = p.key(swap.DistinctKey)
+	swap.ChangedAtKey = p.key(swap.ChangedAtKey)
+	swap.RebuiltKey = p.key(swap.RebuiltKey)
+	swap.RebuiltDistinctKey = p.key(swap.RebuiltDistinctKey)
+	swap.RebuiltChangedAtKey = p.key(swap.RebuiltChangedAtKey)
+	swap.JournalKey = p.key(swap.JournalKey)
+	swap.LockKey = p.key(swap.LockKey)
+	return p.cache.SwapRanking(ctx, swap)
+}
+
+func (p *Prefixed) ExpireAt(ctx context.Context, key string, at time.Time) error {
+	return p.cache.ExpireAt(ctx, p.key(key), at)
+}
+
+func (p *Prefixed) AcquireLock(ctx context.Context, key, fencingKey, owner string, ttl time.Duration, floor int64) (int64, error) {
+	return p.cache.AcquireLock(ctx, p.key(key), p.key(fencingKey), owner, ttl, floor)
+}
+
+func (p *Prefixed) ReleaseLock(ctx context.Context, key, owner string, token int64) (bool, error) {
+	return p.cache.ReleaseLock(ctx, p.key(key), owner, token)
+}
+
+func (p *Prefixed) LockHolder(ctx context.Context, key string) (Lock, bool, error) {
+	return p.cache.LockHolder(ctx, p.key(key))
+}
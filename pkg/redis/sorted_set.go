@@ -0,0 +1,297 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func (r *Redis) ZIncrBy(ctx context.Context, key string, increment float64, member string) (float64, error) {
+	score, err := r.Client.ZIncrBy(ctx, key, increment, member).Result()
+	if err != nil {
+		log.Printf("[Cache] Failed to ZINCRBY member %s of key %s: %v\n", member, key, err)
+		return 0, err
+	}
+
+	return score, nil
+}
+
+func (r *Redis) ZAdd(ctx context.Context, key string, members []ZMember) error {
+	if len(members) == 0 {
+		return nil
+	}
+
+	zs := make([]redis.Z, 0, len(members))
+	for _, m := range members {
+		zs = append(zs, redis.Z{Score: m.Score, Member: m.Member})
+	}
+
+	if err := r.Client.ZAdd(ctx, key, zs...).Err(); err != nil {
+		log.Printf("[Cache] Failed to ZADD %d members to key %s: %v\n", len(members), key, err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *Redis) ZRevRangeWithScores(ctx context.Context, key string, start, stop int64) ([]ZMember, error) {
+	zs, err := r.Client.ZRevRangeWithScores(ctx, key, start, stop).Result()
+	if err != nil {
+		log.Printf("[Cache] Failed to ZREVRANGE key %s [%d:%d]: %v\n", key, start, stop, err)
+		return nil, err
+	}
+
+	members := make([]ZMember, 0, len(zs))
+	for _, z := range zs {
+		member, ok := z.Member.(string)
+		if !ok {
+			continue
+		}
+		members = append(members, ZMember{Member: member, Score: z.Score})
+	}
+
+	return members, nil
+}
+
+func (r *Redis) ZScore(ctx context.Context, key string, member string) (score float64, found bool, err error) {
+	score, err = r.Client.ZScore(ctx, key, member).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return 0, false, nil // member doesn't exist
+		}
+		log.Printf("[Cache] Failed to ZSCORE member %s of key %s: %v\n", member, key, err)
+		return 0, false, err
+	}
+
+	return score, true, nil
+}
+
+// PipedZScore reads the scores of several members in a single round trip, members not in the
+// sorted set are left out of the result
+func (r *Redis) PipedZScore(ctx context.Context, key string, members []string) (map[string]float64, error) {
+	scores := make(map[string]float64, len(members))
+	if len(members) == 0 {
+		return scores, nil
+	}
+
+	pipe := r.Client.Pipeline()
+	cmds := make([]*redis.FloatCmd, len(members))
+	for i, member := range members {
+		cmds[i] = pipe.ZScore(ctx, key, member)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		log.Printf("[Cache] Piped ZSCORE of %d members of key %s failed. err: %v", len(members), key, err)
+		return nil, err
+	}
+
+	for i, cmd := range cmds {
+		score, err := cmd.Result()
+		if err != nil {
+			continue // member doesn't exist
+		}
+		scores[members[i]] = score
+	}
+
+	return scores, nil
+}
+
+func (r *Redis) ZCount(ctx context.Context, key string, min, max string) (int64, error) {
+	count, err := r.Client.ZCount(ctx, key, min, max).Result()
+	if err != nil {
+		log.Printf("[Cache] Failed to ZCOUNT key %s [%s:%s]: %v\n", key, min, max, err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *Redis) ZCard(ctx context.Context, key string) (int64, error) {
+	count, err := r.Client.ZCard(ctx, key).Result()
+	if err != nil {
+		log.Printf("[Cache] Failed to ZCARD key %s: %v\n", key, err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// zUpdateFunc defines update, which applies one ranking update. Scores are integral, so
+// distinct scores are stored as members named after themselves and dropped once no member
+// holds them any more. Set updates always refresh the change time, the others only when the
+// score actually moves, and removals drop it with the member.
+const zUpdateFunc = `
+	local function update(ranking, distinct, changedAt, score, member, mode, at)
+		local current = redis.call('ZSCORE', ranking, member)
+		if mode == 'rem' then
+			if current then
+				redis.call('ZREM', ranking, member)
+				redis.call('HDEL', changedAt, member)
+				if redis.call('ZCOUNT', ranking, current, current) == 0 then
+					redis.call('ZREM', distinct, string.format('%d', tonumber(current)))
+				end
+			end
+			return
+		end
+
+		score = tonumber(score)
+		if current then
+			current = tonumber(current)
+			if mode == 'incr' then
+				score = current + score
+			elseif mode == 'max' then
+				score = math.max(score, current)
+			end
+		end
+
+		if score ~= current then
+			redis.call('ZADD', ranking, score, member)
+			redis.call('ZADD', distinct, score, string.format('%d', score))
+			if current and redis.call('ZCOUNT', ranking, current, current) == 0 then
+				redis.call('ZREM', distinct, string.format('%d', current))
+			end
+		end
+
+		if score ~= current or mode == 'set' then
+			redis.call('HSET', changedAt, member, at)
+		end
+	end
+`
+
+// zUpdate applies a ZUpdate, journaling it first while its ranking is being rebuilt
+var zUpdate = redis.NewScript(zUpdateFunc + `
+	if redis.call('EXISTS', KEYS[4]) == 1 then
+		redis.call('RPUSH', KEYS[4], ARGV[1], ARGV[2], ARGV[3], ARGV[4])
+	end
+
+	update(KEYS[1], KEYS[2], KEYS[3], ARGV[1], ARGV[2], ARGV[3], ARGV[4])
+	return 1
+`)
+
+// PipedZUpdate applies several ranking updates in a single round trip
+func (r *Redis) PipedZUpdate(ctx context.Context, updates []ZUpdate) error {
+	if len(updates) == 0 {
+		return nil
+	}
+
+	pipe := r.Client.Pipeline()
+	for _, u := range updates {
+		zUpdate.Eval(
+			ctx,
+			pipe,
+			[]string{u.Key, u.DistinctKey, u.ChangedAtKey, u.JournalKey},
+			u.Score, u.Member, u.Mode.String(), u.ChangedAt,
+		)
+
+		if !u.ExpireAt.IsZero() {
+			pipe.ExpireAt(ctx, u.Key, u.ExpireAt)
+			pipe.ExpireAt(ctx, u.DistinctKey, u.ExpireAt)
+			pipe.ExpireAt(ctx, u.ChangedAtKey, u.ExpireAt)
+		}
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("[Cache] Piped sorted set update of %d members failed. err: %v", len(updates), err)
+		return err
+	}
+
+	return nil
+}
+
+// ZRangeByScore returns at most count members scoring between min and max, lowest first
+func (r *Redis) ZRangeByScore(ctx context.Context, key string, min, max string, count int64) ([]string, error) {
+	members, err := r.Client.ZRangeByScore(ctx, key, &redis.ZRangeBy{Min: min, Max: max, Count: count}).Result()
+	if err != nil {
+		log.Printf("[Cache] Failed to ZRANGEBYSCORE key %s [%s:%s]: %v\n", key, min, max, err)
+		return nil, err
+	}
+
+	return members, nil
+}
+
+// journalStart is the first entry of a journal, the updates follow four values each
+const journalStart = "start"
+
+// StartJournal opens an empty journal in key, which the updates of the ranking it belongs to
+// are appended to until SwapRanking replays them. It lapses after ttl so a rebuild that never
+// finishes does not journal forever.
+func (r *Redis) StartJournal(ctx context.Context, key string, ttl time.Duration) error {
+	_, err := r.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		pipe.RPush(ctx, key, journalStart)
+		pipe.PExpire(ctx, key, ttl)
+		return nil
+	})
+	if err != nil {
+		log.Printf("[Cache] Failed to start journal %s: %v\n", key, err)
+		return err
+	}
+
+	return nil
+}
+
+// swapRanking renames the rebuilt keys in KEYS[1..3] over the live ones in KEYS[4..6], deleting
+// a live key whose rebuilt one is missing, then replays the journal in KEYS[7] onto them and
+// drops it. Nothing is swapped unless ARGV[1] holds the lock in KEYS[8] under token ARGV[2].
+// It returns how many updates were replayed, -1 when the journal had lapsed and -2 when the
+// lock was not held.
+var swapRanking = redis.NewScript(zUpdateFunc + `
+	if redis.call('HGET', KEYS[8], 'owner') ~= ARGV[1] or redis.call('HGET', KEYS[8], 'token') ~= ARGV[2] then
+		return -2
+	end
+
+	for i = 1, 3 do
+		if redis.call('EXISTS', KEYS[i]) == 1 then
+			redis.call('RENAME', KEYS[i], KEYS[i + 3])
+		else
+			redis.call('DEL', KEYS[i + 3])
+		end
+	end
+
+	if redis.call('EXISTS', KEYS[7]) == 0 then
+		return -1
+	end
+
+	local journal = redis.call('LRANGE', KEYS[7], 1, -1)
+	for i = 1, #journal, 4 do
+		update(KEYS[4], KEYS[5], KEYS[6], journal[i], journal[i + 1], journal[i + 2], journal[i + 3])
+	end
+
+	redis.call('DEL', KEYS[7])
+	return #journal / 4
+`)
+
+// SwapRanking swaps a rebuilt ranking in for the live one in a single step, replaying the
+// updates journaled since the rebuild started so none are lost to it. replayed is -1 when the
+// journal lapsed before the swap, in which case updates may have been lost. ErrLockLost is
+// returned, and nothing swapped, unless the swap's lock owner still holds its lock.
+func (r *Redis) SwapRanking(ctx context.Context, swap ZSwap) (replayed int64, err error) {
+	replayed, err = swapRanking.Run(ctx, r.Client, []string{
+		swap.RebuiltKey, swap.RebuiltDistinctKey, swap.RebuiltChangedAtKey,
+		swap.Key, swap.DistinctKey, swap.ChangedAtKey,
+		swap.JournalKey,
+		swap.LockKey,
+	}, swap.LockOwner, swap.LockToken).Int64()
+	if err != nil {
+		log.Printf("[Cache] Failed to swap ranking %s: %v\n", swap.Key, err)
+		return 0, err
+	}
+
+	if replayed == -2 {
+		return 0, ErrLockLost
+	}
+
+	return replayed, nil
+}
+
+func (r *Redis) ExpireAt(ctx context.Context, key string, at time.Time) error {
+	if err := r.Client.ExpireAt(ctx, key, at).Err(); err != nil {
+		log.Printf("[Cache] Failed to set expiry of key %s: %v\n", key, err)
+		return err
+	}
+
+	return nil
+}
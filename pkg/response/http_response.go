@@ -58,3 +58,38 @@ func NewPaginationMeta(page, limit int, total int64) PaginationMeta {
 		TotalPages: totalPages,
 	}
 }
+
+// CursorMeta is the metadata of a keyset paginated response, NextCursor is empty on the last page
+type CursorMeta struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// NewCursorMeta creates cursor pagination metadata
+func NewCursorMeta(limit int, nextCursor string) CursorMeta {
+	return CursorMeta{
+		Limit:      limit,
+		NextCursor: nextCursor,
+		HasMore:    nextCursor != "",
+	}
+}
+
+// BatchMeta is the metadata of a batch response, counting its items by outcome
+type BatchMeta struct {
+	Total    int            `json:"total"`
+	Statuses map[string]int `json:"statuses"`
+}
+
+// NewBatchMeta creates batch metadata from the outcome of every item
+func NewBatchMeta(statuses []string) BatchMeta {
+	counts := make(map[string]int)
+	for _, status := range statuses {
+		counts[status]++
+	}
+
+	return BatchMeta{
+		Total:    len(statuses),
+		Statuses: counts,
+	}
+}
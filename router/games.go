@@ -0,0 +1,312 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"gaming-leaderboard/constants"
+	gamesSvc "gaming-leaderboard/internal/games/service"
+	"gaming-leaderboard/internal/models"
+	"gaming-leaderboard/pkg/apperror"
+	"gaming-leaderboard/pkg/db/postgres"
+	onewrelic "gaming-leaderboard/pkg/newrelic"
+	"gaming-leaderboard/pkg/redis"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tenant is one game's API, served from the game's schema and Redis key prefix by its own
+// services and worker
+type tenant struct {
+	game   models.Game
+	engine *gin.Engine
+}
+
+// apiKey is an API key seen recently, it is checked against the games table again once it
+// is a minute old so keys rotated on another instance stop working
+type apiKey struct {
+	tenant    *tenant
+	checkedAt time.Time
+}
+
+// rejectedKey is an API key refused recently, refused again without a lookup until it is
+// constants.RejectedAPIKeyTTL old
+type rejectedKey struct {
+	err        apperror.Error
+	rejectedAt time.Time
+}
+
+// poolSize caps the connections of each game's own schema, which every game opens on top of
+// the main cluster
+type poolSize struct {
+	maxOpen int
+	maxIdle int
+}
+
+// gameDirectory looks up the registered games, which GamesService does from the games table
+type gameDirectory interface {
+	AllGames(ctx context.Context) ([]*models.Game, apperror.Error)
+	Authenticate(ctx context.Context, apiKey string) (models.Game, apperror.Error)
+}
+
+// tenants hosts every game and routes requests to the game their API key belongs to. Games are
+// started off the request path, t.mu only ever guards the maps.
+type tenants struct {
+	ctx      context.Context
+	games    gameDirectory
+	poolSize poolSize
+
+	mu       sync.Mutex
+	byGame   map[int]*tenant
+	byKey    map[string]apiKey      // by API key hash
+	rejected map[string]rejectedKey // by API key hash
+	starting map[int]bool
+	clusters map[string]*postgres.DbCluster
+}
+
+func newTenants(ctx context.Context, games gameDirectory, poolSize poolSize) *tenants {
+	return &tenants{
+		ctx:      ctx,
+		games:    games,
+		poolSize: poolSize,
+		byGame:   make(map[int]*tenant),
+		byKey:    make(map[string]apiKey),
+		rejected: make(map[string]rejectedKey),
+		starting: make(map[int]bool),
+		clusters: make(map[string]*postgres.DbCluster),
+	}
+}
+
+// HostAll starts every registered game, so their workers run before any request comes in
+func (t *tenants) HostAll() error {
+	games, cusErr := t.games.AllGames(t.ctx)
+	if cusErr.Exists() {
+		return cusErr
+	}
+
+	for _, game := range games {
+		if _, err := t.host(*game); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Reload starts hosting a game created or changed on this instance, and makes its API keys be
+// checked again on their next use
+func (t *tenants) Reload(game models.Game) {
+	t.mu.Lock()
+	for hash, key := range t.byKey {
+		if key.tenant.game.ID == game.ID {
+			delete(t.byKey, hash)
+		}
+	}
+	t.mu.Unlock()
+
+	if _, ok := t.hosted(game); !ok {
+		t.startHosting(game)
+	}
+}
+
+// Serve hands a request to the API of the game its API key belongs to. Requests without an
+// API key are served by the default game, as they were before games existed, while an API key
+// no game holds is refused.
+func (t *tenants) Serve(c *gin.Context) {
+	tenant, cusErr := t.resolveDefault()
+	if key := c.GetHeader(constants.APIKeyHeader); key != "" {
+		tenant, cusErr = t.resolve(c, key)
+	}
+	if cusErr.Exists() {
+		cusErr.AbortWithError(c)
+		return
+	}
+
+	tenant.engine.ServeHTTP(c.Writer, c.Request)
+}
+
+// resolve returns the hosted game an API key belongs to. A game that is not hosted yet is
+// started in the background and the request refused until it is.
+func (t *tenants) resolve(ctx context.Context, key string) (*tenant, apperror.Error) {
+	hash := gamesSvc.HashAPIKey(key)
+	now := time.Now()
+
+	t.mu.Lock()
+	seen, known := t.byKey[hash]
+	rejected, refused := t.rejected[hash]
+	t.mu.Unlock()
+
+	if known && now.Sub(seen.checkedAt) < constants.OneMinute {
+		return seen.tenant, apperror.Error{}
+	}
+
+	if refused && now.Sub(rejected.rejectedAt) < constants.RejectedAPIKeyTTL {
+		return nil, rejected.err
+	}
+
+	game, cusErr := t.games.Authenticate(ctx, key)
+	if cusErr.Exists() {
+		if cusErr.StatusCode() == http.StatusUnauthorized {
+			t.reject(hash, cusErr, now)
+		}
+		return nil, cusErr
+	}
+
+	tenant, ok := t.hosted(game)
+	if !ok {
+		t.startHosting(game)
+		return nil, apperror.New(
+			fmt.Errorf("game %s is starting, please try again shortly", game.Slug),
+			http.StatusServiceUnavailable,
+		)
+	}
+
+	t.mu.Lock()
+	delete(t.rejected, hash)
+	t.byKey[hash] = apiKey{tenant: tenant, checkedAt: now}
+	t.mu.Unlock()
+
+	return tenant, apperror.Error{}
+}
+
+// resolveDefault returns the hosted default game, the one served from the public schema
+func (t *tenants) resolveDefault() (*tenant, apperror.Error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, hosted := range t.byGame {
+		if hosted.game.SchemaName == constants.PublicSchema {
+			return hosted, apperror.Error{}
+		}
+	}
+
+	return nil, apperror.New(fmt.Errorf("default game is not hosted"), http.StatusServiceUnavailable)
+}
+
+// reject remembers that an API key was refused. Expired entries are swept once the set is
+// full, and nothing more is remembered while it stays full.
+func (t *tenants) reject(hash string, err apperror.Error, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.rejected) >= constants.MaxRejectedAPIKeys {
+		for seen, rejected := range t.rejected {
+			if now.Sub(rejected.rejectedAt) >= constants.RejectedAPIKeyTTL {
+				delete(t.rejected, seen)
+			}
+		}
+	}
+
+	if len(t.rejected) < constants.MaxRejectedAPIKeys {
+		t.rejected[hash] = rejectedKey{err: err, rejectedAt: now}
+	}
+}
+
+// hosted returns the running tenant of a game, updated to game, if it is hosted
+func (t *tenants) hosted(game models.Game) (*tenant, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	current, ok := t.byGame[game.ID]
+	if ok {
+		current.game = game
+	}
+
+	return current, ok
+}
+
+// startHosting hosts a game in the background unless it is already being started
+func (t *tenants) startHosting(game models.Game) {
+	t.mu.Lock()
+	if t.starting[game.ID] {
+		t.mu.Unlock()
+		return
+	}
+	t.starting[game.ID] = true
+	t.mu.Unlock()
+
+	go func() {
+		defer func() {
+			t.mu.Lock()
+			delete(t.starting, game.ID)
+			t.mu.Unlock()
+		}()
+
+		// Opening the game's connections panics when its servers cannot be reached
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("[ERROR] game hosting panicked | game_id=%d | err=%v", game.ID, r)
+			}
+		}()
+
+		if _, err := t.host(game); err != nil {
+			log.Printf("[WARN] game hosting failed | game_id=%d | err=%v", game.ID, err)
+		}
+	}()
+}
+
+// host returns the running tenant of a game, starting it when the game is not hosted yet. The
+// game's boards are its leaderboard definitions, which its services reload themselves. Callers
+// other than HostAll go through startHosting, so a game is never started twice.
+func (t *tenants) host(game models.Game) (*tenant, error) {
+	if current, ok := t.hosted(game); ok {
+		return current, nil
+	}
+
+	db, err := t.cluster(game)
+	if err != nil {
+		return nil, err
+	}
+
+	var cache redis.Cache = redis.GetClient()
+	if game.SchemaName != constants.PublicSchema {
+		cache = redis.NewPrefixed(cache, fmt.Sprintf(constants.GameKeyPrefixFormat, game.Slug))
+	}
+
+	engine := gin.New()
+	registerGameRoutes(t.ctx, engine, game, db, cache)
+
+	hosted := &tenant{game: game, engine: engine}
+	t.mu.Lock()
+	t.byGame[game.ID] = hosted
+	t.mu.Unlock()
+
+	log.Printf("[INFO] game hosted | game_id=%d | slug=%s | schema=%s", game.ID, game.Slug, game.SchemaName)
+	return hosted, nil
+}
+
+// cluster returns the connections to a game's schema, opened once and kept for the process.
+// The game in the public schema shares the main cluster, the others get pools capped at
+// t.poolSize so connections grow slowly with the number of games.
+func (t *tenants) cluster(game models.Game) (*postgres.DbCluster, error) {
+	if game.SchemaName == constants.PublicSchema {
+		return postgres.GetCluster().DbCluster, nil
+	}
+
+	t.mu.Lock()
+	db, ok := t.clusters[game.SchemaName]
+	t.mu.Unlock()
+	if ok {
+		return db, nil
+	}
+
+	db, err := postgres.GetCluster().ForSchema(
+		t.ctx,
+		game.SchemaName,
+		t.poolSize.maxOpen,
+		t.poolSize.maxIdle,
+		onewrelic.NRApp,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	t.clusters[game.SchemaName] = db
+	t.mu.Unlock()
+	return db, nil
+}
@@ -0,0 +1,164 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gaming-leaderboard/constants"
+	gamesSvc "gaming-leaderboard/internal/games/service"
+	"gaming-leaderboard/internal/models"
+	"gaming-leaderboard/pkg/apperror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// directory holds games by the hash of their API key, as the games table does, and counts the
+// lookups made
+type directory struct {
+	byHash  map[string]models.Game
+	lookups int
+}
+
+func (d *directory) AllGames(ctx context.Context) ([]*models.Game, apperror.Error) {
+	games := make([]*models.Game, 0, len(d.byHash))
+	for _, game := range d.byHash {
+		game := game
+		games = append(games, &game)
+	}
+
+	return games, apperror.Error{}
+}
+
+func (d *directory) Authenticate(ctx context.Context, apiKey string) (models.Game, apperror.Error) {
+	d.lookups++
+
+	game, ok := d.byHash[gamesSvc.HashAPIKey(apiKey)]
+	if !ok {
+		return models.Game{}, apperror.New(fmt.Errorf("invalid api key"), http.StatusUnauthorized)
+	}
+
+	return game, apperror.Error{}
+}
+
+// hostedTenants serves each game from an engine answering with the game's schema, without
+// opening its connections
+func hostedTenants(games map[string]models.Game, hosted ...models.Game) (*tenants, *directory) {
+	dir := &directory{byHash: make(map[string]models.Game)}
+	for key, game := range games {
+		dir.byHash[gamesSvc.HashAPIKey(key)] = game
+	}
+
+	t := newTenants(context.Background(), dir, poolSize{})
+	for _, game := range hosted {
+		engine := gin.New()
+		schema := game.SchemaName
+		engine.GET("/api/v1/leaderboard/top", func(c *gin.Context) {
+			c.String(http.StatusOK, schema)
+		})
+		t.byGame[game.ID] = &tenant{game: game, engine: engine}
+	}
+
+	return t, dir
+}
+
+func TestTenantsServe(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	defaultGame := models.Game{ID: 1, Slug: "classic", SchemaName: constants.PublicSchema}
+	arcade := models.Game{ID: 2, Slug: "arcade", SchemaName: fmt.Sprintf(constants.GameSchemaFormat, "arcade")}
+	games := map[string]models.Game{"default-key": defaultGame, "arcade-key": arcade}
+
+	tests := []struct {
+		name       string
+		hosted     []models.Game
+		apiKey     string
+		wantStatus int
+		wantSchema string
+	}{
+		{
+			name:       "known key is served from its game's schema",
+			hosted:     []models.Game{defaultGame, arcade},
+			apiKey:     "arcade-key",
+			wantStatus: http.StatusOK,
+			wantSchema: arcade.SchemaName,
+		},
+		{
+			name:       "default game's key is served from the public schema",
+			hosted:     []models.Game{defaultGame, arcade},
+			apiKey:     "default-key",
+			wantStatus: http.StatusOK,
+			wantSchema: constants.PublicSchema,
+		},
+		{
+			name:       "unknown key is refused",
+			hosted:     []models.Game{defaultGame, arcade},
+			apiKey:     "stolen-key",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "no key is served by the default game",
+			hosted:     []models.Game{defaultGame, arcade},
+			wantStatus: http.StatusOK,
+			wantSchema: constants.PublicSchema,
+		},
+		{
+			name:       "no key is refused while the default game is not hosted",
+			hosted:     []models.Game{arcade},
+			wantStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenants, _ := hostedTenants(games, tt.hosted...)
+			engine := gin.New()
+			engine.Any("/api/v1/*path", tenants.Serve)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/leaderboard/top", nil)
+			if tt.apiKey != "" {
+				req.Header.Set(constants.APIKeyHeader, tt.apiKey)
+			}
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantSchema != "" && rec.Body.String() != tt.wantSchema {
+				t.Errorf("served from schema %q, want %q", rec.Body.String(), tt.wantSchema)
+			}
+		})
+	}
+}
+
+func TestTenantsResolveRemembersKeys(t *testing.T) {
+	arcade := models.Game{ID: 2, Slug: "arcade", SchemaName: fmt.Sprintf(constants.GameSchemaFormat, "arcade")}
+	tenants, dir := hostedTenants(map[string]models.Game{"arcade-key": arcade}, arcade)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		tenant, cusErr := tenants.resolve(ctx, "arcade-key")
+		if cusErr.Exists() {
+			t.Fatalf("resolve known key: %v", cusErr)
+		}
+		if tenant.game.ID != arcade.ID {
+			t.Errorf("resolved game %d, want %d", tenant.game.ID, arcade.ID)
+		}
+
+		if _, cusErr := tenants.resolve(ctx, "stolen-key"); cusErr.StatusCode() != http.StatusUnauthorized {
+			t.Errorf("resolve unknown key = %d, want %d", cusErr.StatusCode(), http.StatusUnauthorized)
+		}
+	}
+
+	// Each key is looked up once, then answered from memory until it is rechecked
+	if dir.lookups != 2 {
+		t.Errorf("looked up %d keys, want 2", dir.lookups)
+	}
+
+	if _, known := tenants.byKey["arcade-key"]; known {
+		t.Errorf("API key kept in plain text, want only its hash")
+	}
+}
@@ -2,17 +2,28 @@ package router
 
 import (
 	"context"
+	"gaming-leaderboard/constants"
 	"gaming-leaderboard/internal/controller"
 	gameSessionsRepo "gaming-leaderboard/internal/game_sessions/repository"
 	gameSessionsSvc "gaming-leaderboard/internal/game_sessions/service"
+	"gaming-leaderboard/internal/game_sessions/service/validators"
+	gamesRepo "gaming-leaderboard/internal/games/repository"
+	gamesSvc "gaming-leaderboard/internal/games/service"
 	leaderboardRepo "gaming-leaderboard/internal/leaderboard/repository"
 	leaderboardSvc "gaming-leaderboard/internal/leaderboard/service"
+	"gaming-leaderboard/internal/models"
+	teamsRepo "gaming-leaderboard/internal/teams/repository"
+	teamsSvc "gaming-leaderboard/internal/teams/service"
+	usersRepo "gaming-leaderboard/internal/users/repository"
+	usersSvc "gaming-leaderboard/internal/users/service"
 	"gaming-leaderboard/middleware"
 	"gaming-leaderboard/pkg/db/postgres"
 	"gaming-leaderboard/pkg/redis"
+	"log"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
 )
 
 func RegisterPublicRoutes(ctx context.Context, engine *gin.Engine) {
@@ -22,22 +33,139 @@ func RegisterPublicRoutes(ctx context.Context, engine *gin.Engine) {
 		})
 	}))
 
+	gamesService := gamesSvc.NewGamesService(
+		gamesRepo.NewGamesRepository(postgres.GetCluster().DbCluster),
+		loadTeamBoards(),
+	)
+
+	// The data from before games existed stays in the public schema, served as the default game
+	if err := gamesService.EnsureDefaultGame(
+		ctx,
+		viper.GetString("games.default.slug"),
+		viper.GetString("games.default.name"),
+		viper.GetString("games.default.apiKey"),
+	); err != nil {
+		log.Panicf("Invalid default game: %v", err)
+	}
+
+	games := newTenants(ctx, gamesService, loadTenantPoolSize())
+	if err := games.HostAll(); err != nil {
+		log.Panicf("Unable to host games: %v", err)
+	}
+
+	gamesController := controller.NewGamesController(
+		gamesService,
+		games,
+	)
+
+	// Games are managed apart from their APIs, which only ever see their own game
+	admin := engine.Group("/admin/v1/",
+		middleware.CORSMiddleware(),
+		middleware.NewRelicMiddleware(),
+		middleware.SanitizeQueryParams(),
+		middleware.RequestLogger(),
+		middleware.AdminAuth())
+	{
+		admin.POST("/games", gamesController.CreateGame)
+		admin.GET("/games", gamesController.ListGames)
+		admin.GET("/games/:game_id", gamesController.GetGame)
+		admin.PATCH("/games/:game_id", gamesController.UpdateGame)
+		admin.POST("/games/:game_id/rotate-key", gamesController.RotateAPIKey)
+	}
+
+	// Every other request is served by the game its API key belongs to, or by the default game
+	// when it carries none
+	engine.Any("/api/v1/*path", middleware.CORSMiddleware(), games.Serve)
+}
+
+// registerGameRoutes serves one game's API from the game's schema and cache, ranking the
+// game's leaderboard definitions
+func registerGameRoutes(
+	ctx context.Context,
+	engine *gin.Engine,
+	game models.Game,
+	db *postgres.DbCluster,
+	cache redis.Cache,
+) {
 	// move to initialization
-	leaderboardRepository := leaderboardRepo.NewLeaderboardRepository(postgres.GetCluster().DbCluster)
-	gameSessionsRepository := gameSessionsRepo.NewGameSessionsRepository(postgres.GetCluster().DbCluster)
+	leaderboardRepository := leaderboardRepo.NewLeaderboardRepository(db)
+	seasonsRepository := leaderboardRepo.NewSeasonsRepository(db)
+	seasonStandingsRepository := leaderboardRepo.NewSeasonStandingsRepository(db)
+	rankHistoryRepository := leaderboardRepo.NewRankHistoryRepository(db)
+	usersRepository := usersRepo.NewUsersRepository(db)
+	friendshipsRepository := usersRepo.NewFriendshipsRepository(db)
+	teamsRepository := teamsRepo.NewTeamsRepository(db)
+	teamLeaderboardRepository := leaderboardRepo.NewTeamLeaderboardRepository(db)
+	definitionsRepository := leaderboardRepo.NewDefinitionsRepository(db)
+	bansRepository := leaderboardRepo.NewBansRepository(db)
+	moderationActionsRepository := leaderboardRepo.NewModerationActionsRepository(db)
+	gameSessionsRepository := gameSessionsRepo.NewGameSessionsRepository(db)
 
-	leaderboardService := leaderboardSvc.NewLeaderboardService(leaderboardRepository, redis.GetClient())
+	leaderboardService := leaderboardSvc.NewLeaderboardService(
+		leaderboardRepository,
+		seasonsRepository,
+		seasonStandingsRepository,
+		rankHistoryRepository,
+		bansRepository,
+		usersRepository,
+		friendshipsRepository,
+		teamLeaderboardRepository,
+		definitionsRepository,
+		cache,
+		loadLeaderboardConfig(game),
+	)
 	leaderboardWorker := leaderboardSvc.NewLeaderboardWorker(
 		leaderboardRepository,
 		leaderboardService,
-		3*time.Minute,
+		loadWorkerConfig(),
 	)
 
+	// Boards are ranked as the game's leaderboard definitions declare, from before the worker starts
+	leaderboardService.WatchBoards(ctx)
 	leaderboardWorker.Start(ctx)
 
 	gameSessionsService := gameSessionsSvc.NewGameSessionsService(
 		gameSessionsRepository,
+		usersRepository,
+		teamsRepository,
+		bansRepository,
+		moderationActionsRepository,
+		leaderboardService,
+		leaderboardWorker,
+		loadValidators(gameSessionsRepository, cache),
+		loadSubmitConfig(game),
+	)
+
+	usersService := usersSvc.NewUsersService(usersRepository, friendshipsRepository, cache)
+
+	usersController := controller.NewUsersController(
+		usersService,
+	)
+
+	teamsService := teamsSvc.NewTeamsService(teamsRepository)
+
+	teamsController := controller.NewTeamsController(
+		teamsService,
 		leaderboardService,
+	)
+
+	seasonsController := controller.NewSeasonsController(
+		leaderboardService,
+		leaderboardWorker,
+	)
+
+	sessionsController := controller.NewSessionsController(
+		gameSessionsService,
+	)
+
+	definitionsService := leaderboardSvc.NewDefinitionsService(leaderboardService, leaderboardWorker)
+
+	boardsController := controller.NewBoardsController(
+		definitionsService,
+	)
+
+	adminController := controller.NewAdminController(
+		gameSessionsService,
 		leaderboardWorker,
 	)
 
@@ -55,9 +183,209 @@ func RegisterPublicRoutes(ctx context.Context, engine *gin.Engine) {
 
 		leaderboard := apiV1.Group("/leaderboard")
 		{
+			leaderboard.GET("", controller.GetLeaderboard)
 			leaderboard.POST("/submit", controller.CreateScore)
+			leaderboard.POST("/submit/batch", controller.CreateScores)
 			leaderboard.GET("/top", controller.GetTopLeaderboard)
+			leaderboard.GET("/tiers", controller.GetBoardTiers)
 			leaderboard.GET("/rank/:user_id", controller.GetUserRankByUserID)
+			leaderboard.GET("/rank/:user_id/around", controller.GetUserNeighbours)
+			leaderboard.GET("/friends/:user_id", controller.GetFriendsLeaderboard)
 		}
+
+		users := apiV1.Group("/users")
+		{
+			users.POST("", usersController.CreateUser)
+			users.GET("", usersController.SearchUsers)
+			users.GET("/:user_id", usersController.GetUser)
+			users.PATCH("/:user_id", usersController.UpdateUser)
+			users.GET("/:user_id/friends", usersController.ListFriends)
+			users.POST("/:user_id/friends", usersController.AddFriend)
+			users.DELETE("/:user_id/friends/:friend_id", usersController.RemoveFriend)
+			users.GET("/:user_id/rank-history", controller.GetRankHistory)
+		}
+
+		// Team membership decides whose sessions count for a team, so it is managed by admins
+		teams := apiV1.Group("/teams")
+		{
+			teams.GET("/top", teamsController.GetTopTeams)
+			teams.GET("/:team_id", teamsController.GetTeam)
+			teams.GET("/:team_id/rank", teamsController.GetTeamRank)
+		}
+
+		sessions := apiV1.Group("/sessions")
+		{
+			sessions.POST("/start", sessionsController.StartSession)
+		}
+
+		seasons := apiV1.Group("/seasons")
+		{
+			seasons.GET("/:season_id/leaderboard", seasonsController.GetSeasonLeaderboard)
+		}
+
+		admin := apiV1.Group("/admin", middleware.AdminAuth())
+		{
+			admin.POST("/seasons", seasonsController.OpenSeason)
+			admin.POST("/seasons/:season_id/close", seasonsController.CloseSeason)
+			admin.GET("/boards", boardsController.ListBoards)
+			admin.POST("/boards", boardsController.CreateBoard)
+			admin.GET("/boards/:board_id", boardsController.GetBoard)
+			admin.PUT("/boards/:board_id", boardsController.UpdateBoard)
+			admin.DELETE("/boards/:board_id", boardsController.DeleteBoard)
+			admin.POST("/leaderboard/recalculate", adminController.RecalculateLeaderboard)
+			admin.GET("/leaderboard/worker", adminController.GetWorkerLeadership)
+			admin.GET("/sessions/quarantined", adminController.GetQuarantinedSessions)
+			admin.POST("/sessions/:session_id/review", adminController.ReviewSession)
+			admin.POST("/sessions/:session_id/void", adminController.VoidSession)
+			admin.POST("/sessions/:session_id/restore", adminController.RestoreSession)
+			admin.GET("/bans", adminController.GetBans)
+			admin.POST("/users/:user_id/ban", adminController.BanUser)
+			admin.POST("/users/:user_id/unban", adminController.UnbanUser)
+			admin.GET("/moderation/log", adminController.GetModerationLog)
+			admin.POST("/teams", teamsController.CreateTeam)
+			admin.POST("/teams/:team_id/members", teamsController.AddMember)
+			admin.DELETE("/teams/:team_id/members/:user_id", teamsController.RemoveMember)
+		}
+	}
+}
+
+// loadLeaderboardConfig reads the leaderboard config. The boards seeding the game's leaderboard
+// definitions are the game's own when it has any, else the configured ones.
+func loadLeaderboardConfig(game models.Game) leaderboardSvc.Config {
+	location, err := time.LoadLocation(viper.GetString("leaderboard.timezone"))
+	if err != nil {
+		log.Panicf("Invalid leaderboard timezone: %v", err)
+	}
+
+	var boards []models.Board
+	if err := viper.UnmarshalKey("leaderboard.boards", &boards); err != nil {
+		log.Panicf("Invalid leaderboard boards: %v", err)
+	}
+
+	if len(game.Boards) > 0 {
+		boards = append([]models.Board(nil), game.Boards...)
+	}
+
+	if err := leaderboardSvc.ValidateBoards(boards); err != nil {
+		log.Panicf("Invalid leaderboard boards: %v", err)
+	}
+
+	teamBoards := loadTeamBoards()
+	if err := leaderboardSvc.ValidateTeamBoards(teamBoards, boards); err != nil {
+		log.Panicf("Invalid leaderboard team boards: %v", err)
+	}
+
+	return leaderboardSvc.Config{
+		Boards:     boards,
+		TeamBoards: teamBoards,
+		Location:   location,
+		Retention: map[string]time.Duration{
+			constants.WindowDaily:   viper.GetDuration("leaderboard.retention.daily"),
+			constants.WindowWeekly:  viper.GetDuration("leaderboard.retention.weekly"),
+			constants.WindowMonthly: viper.GetDuration("leaderboard.retention.monthly"),
+		},
+		HistoryRetention:  viper.GetDuration("leaderboard.history.retention"),
+		ReconcileInterval: viper.GetDuration("leaderboard.reconcileInterval"),
+	}
+}
+
+// loadTeamBoards reads the configured team boards, which seed each game's team board definitions
+func loadTeamBoards() []models.TeamBoard {
+	var teamBoards []models.TeamBoard
+	if err := viper.UnmarshalKey("leaderboard.teamBoards", &teamBoards); err != nil {
+		log.Panicf("Invalid leaderboard team boards: %v", err)
+	}
+
+	return teamBoards
+}
+
+func loadWorkerConfig() leaderboardSvc.WorkerConfig {
+	config := leaderboardSvc.WorkerConfig{
+		PollInterval: viper.GetDuration("leaderboard.worker.pollInterval"),
+		Debounce:     viper.GetDuration("leaderboard.worker.debounce"),
+		MaxLatency:   viper.GetDuration("leaderboard.worker.maxLatency"),
+		LockTTL:      viper.GetDuration("leaderboard.worker.lockTTL"),
+	}
+
+	if config.PollInterval <= 0 {
+		log.Panicf("Invalid leaderboard worker poll interval: %v", config.PollInterval)
+	}
+
+	// The lock is renewed every third of its ttl, so it must leave room for a few renewals
+	if config.LockTTL < 3*time.Second {
+		log.Panicf("Invalid leaderboard worker lock ttl: %v", config.LockTTL)
+	}
+
+	return config
+}
+
+// loadTenantPoolSize reads the pool size of the games with a schema of their own
+func loadTenantPoolSize() poolSize {
+	size := poolSize{
+		maxOpen: viper.GetInt("postgresql.tenantMaxOpenConns"),
+		maxIdle: viper.GetInt("postgresql.tenantMaxIdleConns"),
+	}
+
+	if size.maxOpen <= 0 || size.maxIdle < 0 || size.maxIdle > size.maxOpen {
+		log.Panicf("Invalid tenant pool size: max open %d, max idle %d", size.maxOpen, size.maxIdle)
+	}
+
+	return size
+}
+
+// loadSubmitConfig reads the submission config, session tokens being signed with a secret of
+// the game's own so they are not accepted by another game
+func loadSubmitConfig(game models.Game) gameSessionsSvc.Config {
+	config := gameSessionsSvc.Config{
+		MaxBatchSize: viper.GetInt("leaderboard.submit.maxBatchSize"),
+		TokenSecret:  []byte(viper.GetString("sessions.secret")),
+		TokenTTL:     viper.GetDuration("sessions.tokenTTL"),
+	}
+
+	if config.MaxBatchSize <= 0 {
+		log.Panicf("Invalid leaderboard submit max batch size: %d", config.MaxBatchSize)
+	}
+
+	if len(config.TokenSecret) == 0 {
+		log.Panicf("Missing sessions secret")
+	}
+	if string(config.TokenSecret) == constants.PlaceholderSecret {
+		log.Panicf("Sessions secret must not be the placeholder %q", constants.PlaceholderSecret)
+	}
+	config.TokenSecret = append(config.TokenSecret, ":"+game.Slug...)
+
+	if config.TokenTTL <= 0 {
+		log.Panicf("Invalid sessions token ttl: %v", config.TokenTTL)
+	}
+
+	return config
+}
+
+func loadValidators(gameSessionsRepository *gameSessionsRepo.GameSessionsRepository, cache redis.Cache) validators.Chain {
+	var limits map[string]validators.ScoreLimit
+	if err := viper.UnmarshalKey("antiCheat.scoreLimits", &limits); err != nil {
+		log.Panicf("Invalid anti-cheat score limits: %v", err)
+	}
+
+	var maxPerSecond map[string]float64
+	if err := viper.UnmarshalKey("antiCheat.maxScorePerSecond", &maxPerSecond); err != nil {
+		log.Panicf("Invalid anti-cheat max score per second: %v", err)
+	}
+
+	// Cheap checks run first, the rate limit before anything reads the database
+	return validators.Chain{
+		validators.ScoreRange{Limits: limits},
+		validators.SubmissionRate{
+			Cache:  cache,
+			Limit:  viper.GetInt64("antiCheat.rateLimit.submissions"),
+			Window: viper.GetDuration("antiCheat.rateLimit.window"),
+		},
+		validators.ScoreRate{MaxPerSecond: maxPerSecond},
+		validators.Outlier{
+			History:    gameSessionsRepository,
+			ZScore:     viper.GetFloat64("antiCheat.outlier.zScore"),
+			MinSamples: viper.GetInt("antiCheat.outlier.minSamples"),
+			Samples:    viper.GetInt("antiCheat.outlier.samples"),
+		},
 	}
 }
@@ -1,20 +1,35 @@
+import os
 import requests
 import random
 import time
 
 API_BASE_URL = "http://localhost:8081/api/v1/leaderboard"
+SESSIONS_URL = "http://localhost:8081/api/v1/sessions"
+# requests without an API key are served by the default game, set GAME_APIKEY to test another
+HEADERS = {"X-API-Key": os.environ["GAME_APIKEY"]} if os.environ.get("GAME_APIKEY") else {}
 
 # Simulate score submission
 def submit_score(user_id):
     score = random.randint(100, 10000)
+    session = requests.post(
+        f"{SESSIONS_URL}/start",
+        headers=HEADERS,
+        json={"user_id": user_id, "game_mode": "solo"}
+    ).json()
     requests.post(
         f"{API_BASE_URL}/submit",
-        json={"user_id": user_id, "score": score}
+        headers=HEADERS,
+        json={
+            "user_id": user_id,
+            "score": score,
+            "game_mode": "solo",
+            "session_token": session["data"]["session_token"],
+        }
     )
 
 # Fetch top players
 def get_top_players():
-    requests.get(f"{API_BASE_URL}/top")
+    requests.get(f"{API_BASE_URL}/top", headers=HEADERS)
 
 while True:
     user_id = random.randint(1, 1000000)
@@ -1,3 +1,4 @@
+import os
 import requests
 import random
 import time
@@ -6,6 +7,9 @@ from datetime import datetime
 from typing import List, Dict
 
 API_BASE_URL = "http://localhost:8081/api/v1/leaderboard"
+SESSIONS_URL = "http://localhost:8081/api/v1/sessions"
+# requests without an API key are served by the default game, set GAME_APIKEY to test another
+HEADERS = {"X-API-Key": os.environ["GAME_APIKEY"]} if os.environ.get("GAME_APIKEY") else {}
 
 class LeaderboardTester:
     def __init__(self, base_url: str, worker_interval_minutes: int = 3):
@@ -18,7 +22,7 @@ class LeaderboardTester:
     def fetch_top_leaderboard(self) -> List[Dict]:
         """Fetch top 10 leaderboard"""
         try:
-            response = requests.get(f"{self.base_url}/top", timeout=10)
+            response = requests.get(f"{self.base_url}/top", headers=HEADERS, timeout=10)
             response.raise_for_status()
             data = response.json()
             
@@ -34,7 +38,7 @@ class LeaderboardTester:
     def fetch_user_rank(self, user_id: int) -> Dict:
         """Fetch rank for a specific user"""
         try:
-            response = requests.get(f"{self.base_url}/rank/{user_id}", timeout=10)
+            response = requests.get(f"{self.base_url}/rank/{user_id}", headers=HEADERS, timeout=10)
             response.raise_for_status()
             data = response.json()
             
@@ -50,14 +54,25 @@ class LeaderboardTester:
     def submit_score(self, user_id: int, score: int, game_mode: str = "solo") -> bool:
         """Submit a score for a user"""
         try:
+            # Scores are only accepted with the token of a started play session
+            session = requests.post(
+                f"{SESSIONS_URL}/start",
+                headers=HEADERS,
+                json={"user_id": user_id, "game_mode": game_mode},
+                timeout=10
+            )
+            session.raise_for_status()
+
             payload = {
                 "user_id": user_id,
                 "score": score,
-                "game_mode": game_mode  # Added required field
+                "game_mode": game_mode,  # Added required field
+                "session_token": session.json()["data"]["session_token"]
             }
             
             response = requests.post(
                 f"{self.base_url}/submit",
+                headers=HEADERS,
                 json=payload,
                 timeout=10
             )